@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// adminRoutes is served on its own listener, which should only be
+// reachable from a private network. Every route needs the admin key or a
+// client certificate, depending on how the listener is set up.
+func (app *application) adminRoutes() http.Handler {
+	mux := chi.NewRouter()
+
+	mux.Use(app.requestID)
+	mux.Use(app.logRequests)
+	mux.Use(middleware.Recoverer)
+	mux.Use(app.requireAdmin)
+	mux.NotFound(app.notFoundResponse)
+
+	mux.Get("/admin/polls", app.adminListPollsHandler)
+	mux.Post("/admin/polls/{pollID}/hide", app.adminHidePollHandler)
+	mux.Post("/admin/polls/{pollID}/unhide", app.adminUnhidePollHandler)
+	mux.Delete("/admin/polls/{pollID}", app.adminDeletePollHandler)
+	mux.Get("/admin/features", app.adminShowFeaturesHandler)
+	mux.Patch("/admin/features", app.adminUpdateFeaturesHandler)
+	mux.Get("/admin/limiter", app.adminShowLimiterHandler)
+	mux.Patch("/admin/limiter", app.adminUpdateLimiterHandler)
+
+	mux.Mount("/debug", middleware.Profiler())
+	mux.Method(http.MethodGet, "/v1/metrics", expvar.Handler())
+	mux.Method(http.MethodGet, "/metrics", app.prometheusHandler())
+
+	return mux
+}
+
+// requireAdmin checks the X-Admin-Key header when an admin key is set.
+// Client certificates are checked during the TLS handshake.
+func (app *application) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.config.admin.key != "" {
+			key := r.Header.Get("X-Admin-Key")
+			if subtle.ConstantTimeCompare([]byte(key), []byte(app.config.admin.key)) != 1 {
+				app.invalidAdminKeyResponse(w)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// adminServer returns nil when the admin API is switched off.
+func (app *application) adminServer() (*http.Server, error) {
+	if app.config.admin.addr == "" {
+		return nil, nil
+	}
+
+	tlsConfig, err := app.adminTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Server{
+		Addr:              app.config.admin.addr,
+		Handler:           app.adminRoutes(),
+		TLSConfig:         tlsConfig,
+		IdleTimeout:       app.config.http.idleTimeout,
+		ReadTimeout:       app.config.http.readTimeout,
+		ReadHeaderTimeout: app.config.http.readHeaderTimeout,
+		// profiles take as long as they're asked to
+		WriteTimeout: 0,
+	}, nil
+}
+
+// adminTLSConfig returns nil when the admin API serves plain HTTP. With a
+// client CA, only clients with a certificate it signed can connect.
+func (app *application) adminTLSConfig() (*tls.Config, error) {
+	if app.config.admin.tlsCert == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(app.config.admin.tlsCert, app.config.admin.tlsKey)
+	if err != nil {
+		return nil, fmt.Errorf("admin tls: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if app.config.admin.clientCA != "" {
+		pem, err := os.ReadFile(app.config.admin.clientCA)
+		if err != nil {
+			return nil, fmt.Errorf("admin client ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("admin client ca: no certificates found")
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
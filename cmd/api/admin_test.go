@@ -0,0 +1,168 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testAdminKey = "0123456789abcdef0123456789abcdef"
+
+func newAdminApp() *application {
+	a := &application{logger: app.logger, models: app.models}
+	a.config.admin.key = testAdminKey
+	a.config.limiter.rps = 2
+	a.config.limiter.burst = 4
+	a.config.limiter.enabled = true
+	a.config.features.registration = true
+	a.initRuntime()
+	return a
+}
+
+func Test_app_adminRoutes(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		key            string
+		expectedStatus int
+	}{
+		{"features with key", "/admin/features", testAdminKey, http.StatusOK},
+		{"features without key", "/admin/features", "", http.StatusUnauthorized},
+		{"features with wrong key", "/admin/features", "nope", http.StatusUnauthorized},
+		{"pprof with key", "/debug/pprof/", testAdminKey, http.StatusOK},
+		{"pprof without key", "/debug/pprof/", "", http.StatusUnauthorized},
+		{"metrics with key", "/metrics", testAdminKey, http.StatusOK},
+		{"unknown route without key", "/v1/polls", "", http.StatusUnauthorized},
+		{"unknown route with key", "/v1/polls", testAdminKey, http.StatusNotFound},
+	}
+
+	mux := newAdminApp().adminRoutes()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, test.path, nil)
+			if test.key != "" {
+				req.Header.Set("X-Admin-Key", test.key)
+			}
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func Test_app_adminTLSConfigRequiresClientCert(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeTestCert(t, dir, "ca", nil, nil)
+	writeTestCert(t, dir, "server", ca, caKey)
+	client, clientKey := writeTestCert(t, dir, "client", ca, caKey)
+
+	a := newAdminApp()
+	a.config.admin.key = ""
+	a.config.admin.tlsCert = filepath.Join(dir, "server.pem")
+	a.config.admin.tlsKey = filepath.Join(dir, "server-key.pem")
+	a.config.admin.clientCA = filepath.Join(dir, "ca.pem")
+
+	tlsConfig, err := a.adminTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewUnstartedServer(a.adminRoutes())
+	srv.TLS = tlsConfig
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	get := func(certs []tls.Certificate) (*http.Response, error) {
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: certs,
+		}}}
+		return c.Get(srv.URL + "/admin/features")
+	}
+
+	if _, err := get(nil); err == nil {
+		t.Error("expected a client without a certificate to be turned away")
+	}
+
+	res, err := get([]tls.Certificate{{Certificate: [][]byte{client.Raw}, PrivateKey: clientKey}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status code %d, but got %d", http.StatusOK, res.StatusCode)
+	}
+}
+
+// writeTestCert writes name.pem and name-key.pem to dir. The certificate is
+// self-signed when parent is nil, and valid for localhost.
+func writeTestCert(t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(filepath.Join(dir, name+".pem"), certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+"-key.pem"), keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key
+}
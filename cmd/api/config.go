@@ -0,0 +1,443 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ivcp/polls/internal/validator"
+	"gopkg.in/yaml.v3"
+)
+
+type config struct {
+	port int
+	env  string
+	db   struct {
+		dsn             string
+		maxConns        int
+		minConns        int
+		maxConnIdleTime time.Duration
+		maxConnLifetime time.Duration
+		autoMigrate     bool
+	}
+	http struct {
+		readTimeout       time.Duration
+		readHeaderTimeout time.Duration
+		writeTimeout      time.Duration
+		idleTimeout       time.Duration
+		shutdownTimeout   time.Duration
+	}
+	limiter struct {
+		rps     float64
+		burst   int
+		enabled bool
+	}
+	cors struct {
+		trustedOrigins stringList
+	}
+	jwt struct {
+		keys string
+		ttl  time.Duration
+	}
+	oidc struct {
+		issuer       string
+		clientID     string
+		clientSecret string
+		redirectURL  string
+	}
+	tracing struct {
+		endpoint    string
+		sampleRatio float64
+		stderr      bool
+	}
+	deletion struct {
+		gracePeriod   time.Duration
+		purgeInterval time.Duration
+	}
+	features struct {
+		registration bool
+		pollListing  bool
+		writeIns     bool
+	}
+	admin struct {
+		addr     string
+		key      string
+		tlsCert  string
+		tlsKey   string
+		clientCA string
+	}
+}
+
+// setting says where a flag can also be set from. Settings are applied in
+// order of precedence: defaults, the config file, env vars, then flags.
+type setting struct {
+	flag   string
+	key    string // in the config file
+	env    string
+	secret bool
+}
+
+var settings = []setting{
+	{flag: "port", key: "port", env: "SERVER_PORT"},
+	{flag: "env", key: "env", env: "SERVER_ENV"},
+	{flag: "db-dsn", key: "db.dsn", env: "DB_DSN", secret: true},
+	{flag: "db-max-conns", key: "db.max_conns", env: "DB_MAX_CONNS"},
+	{flag: "db-min-conns", key: "db.min_conns", env: "DB_MIN_CONNS"},
+	{flag: "db-max-conn-idle-time", key: "db.max_conn_idle_time", env: "DB_MAX_CONN_IDLE_TIME"},
+	{flag: "db-max-conn-lifetime", key: "db.max_conn_lifetime", env: "DB_MAX_CONN_LIFETIME"},
+	{flag: "auto-migrate", key: "db.auto_migrate", env: "AUTO_MIGRATE"},
+	{flag: "http-read-timeout", key: "http.read_timeout", env: "HTTP_READ_TIMEOUT"},
+	{flag: "http-read-header-timeout", key: "http.read_header_timeout", env: "HTTP_READ_HEADER_TIMEOUT"},
+	{flag: "http-write-timeout", key: "http.write_timeout", env: "HTTP_WRITE_TIMEOUT"},
+	{flag: "http-idle-timeout", key: "http.idle_timeout", env: "HTTP_IDLE_TIMEOUT"},
+	{flag: "shutdown-timeout", key: "http.shutdown_timeout", env: "SHUTDOWN_TIMEOUT"},
+	{flag: "limiter-rps", key: "limiter.rps", env: "LIMITER_RPS"},
+	{flag: "limiter-burst", key: "limiter.burst", env: "LIMITER_BURST"},
+	{flag: "limiter-enabled", key: "limiter.enabled", env: "LIMITER_ENABLED"},
+	{flag: "cors-trusted-origins", key: "cors.trusted_origins", env: "CORS_TRUSTED_ORIGINS"},
+	{flag: "jwt-keys", key: "jwt.keys", env: "JWT_KEYS", secret: true},
+	{flag: "jwt-ttl", key: "jwt.ttl", env: "JWT_TTL"},
+	{flag: "oidc-issuer", key: "oidc.issuer", env: "OIDC_ISSUER"},
+	{flag: "oidc-client-id", key: "oidc.client_id", env: "OIDC_CLIENT_ID"},
+	{flag: "oidc-client-secret", key: "oidc.client_secret", env: "OIDC_CLIENT_SECRET", secret: true},
+	{flag: "oidc-redirect-url", key: "oidc.redirect_url", env: "OIDC_REDIRECT_URL"},
+	{flag: "otel-endpoint", key: "tracing.endpoint", env: "OTEL_EXPORTER_OTLP_ENDPOINT"},
+	{flag: "trace-sample-ratio", key: "tracing.sample_ratio", env: "TRACE_SAMPLE_RATIO"},
+	{flag: "trace-stderr", key: "tracing.stderr", env: "TRACE_STDERR"},
+	{flag: "delete-grace-period", key: "deletion.grace_period", env: "DELETE_GRACE_PERIOD"},
+	{flag: "purge-interval", key: "deletion.purge_interval", env: "PURGE_INTERVAL"},
+	{flag: "feature-registration", key: "features.registration", env: "FEATURE_REGISTRATION"},
+	{flag: "feature-poll-listing", key: "features.poll_listing", env: "FEATURE_POLL_LISTING"},
+	{flag: "feature-write-ins", key: "features.write_ins", env: "FEATURE_WRITE_INS"},
+	{flag: "admin-addr", key: "admin.addr", env: "ADMIN_ADDR"},
+	{flag: "admin-key", key: "admin.key", env: "ADMIN_KEY", secret: true},
+	{flag: "admin-tls-cert", key: "admin.tls_cert", env: "ADMIN_TLS_CERT"},
+	{flag: "admin-tls-key", key: "admin.tls_key", env: "ADMIN_TLS_KEY"},
+	{flag: "admin-client-ca", key: "admin.client_ca", env: "ADMIN_CLIENT_CA"},
+}
+
+const redacted = "[redacted]"
+
+const minAdminKeyLength = 32
+
+func configFlags(cfg *config) *flag.FlagSet {
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+
+	fs.IntVar(&cfg.port, "port", 4000, "API server port")
+	fs.StringVar(&cfg.env, "env", "development", "Environment name, reported by the healthcheck and in traces")
+
+	fs.StringVar(&cfg.db.dsn, "db-dsn", "", "PostgreSQL DSN")
+	fs.IntVar(&cfg.db.maxConns, "db-max-conns", 25, "Largest number of open database connections")
+	fs.IntVar(&cfg.db.minConns, "db-min-conns", 0, "Number of database connections kept open when idle")
+	fs.DurationVar(&cfg.db.maxConnIdleTime, "db-max-conn-idle-time", 15*time.Minute, "How long a database connection can sit idle before it's closed")
+	fs.DurationVar(&cfg.db.maxConnLifetime, "db-max-conn-lifetime", time.Hour, "How long a database connection is used before it's replaced")
+	fs.BoolVar(&cfg.db.autoMigrate, "auto-migrate", true, "Apply pending migrations on start, turn off when replicas are migrated with the migrate command")
+
+	fs.DurationVar(&cfg.http.readTimeout, "http-read-timeout", 10*time.Second, "Longest time to read a request")
+	fs.DurationVar(&cfg.http.readHeaderTimeout, "http-read-header-timeout", 5*time.Second, "Longest time to read request headers")
+	fs.DurationVar(&cfg.http.writeTimeout, "http-write-timeout", 30*time.Second, "Longest time to write a response")
+	fs.DurationVar(&cfg.http.idleTimeout, "http-idle-timeout", time.Minute, "How long keep-alive connections are kept open between requests")
+	fs.DurationVar(&cfg.http.shutdownTimeout, "shutdown-timeout", 30*time.Second, "How long to wait for requests in flight when shutting down")
+
+	fs.Float64Var(&cfg.limiter.rps, "limiter-rps", 2, "Rate limiter maximum requests persecond")
+	fs.IntVar(&cfg.limiter.burst, "limiter-burst", 4, "Rate limiter maximum burst")
+	fs.BoolVar(&cfg.limiter.enabled, "limiter-enabled", true, "Enable rate limiter")
+
+	fs.Var(&cfg.cors.trustedOrigins, "cors-trusted-origins", "Origins allowed to make cross-origin requests, separated by commas, any origin when empty")
+
+	fs.StringVar(&cfg.jwt.keys, "jwt-keys", "", "Signing keys for JWT owner tokens, kid=seed pairs separated by commas, the first one signs")
+	fs.DurationVar(&cfg.jwt.ttl, "jwt-ttl", 90*24*time.Hour, "Lifetime of JWT owner tokens")
+
+	fs.StringVar(&cfg.oidc.issuer, "oidc-issuer", "", "OpenID Connect issuer URL, leave empty to disable sign in with an identity provider")
+	fs.StringVar(&cfg.oidc.clientID, "oidc-client-id", "", "OpenID Connect client ID")
+	fs.StringVar(&cfg.oidc.clientSecret, "oidc-client-secret", "", "OpenID Connect client secret")
+	fs.StringVar(&cfg.oidc.redirectURL, "oidc-redirect-url", "", "URL of /v1/auth/oidc/callback as registered with the issuer")
+
+	fs.StringVar(&cfg.tracing.endpoint, "otel-endpoint", "", "OTLP/HTTP endpoint for traces, e.g. http://localhost:4318, leave empty to turn tracing off")
+	fs.Float64Var(&cfg.tracing.sampleRatio, "trace-sample-ratio", 1, "Share of requests to trace, from 0 to 1")
+	fs.BoolVar(&cfg.tracing.stderr, "trace-stderr", false, "Print spans to stderr when no OTLP endpoint is set, for debugging")
+
+	fs.DurationVar(&cfg.deletion.gracePeriod, "delete-grace-period", 7*24*time.Hour, "How long deleted polls can be restored before they are purged")
+	fs.DurationVar(&cfg.deletion.purgeInterval, "purge-interval", time.Hour, "How often deleted polls past the grace period are purged")
+
+	fs.BoolVar(&cfg.features.registration, "feature-registration", true, "Allow signing up with POST /v1/users")
+	fs.BoolVar(&cfg.features.pollListing, "feature-poll-listing", true, "Allow listing public polls with GET /v1/polls")
+	fs.BoolVar(&cfg.features.writeIns, "feature-write-ins", true, "Allow write-in votes")
+
+	fs.StringVar(&cfg.admin.addr, "admin-addr", "", "Address of the admin API, e.g. 127.0.0.1:4001, leave empty to disable it")
+	fs.StringVar(&cfg.admin.key, "admin-key", "", "Key admin requests send in the X-Admin-Key header")
+	fs.StringVar(&cfg.admin.tlsCert, "admin-tls-cert", "", "Certificate the admin API serves TLS with")
+	fs.StringVar(&cfg.admin.tlsKey, "admin-tls-key", "", "Private key of the admin API certificate")
+	fs.StringVar(&cfg.admin.clientCA, "admin-client-ca", "", "CA bundle admin client certificates must be signed by, enables mTLS")
+
+	return fs
+}
+
+// command is what main was asked to do instead of serving.
+type command struct {
+	printConfig bool
+	args        []string // left after the flags, e.g. migrate up
+}
+
+// loadConfig builds the config from defaults, the file given with -config,
+// env vars and flags, each overriding the one before. The command says
+// what to run instead of the server, if anything.
+func loadConfig(args []string, getenv func(string) string) (cfg config, cmd command, err error) {
+	fs := configFlags(&cfg)
+	var path string
+	fs.StringVar(&path, "config", getenv("CONFIG_FILE"), "YAML or TOML config file")
+	fs.BoolVar(&cmd.printConfig, "print-config", false, "Print the effective config with secrets redacted and exit")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] [migrate up|down|status|version]\n", fs.Name())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, cmd, err
+	}
+
+	fromFlags := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { fromFlags[f.Name] = true })
+
+	if path != "" {
+		values, err := readConfigFile(path)
+		if err != nil {
+			return cfg, cmd, err
+		}
+		if err := applySettings(fs, fromFlags, values, func(s setting) string { return s.key }); err != nil {
+			return cfg, cmd, fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+
+	env := map[string]string{}
+	for _, s := range settings {
+		if v := getenv(s.env); v != "" {
+			env[s.env] = v
+		}
+	}
+	if err := applySettings(fs, fromFlags, env, func(s setting) string { return s.env }); err != nil {
+		return cfg, cmd, fmt.Errorf("env: %w", err)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return cfg, cmd, err
+	}
+
+	cmd.args = fs.Args()
+
+	return cfg, cmd, nil
+}
+
+// applySettings sets every flag that has a value under its name, except
+// those already given on the command line. Values for unknown names are an
+// error.
+func applySettings(fs *flag.FlagSet, fromFlags map[string]bool, values map[string]string, name func(setting) string) error {
+	known := map[string]bool{}
+	for _, s := range settings {
+		known[name(s)] = true
+		value, ok := values[name(s)]
+		if !ok || fromFlags[s.flag] {
+			continue
+		}
+		if err := fs.Set(s.flag, value); err != nil {
+			return fmt.Errorf("%s: %w", name(s), err)
+		}
+	}
+
+	for n := range values {
+		if !known[n] {
+			return fmt.Errorf("unknown setting %q", n)
+		}
+	}
+	return nil
+}
+
+// readConfigFile flattens the file into dotted keys, e.g. db.max_conns.
+func readConfigFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+
+	raw := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		err = toml.Unmarshal(b, &raw)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &raw)
+	default:
+		return nil, fmt.Errorf("config file %s: must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flattenConfig("", raw, values)
+	return values, nil
+}
+
+func flattenConfig(prefix string, raw map[string]any, values map[string]string) {
+	for k, v := range raw {
+		key := prefix + k
+		switch v := v.(type) {
+		case map[string]any:
+			flattenConfig(key+".", v, values)
+		case nil:
+			values[key] = ""
+		case []any:
+			items := make([]string, len(v))
+			for i := range v {
+				items[i] = fmt.Sprint(v[i])
+			}
+			values[key] = strings.Join(items, ",")
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+func (cfg *config) validate() error {
+	v := validator.New()
+
+	v.Check(cfg.port > 0 && cfg.port <= 65535, "port", "must be between 1 and 65535")
+	v.Check(cfg.env != "", "env", "must be provided")
+
+	v.Check(cfg.db.dsn != "", "db.dsn", "must be provided")
+	v.Check(cfg.db.maxConns > 0, "db.max_conns", "must be greater than zero")
+	v.Check(cfg.db.minConns >= 0, "db.min_conns", "must not be negative")
+	v.Check(cfg.db.minConns <= cfg.db.maxConns, "db.min_conns", "must not be more than db.max_conns")
+	v.Check(cfg.db.maxConnIdleTime > 0, "db.max_conn_idle_time", "must be greater than zero")
+	v.Check(cfg.db.maxConnLifetime > 0, "db.max_conn_lifetime", "must be greater than zero")
+
+	v.Check(cfg.http.readTimeout > 0, "http.read_timeout", "must be greater than zero")
+	v.Check(cfg.http.readHeaderTimeout > 0, "http.read_header_timeout", "must be greater than zero")
+	v.Check(cfg.http.writeTimeout > 0, "http.write_timeout", "must be greater than zero")
+	v.Check(cfg.http.idleTimeout > 0, "http.idle_timeout", "must be greater than zero")
+	v.Check(cfg.http.shutdownTimeout > 0, "http.shutdown_timeout", "must be greater than zero")
+
+	if cfg.limiter.enabled {
+		v.Check(cfg.limiter.rps > 0, "limiter.rps", "must be greater than zero")
+		v.Check(cfg.limiter.burst > 0, "limiter.burst", "must be greater than zero")
+	}
+
+	for _, origin := range cfg.cors.trustedOrigins {
+		u, err := url.Parse(origin)
+		v.Check(err == nil && u.Scheme != "" && u.Host != "" && u.Path == "", "cors.trusted_origins", "must be origins like https://example.com")
+	}
+
+	v.Check(cfg.jwt.ttl > 0, "jwt.ttl", "must be greater than zero")
+
+	if cfg.oidc.issuer != "" {
+		v.Check(cfg.oidc.clientID != "", "oidc.client_id", "must be provided with oidc.issuer")
+		v.Check(cfg.oidc.redirectURL != "", "oidc.redirect_url", "must be provided with oidc.issuer")
+	}
+
+	v.Check(cfg.tracing.sampleRatio >= 0 && cfg.tracing.sampleRatio <= 1, "tracing.sample_ratio", "must be between 0 and 1")
+
+	v.Check(cfg.deletion.gracePeriod >= 0, "deletion.grace_period", "must not be negative")
+	v.Check(cfg.deletion.purgeInterval > 0, "deletion.purge_interval", "must be greater than zero")
+
+	if cfg.admin.addr != "" {
+		_, _, err := net.SplitHostPort(cfg.admin.addr)
+		v.Check(err == nil, "admin.addr", "must be a host:port address")
+		v.Check(cfg.admin.key != "" || cfg.admin.clientCA != "", "admin.key", "must be provided unless admin.client_ca is set")
+	}
+	if cfg.admin.key != "" {
+		v.Check(len(cfg.admin.key) >= minAdminKeyLength, "admin.key", fmt.Sprintf("must be at least %d bytes long", minAdminKeyLength))
+	}
+	v.Check((cfg.admin.tlsCert == "") == (cfg.admin.tlsKey == ""), "admin.tls_cert", "must be set together with admin.tls_key")
+	if cfg.admin.clientCA != "" {
+		v.Check(cfg.admin.tlsCert != "", "admin.client_ca", "needs admin.tls_cert and admin.tls_key")
+	}
+
+	if v.Valid() {
+		return nil
+	}
+
+	keys := make([]string, 0, len(v.Errors))
+	for k := range v.Errors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	problems := make([]string, len(keys))
+	for i, k := range keys {
+		problems[i] = k + " " + v.Errors[k]
+	}
+	return errors.New("invalid config: " + strings.Join(problems, "; "))
+}
+
+// printConfig writes the config as YAML, in the same shape the config file
+// takes, with secrets redacted.
+func printConfig(w io.Writer, cfg *config) error {
+	// registering the flags resets their targets to the defaults, so the
+	// values are copied in afterwards
+	var current config
+	fs := configFlags(&current)
+	current = *cfg
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range settings {
+		value := fs.Lookup(s.flag).Value.String()
+		if s.secret && value != "" {
+			value = redacted
+		}
+
+		node := root
+		parts := strings.Split(s.key, ".")
+		for _, part := range parts[:len(parts)-1] {
+			node = childMapping(node, part)
+		}
+		scalar := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+		if value == "" {
+			scalar.Style = yaml.DoubleQuotedStyle
+		}
+		node.Content = append(node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: parts[len(parts)-1]},
+			scalar,
+		)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func childMapping(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+	return child
+}
+
+// stringList is a flag holding values separated by commas.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+func envFrom(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func Test_loadConfigDefaults(t *testing.T) {
+	cfg, cmd, err := loadConfig(nil, envFrom(map[string]string{"DB_DSN": "postgres://localhost/polls"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.printConfig {
+		t.Error("expected print-config to be off")
+	}
+	if cfg.port != 4000 || cfg.env != "development" || cfg.db.maxConns != 25 || !cfg.limiter.enabled || !cfg.features.registration {
+		t.Errorf("expected defaults, but got %+v", cfg)
+	}
+}
+
+func Test_loadConfigPrecedence(t *testing.T) {
+	env := envFrom(map[string]string{
+		"CONFIG_FILE":  "testdata/config.yaml",
+		"SERVER_ENV":   "production",
+		"DB_MAX_CONNS": "20",
+		"LIMITER_RPS":  "7",
+	})
+	args := []string{"-limiter-rps", "9", "-print-config"}
+
+	cfg, cmd, err := loadConfig(args, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !cmd.printConfig {
+		t.Error("expected print-config to be on")
+	}
+	if cfg.port != 8080 {
+		t.Errorf("expected port from the file, but got %d", cfg.port)
+	}
+	if cfg.env != "production" || cfg.db.maxConns != 20 {
+		t.Errorf("expected env vars to override the file, but got env %q and max conns %d", cfg.env, cfg.db.maxConns)
+	}
+	if cfg.limiter.rps != 9 {
+		t.Errorf("expected flags to override env vars, but got rps %v", cfg.limiter.rps)
+	}
+	if cfg.http.writeTimeout != 45*time.Second {
+		t.Errorf("expected write timeout from the file, but got %s", cfg.http.writeTimeout)
+	}
+	if cfg.http.readTimeout != 10*time.Second {
+		t.Errorf("expected default read timeout, but got %s", cfg.http.readTimeout)
+	}
+	if len(cfg.cors.trustedOrigins) != 2 || cfg.cors.trustedOrigins[1] != "https://polls.example.com" {
+		t.Errorf("expected trusted origins from the file, but got %v", cfg.cors.trustedOrigins)
+	}
+	if cfg.features.registration {
+		t.Error("expected registration to be switched off by the file")
+	}
+}
+
+func Test_loadConfigCommand(t *testing.T) {
+	args := []string{"-auto-migrate=false", "migrate", "status"}
+	cfg, cmd, err := loadConfig(args, envFrom(map[string]string{"DB_DSN": "x"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.db.autoMigrate {
+		t.Error("expected auto-migrate to be off")
+	}
+	if len(cmd.args) != 2 || cmd.args[0] != "migrate" || cmd.args[1] != "status" {
+		t.Errorf("expected migrate status to be left after the flags, but got %v", cmd.args)
+	}
+}
+
+func Test_loadConfigTOML(t *testing.T) {
+	cfg, _, err := loadConfig([]string{"-config", "testdata/config.toml"}, envFrom(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.port != 8081 || cfg.limiter.burst != 9 {
+		t.Errorf("expected values from the file, but got port %d and burst %d", cfg.port, cfg.limiter.burst)
+	}
+}
+
+func Test_loadConfigInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		expected string
+	}{
+		{"missing dsn", nil, nil, "db.dsn must be provided"},
+		{"bad port", []string{"-port", "70000"}, map[string]string{"DB_DSN": "x"}, "port must be between 1 and 65535"},
+		{"bad env var", nil, map[string]string{"DB_DSN": "x", "SERVER_PORT": "eighty"}, "SERVER_PORT"},
+		{"min over max conns", []string{"-db-max-conns", "2", "-db-min-conns", "3"}, map[string]string{"DB_DSN": "x"}, "db.min_conns must not be more than db.max_conns"},
+		{"bad origin", []string{"-cors-trusted-origins", "example.com"}, map[string]string{"DB_DSN": "x"}, "cors.trusted_origins"},
+		{"oidc without client", []string{"-oidc-issuer", "https://accounts.example.com"}, map[string]string{"DB_DSN": "x"}, "oidc.client_id must be provided"},
+		{"sample ratio", []string{"-trace-sample-ratio", "2"}, map[string]string{"DB_DSN": "x"}, "tracing.sample_ratio"},
+		{"admin without auth", []string{"-admin-addr", "127.0.0.1:4001"}, map[string]string{"DB_DSN": "x"}, "admin.key must be provided unless admin.client_ca is set"},
+		{"admin bad addr", []string{"-admin-addr", "4001", "-admin-key", strings.Repeat("k", 32)}, map[string]string{"DB_DSN": "x"}, "admin.addr"},
+		{"short admin key", []string{"-admin-key", "secret"}, map[string]string{"DB_DSN": "x"}, "admin.key must be at least 32 bytes long"},
+		{"client ca without tls", []string{"-admin-client-ca", "ca.pem"}, map[string]string{"DB_DSN": "x"}, "admin.client_ca needs admin.tls_cert"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := loadConfig(test.args, envFrom(test.env))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected error to contain %q, but got %q", test.expected, err)
+			}
+		})
+	}
+}
+
+func Test_printConfig(t *testing.T) {
+	cfg, _, err := loadConfig([]string{"-config", "testdata/config.yaml", "-jwt-keys", "k1=seed"}, envFrom(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := printConfig(&buf, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, expected := range []string{
+		"port: 8080\n",
+		"  dsn: '[redacted]'\n",
+		"  max_conns: 10\n",
+		"  keys: '[redacted]'\n",
+		"  client_secret: \"\"\n",
+		"  trusted_origins: https://example.com,https://polls.example.com\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, but got:\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "secret@") || strings.Contains(out, "seed") {
+		t.Errorf("expected secrets to be redacted, but got:\n%s", out)
+	}
+
+	if cfg.port != 8080 || cfg.db.maxConns != 10 {
+		t.Errorf("expected printing to leave the config alone, but got %+v", cfg)
+	}
+}
+
+func Test_settingsCoverFlags(t *testing.T) {
+	fs := configFlags(&config{})
+	seen := map[string]bool{}
+	for _, s := range settings {
+		if fs.Lookup(s.flag) == nil {
+			t.Errorf("setting %q has no flag", s.flag)
+		}
+		seen[s.flag] = true
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		if !seen[f.Name] {
+			t.Errorf("flag %q can't be set from the config file or env", f.Name)
+		}
+	})
+}
@@ -2,15 +2,28 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/migrations"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
 )
 
 func (app *application) connectToDB() (*pgxpool.Pool, error) {
-	connPoll, err := pgxpool.New(context.Background(), app.config.db.dsn)
+	cfg, err := pgxpool.ParseConfig(app.config.db.dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the DB dsn: %w", err)
+	}
+	cfg.ConnConfig.Tracer = data.QueryTracer{}
+	cfg.MaxConns = int32(app.config.db.maxConns)
+	cfg.MinConns = int32(app.config.db.minConns)
+	cfg.MaxConnIdleTime = app.config.db.maxConnIdleTime
+	cfg.MaxConnLifetime = app.config.db.maxConnLifetime
+
+	connPoll, err := pgxpool.NewWithConfig(context.Background(), cfg)
 	if err != nil {
 		return nil, fmt.Errorf("error connecting to the DB: %w", err)
 	}
@@ -20,20 +33,59 @@ func (app *application) connectToDB() (*pgxpool.Pool, error) {
 		return nil, fmt.Errorf("failed to ping the DB: %w", err)
 	}
 
-	app.logger.Println("Connected to DB!")
+	app.logger.Info("connected to database")
 
 	return connPoll, nil
 }
 
-func (app *application) runMigrations(db *pgxpool.Pool, dir string) error {
-	if err := goose.SetDialect("postgres"); err != nil {
+func (app *application) runMigrations(db *pgxpool.Pool) error {
+	if err := setupGoose(); err != nil {
 		return fmt.Errorf("migrate: %w", err)
 	}
 	database := stdlib.OpenDBFromPool(db)
 
-	if err := goose.Up(database, dir); err != nil {
+	if err := goose.Up(database, "."); err != nil {
 		return fmt.Errorf("migrate: %w", err)
 	}
 
 	return nil
 }
+
+// setupGoose points goose at the migrations embedded in the binary.
+func setupGoose() error {
+	goose.SetBaseFS(migrations.FS)
+	return goose.SetDialect("postgres")
+}
+
+var errMigrationsPending = errors.New("migrations pending")
+
+// dbReadiness checks that the database answers and that every embedded
+// migration has been applied to it.
+func (app *application) dbReadiness(db *pgxpool.Pool) (func(context.Context) error, error) {
+	if err := setupGoose(); err != nil {
+		return nil, fmt.Errorf("readiness: %w", err)
+	}
+	collected, err := goose.CollectMigrations(".", 0, goose.MaxVersion)
+	if err != nil {
+		return nil, fmt.Errorf("readiness: %w", err)
+	}
+	last, err := collected.Last()
+	if err != nil {
+		return nil, fmt.Errorf("readiness: %w", err)
+	}
+	database := stdlib.OpenDBFromPool(db)
+
+	return func(ctx context.Context) error {
+		if err := db.Ping(ctx); err != nil {
+			return fmt.Errorf("ping database: %w", err)
+		}
+		current, err := goose.GetDBVersionContext(ctx, database)
+		if err != nil {
+			return fmt.Errorf("migration status: %w", err)
+		}
+		if current < last.Version {
+			return fmt.Errorf("%w: database is at migration %d, expected %d", errMigrationsPending, current, last.Version)
+		}
+		return nil
+	}, nil
+}
@@ -1,27 +1,45 @@
 package main
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
 )
 
-func (app *application) logError(err error) {
-	app.logger.Print(err)
+func (app *application) logError(r *http.Request, err error) {
+	app.logger.Error(err.Error(),
+		"request_id", requestIDFromContext(r.Context()),
+		"method", r.Method,
+		"uri", r.URL.RequestURI(),
+	)
 }
 
 func (app *application) errorJSONResponse(w http.ResponseWriter, status int, message any) {
-	env := envelope{"error": message}
+	app.writeError(w, status, envelope{"error": message})
+}
 
+func (app *application) writeError(w http.ResponseWriter, status int, env envelope) {
 	err := app.writeJSON(w, status, env, nil)
 	if err != nil {
-		app.logError(err)
+		app.logger.Error("write error response", "error", err)
 		w.WriteHeader(500)
 	}
 }
 
-func (app *application) serverErrorResponse(w http.ResponseWriter, err error) {
-	app.logError(err)
+// serverErrorResponse hands back the request ID so a user's report can be
+// matched to the logged error.
+func (app *application) serverErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logError(r, err)
 	message := "the server encountered a problem and could not process your request"
-	app.errorJSONResponse(w, http.StatusInternalServerError, message)
+	env := envelope{"error": message}
+	if id := requestIDFromContext(r.Context()); id != "" {
+		env["request_id"] = id
+	}
+	app.writeError(w, http.StatusInternalServerError, env)
 }
 
 func (app *application) notFoundResponse(w http.ResponseWriter, r *http.Request) {
@@ -37,12 +55,30 @@ func (app *application) failedValidationResponse(w http.ResponseWriter, errors m
 	app.errorJSONResponse(w, http.StatusUnprocessableEntity, errors)
 }
 
-func (app *application) rateLimitExcededResponse(w http.ResponseWriter) {
+// rateLimitExcededResponse tells the client when the limiter will have a
+// token for it again, one comes back every 1/rps seconds.
+func (app *application) rateLimitExcededResponse(w http.ResponseWriter, rps float64) {
+	limiterRejections.WithLabelValues("rate").Inc()
+	if rps > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(1/rps))))
+	}
 	message := "rate limit exceeded"
 	app.errorJSONResponse(w, http.StatusTooManyRequests, message)
 }
 
+// quotaExceededResponse sends the client away until usage is counted on a
+// new day, at midnight UTC.
+func (app *application) quotaExceededResponse(w http.ResponseWriter) {
+	limiterRejections.WithLabelValues("quota").Inc()
+	now := time.Now().UTC()
+	reset := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(reset.Sub(now).Seconds()))))
+	message := "daily quota exceeded"
+	app.errorJSONResponse(w, http.StatusTooManyRequests, message)
+}
+
 func (app *application) cannotVoteResponse(w http.ResponseWriter) {
+	duplicateVotes.Inc()
 	message := "you have already voted on this poll"
 	app.errorJSONResponse(w, http.StatusForbidden, message)
 }
@@ -67,3 +103,68 @@ func (app *application) invalidTokenResponse(w http.ResponseWriter) {
 	message := "invalid or missing token"
 	app.errorJSONResponse(w, http.StatusUnauthorized, message)
 }
+
+func (app *application) invalidAPIKeyResponse(w http.ResponseWriter) {
+	message := "invalid or revoked API key"
+	app.errorJSONResponse(w, http.StatusUnauthorized, message)
+}
+
+func (app *application) invalidAdminKeyResponse(w http.ResponseWriter) {
+	message := "invalid or missing admin key"
+	app.errorJSONResponse(w, http.StatusUnauthorized, message)
+}
+
+func (app *application) invalidCredentialsResponse(w http.ResponseWriter) {
+	message := "invalid authentication credentials"
+	app.errorJSONResponse(w, http.StatusUnauthorized, message)
+}
+
+func (app *application) signInRequiredResponse(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	message := "you must be signed in to vote on this poll"
+	app.errorJSONResponse(w, http.StatusUnauthorized, message)
+}
+
+func (app *application) voterNotAllowedResponse(w http.ResponseWriter) {
+	message := "your account is not allowed to vote on this poll"
+	app.errorJSONResponse(w, http.StatusForbidden, message)
+}
+
+func (app *application) orgRoleRequiredResponse(w http.ResponseWriter) {
+	message := "your role in the organization does not permit this"
+	app.errorJSONResponse(w, http.StatusForbidden, message)
+}
+
+func (app *application) lastOwnerResponse(w http.ResponseWriter) {
+	message := "the organization must keep at least one owner"
+	app.errorJSONResponse(w, http.StatusConflict, message)
+}
+
+// duplicateOptionResponse covers an option added concurrently with the
+// same value, which validating the poll beforehand can't catch.
+func (app *application) duplicateOptionResponse(w http.ResponseWriter) {
+	app.failedValidationResponse(w, map[string]string{"options": "must not contain duplicate values"})
+}
+
+func (app *application) restoreWindowClosedResponse(w http.ResponseWriter) {
+	message := "the poll was deleted too long ago to be restored"
+	app.errorJSONResponse(w, http.StatusGone, message)
+}
+
+func (app *application) missingScopeResponse(w http.ResponseWriter, scope string) {
+	message := "token is missing the " + scope + " scope"
+	app.errorJSONResponse(w, http.StatusForbidden, message)
+}
+
+func (app *application) pollAuthErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, errInvalidToken):
+		app.invalidTokenResponse(w)
+	case errors.Is(err, errTokenWrongPoll):
+		app.badRequestResponse(w, err)
+	case errors.Is(err, data.ErrRecordNotFound):
+		app.notFoundResponse(w, r)
+	default:
+		app.serverErrorResponse(w, r, err)
+	}
+}
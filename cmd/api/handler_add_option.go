@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -10,9 +11,11 @@ import (
 
 func (app *application) addOptionHandler(w http.ResponseWriter, r *http.Request) {
 	poll := app.pollFromContext(r.Context())
+	before := data.NewPollSnapshot(poll)
 
 	var input struct {
-		Value string `json:"value"`
+		Value string         `json:"value"`
+		Slot  *data.TimeSlot `json:"slot"`
 	}
 
 	err := app.readJSON(w, r, &input)
@@ -24,6 +27,10 @@ func (app *application) addOptionHandler(w http.ResponseWriter, r *http.Request)
 	newOption := &data.PollOption{
 		Value:    strings.TrimSpace(input.Value),
 		Position: len(poll.Options),
+		Slot:     input.Slot,
+	}
+	if newOption.Value == "" && newOption.Slot != nil {
+		newOption.Value = newOption.Slot.Label()
 	}
 
 	poll.Options = append(poll.Options, newOption)
@@ -35,14 +42,20 @@ func (app *application) addOptionHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = app.models.PollOptions.Insert(newOption, poll.ID)
+	revision := data.NewRevision(data.RevisionAddOption, app.actorFromContext(r.Context()), before)
+	err = app.models.PollOptions.Insert(r.Context(), newOption, poll.ID, revision)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		switch {
+		case errors.Is(err, data.ErrDuplicateOption):
+			app.duplicateOptionResponse(w)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
 	err = app.writeJSON(w, http.StatusCreated, envelope{"message": "option added successfully"}, nil)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
 	}
 }
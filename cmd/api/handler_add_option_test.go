@@ -29,12 +29,24 @@ func Test_app_addOptionHandler(t *testing.T) {
 			expectedStatus: http.StatusUnprocessableEntity,
 			expectedBody:   "must not contain duplicate values",
 		},
+		{
+			name:           "option differs only in case and spacing",
+			json:           `{"value":"  tWO "}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must not contain duplicate values",
+		},
+		{
+			name:           "option added by someone else meanwhile",
+			json:           `{"value":"` + data.ExampleOptionValueRaced + `"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must not contain duplicate values",
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
-			poll, _ := app.models.Polls.Get(data.ExamplePollIDValid)
+			poll, _ := app.models.Polls.Get(context.Background(), data.ExamplePollIDValid)
 			req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
 			rr := httptest.NewRecorder()
 			handler := http.HandlerFunc(app.addOptionHandler)
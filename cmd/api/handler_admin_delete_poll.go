@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+// adminDeletePollHandler removes a poll for good, with its options, votes
+// and tokens. It can't be restored.
+func (app *application) adminDeletePollHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	err = app.models.Polls.Erase(r.Context(), pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.logger.Info("poll erased", "poll_id", pollID, "request_id", requestIDFromContext(r.Context()))
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "poll permanently deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+// adminHidePollHandler takes a poll down for everyone but the operators.
+// Its owner can't bring it back, and it's never purged while hidden.
+func (app *application) adminHidePollHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	err = app.models.Polls.Hide(r.Context(), pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.logger.Info("poll hidden", "poll_id", pollID, "request_id", requestIDFromContext(r.Context()))
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "poll hidden"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// adminListPollsHandler lists every poll, private ones included, with the
+// same filters as the public listing.
+func (app *application) adminListPollsHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Search string
+		data.Filters
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.Search = app.readString(qs, "search", "")
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "-created_at")
+	input.Filters.SortSafelist = []string{"created_at", "question", "-created_at", "-question"}
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	polls, metadata, err := app.models.Polls.GetAllIncludingPrivate(r.Context(), input.Search, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeJSON(
+		w,
+		http.StatusOK,
+		envelope{"polls": polls, "metadata": metadata},
+		nil,
+	); err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_adminListPollsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"private polls are listed", "", http.StatusOK, data.ExamplePollIDOrgPrivate},
+		{"hidden polls are listed", "", http.StatusOK, `"hidden_at":`},
+		{"invalid sort", "?sort=votes", http.StatusUnprocessableEntity, "invalid sort value"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/admin/polls"+test.query, nil)
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(app.adminListPollsHandler).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
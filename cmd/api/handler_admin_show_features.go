@@ -0,0 +1,10 @@
+package main
+
+import "net/http"
+
+func (app *application) adminShowFeaturesHandler(w http.ResponseWriter, r *http.Request) {
+	err := app.writeJSON(w, http.StatusOK, envelope{"features": app.featureStates()}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
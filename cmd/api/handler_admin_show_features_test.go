@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_app_adminShowFeaturesHandler(t *testing.T) {
+	a := newAdminApp()
+
+	req, _ := http.NewRequest(http.MethodGet, "/admin/features", nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(a.adminShowFeaturesHandler).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status code %d, but got %d", http.StatusOK, rr.Code)
+	}
+	expected := `{"features":{"poll_listing":false,"registration":true,"write_ins":false}}`
+	if strings.TrimSpace(rr.Body.String()) != expected {
+		t.Errorf("expected body %s, but got %s", expected, rr.Body)
+	}
+}
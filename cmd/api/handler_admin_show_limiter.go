@@ -0,0 +1,10 @@
+package main
+
+import "net/http"
+
+func (app *application) adminShowLimiterHandler(w http.ResponseWriter, r *http.Request) {
+	err := app.writeJSON(w, http.StatusOK, envelope{"limiter": app.limiter.Load()}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+// adminUnhidePollHandler brings back a hidden poll. A poll its owner had
+// deleted stays deleted.
+func (app *application) adminUnhidePollHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	err = app.models.Polls.Unhide(r.Context(), pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.logger.Info("poll unhidden", "poll_id", pollID, "request_id", requestIDFromContext(r.Context()))
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "poll unhidden"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
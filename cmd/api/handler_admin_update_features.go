@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// adminUpdateFeaturesHandler switches features on or off until the next
+// restart. Features left out of the body keep their state.
+func (app *application) adminUpdateFeaturesHandler(w http.ResponseWriter, r *http.Request) {
+	var input map[string]bool
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	for name := range input {
+		_, ok := app.features[name]
+		v.Check(ok, name, "unknown feature")
+	}
+	if !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	for name, enabled := range input {
+		app.features[name].Store(enabled)
+		app.logger.Info("feature switched", "feature", name, "enabled", enabled, "request_id", requestIDFromContext(r.Context()))
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"features": app.featureStates()}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
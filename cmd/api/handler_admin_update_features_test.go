@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_app_adminUpdateFeaturesHandler(t *testing.T) {
+	tests := []struct {
+		name             string
+		body             string
+		expectedStatus   int
+		expectedBody     string
+		expectedRegister bool
+	}{
+		{"switch off registration", `{"registration": false}`, http.StatusOK, `"registration":false`, false},
+		{"switch on write-ins", `{"write_ins": true}`, http.StatusOK, `"write_ins":true`, true},
+		{"unknown feature", `{"registration": false, "teleport": true}`, http.StatusUnprocessableEntity, `"teleport":"unknown feature"`, true},
+		{"not a bool", `{"registration": "no"}`, http.StatusBadRequest, "incorrect JSON type", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := newAdminApp()
+
+			req, _ := http.NewRequest(http.MethodPatch, "/admin/features", strings.NewReader(test.body))
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(a.adminUpdateFeaturesHandler).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if a.featureEnabled(featureRegistration) != test.expectedRegister {
+				t.Errorf("expected registration enabled to be %t", test.expectedRegister)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// adminUpdateLimiterHandler tunes the per-IP rate limit until the next
+// restart. Clients already seen get the new limit on their next request.
+func (app *application) adminUpdateLimiterHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		RPS     *float64 `json:"rps"`
+		Burst   *int     `json:"burst"`
+		Enabled *bool    `json:"enabled"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	// retry if another change got in between loading and storing
+	var limits limiterSettings
+	for {
+		current := app.limiter.Load()
+		limits = *current
+		if input.RPS != nil {
+			limits.RPS = *input.RPS
+		}
+		if input.Burst != nil {
+			limits.Burst = *input.Burst
+		}
+		if input.Enabled != nil {
+			limits.Enabled = *input.Enabled
+		}
+
+		v := validator.New()
+		if limits.Enabled {
+			v.Check(limits.RPS > 0, "rps", "must be greater than zero")
+			v.Check(limits.Burst > 0, "burst", "must be greater than zero")
+		}
+		if !v.Valid() {
+			app.failedValidationResponse(w, v.Errors)
+			return
+		}
+
+		if app.limiter.CompareAndSwap(current, &limits) {
+			break
+		}
+	}
+
+	app.logger.Info("limiter changed",
+		"rps", limits.RPS, "burst", limits.Burst, "enabled", limits.Enabled,
+		"request_id", requestIDFromContext(r.Context()),
+	)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"limiter": limits}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
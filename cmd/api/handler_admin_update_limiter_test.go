@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_app_adminUpdateLimiterHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+		expected       limiterSettings
+	}{
+		{"raise the limit", `{"rps": 10, "burst": 20}`, http.StatusOK, `"rps":10`, limiterSettings{RPS: 10, Burst: 20, Enabled: true}},
+		{"switch off", `{"enabled": false}`, http.StatusOK, `"enabled":false`, limiterSettings{RPS: 2, Burst: 4, Enabled: false}},
+		{"switch off with zero rps", `{"enabled": false, "rps": 0}`, http.StatusOK, `"rps":0`, limiterSettings{RPS: 0, Burst: 4, Enabled: false}},
+		{"zero rps", `{"rps": 0}`, http.StatusUnprocessableEntity, "must be greater than zero", limiterSettings{RPS: 2, Burst: 4, Enabled: true}},
+		{"unknown field", `{"per_minute": 5}`, http.StatusBadRequest, "unknown key", limiterSettings{RPS: 2, Burst: 4, Enabled: true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := newAdminApp()
+
+			req, _ := http.NewRequest(http.MethodPatch, "/admin/limiter", strings.NewReader(test.body))
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(a.adminUpdateLimiterHandler).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if got := *a.limiter.Load(); got != test.expected {
+				t.Errorf("expected limiter %+v, but got %+v", test.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_createOrganizationHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"valid organization", `{"name":" Marketing "}`, http.StatusCreated, `"name":"Marketing"`},
+		{"creator is owner", `{"name":"Marketing"}`, http.StatusCreated, `"role":"owner"`},
+		{"missing name", `{"name":""}`, http.StatusUnprocessableEntity, `{"error":{"name":"must be provided"}}`},
+		{"name too long", `{"name":"` + strings.Repeat("a", 101) + `"}`, http.StatusUnprocessableEntity, `{"error":{"name":"must not be more than 100 bytes long"}}`},
+		{"unknown field", `{"slug":"marketing"}`, http.StatusBadRequest, `body contains unknown key`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			user := &data.User{ID: data.ExampleUserID}
+			req = req.WithContext(context.WithValue(req.Context(), ctxUserKey, user))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.createOrganizationHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
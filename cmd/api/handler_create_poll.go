@@ -1,25 +1,66 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/ivcp/polls/internal/data"
 	"github.com/ivcp/polls/internal/validator"
 )
 
+const (
+	tokenFormatOpaque = "opaque"
+	tokenFormatJWT    = "jwt"
+)
+
 func (app *application) createPollHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		Question    string `json:"question"`
 		Description string `json:"description"`
 		Options     []struct {
-			Value    string `json:"value"`
-			Position int    `json:"position"`
+			Value     string         `json:"value"`
+			Position  int            `json:"position"`
+			IsCorrect bool           `json:"is_correct"`
+			Slot      *data.TimeSlot `json:"slot"`
 		} `json:"options"`
 		ExpiresAt         data.ExpiresAt `json:"expires_at"`
 		ResultsVisibility string         `json:"results_visibility"`
 		IsPrivate         bool           `json:"is_private"`
+		PollType          string         `json:"poll_type"`
+		AllowWriteIn      bool           `json:"allow_write_in"`
+		Series            string         `json:"series"`
+		Scale             []string       `json:"scale"`
+		TokenFormat       string         `json:"token_format"`
+		VoterIssuer       string         `json:"voter_issuer"`
+		VoterEmailDomain  string         `json:"voter_email_domain"`
+		VoterAuth         bool           `json:"voter_auth"`
+		PublicBallots     bool           `json:"public_ballots"`
+		OrgID             string         `json:"org_id"`
+	}
+
+	// signed in users own the polls they create, anonymous polls only
+	// have their token
+	var ownerID string
+	if r.Header.Get("Authorization") != "" {
+		token, ok := app.bearerToken(r)
+		if !ok {
+			app.invalidTokenResponse(w)
+			return
+		}
+		user, err := app.models.Users.GetForSession(token)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.invalidTokenResponse(w)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+		ownerID = user.ID
 	}
 
 	err := app.readJSON(w, r, &input)
@@ -30,16 +71,34 @@ func (app *application) createPollHandler(w http.ResponseWriter, r *http.Request
 
 	options := []*data.PollOption{}
 	for _, option := range input.Options {
+		value := strings.TrimSpace(option.Value)
+		if value == "" && option.Slot != nil {
+			value = option.Slot.Label()
+		}
 		options = append(
 			options,
-			&data.PollOption{Value: strings.TrimSpace(option.Value), Position: option.Position},
+			&data.PollOption{
+				Value:     value,
+				Position:  option.Position,
+				IsCorrect: option.IsCorrect,
+				Slot:      option.Slot,
+			},
 		)
 	}
 
+	var scale []string
+	for _, label := range input.Scale {
+		scale = append(scale, strings.TrimSpace(label))
+	}
+
 	if input.ResultsVisibility == "" {
 		input.ResultsVisibility = "always"
 	}
 
+	if input.PollType == "" {
+		input.PollType = data.PollTypeSingleChoice
+	}
+
 	poll := &data.Poll{
 		Question:          strings.TrimSpace(input.Question),
 		Description:       strings.TrimSpace(input.Description),
@@ -47,32 +106,120 @@ func (app *application) createPollHandler(w http.ResponseWriter, r *http.Request
 		ExpiresAt:         input.ExpiresAt,
 		ResultsVisibility: input.ResultsVisibility,
 		IsPrivate:         input.IsPrivate,
+		PollType:          input.PollType,
+		AllowWriteIn:      input.AllowWriteIn,
+		Series:            strings.TrimSpace(input.Series),
+		Scale:             scale,
+		VoterIssuer:       strings.TrimSpace(input.VoterIssuer),
+		VoterEmailDomain: strings.ToLower(
+			strings.TrimPrefix(strings.TrimSpace(input.VoterEmailDomain), "@"),
+		),
+		VoterAuth:     input.VoterAuth,
+		PublicBallots: input.PublicBallots,
+		OrgID:         strings.TrimSpace(input.OrgID),
+	}
+
+	if input.TokenFormat == "" {
+		input.TokenFormat = tokenFormatOpaque
 	}
 
 	v := validator.New()
-	if data.ValidatePoll(v, poll); !v.Valid() {
+	data.ValidatePoll(v, poll)
+	v.Check(validator.PermittedValue(
+		input.TokenFormat, tokenFormatOpaque, tokenFormatJWT,
+	), "token_format", "invalid token_format value")
+	v.Check(
+		input.TokenFormat != tokenFormatJWT || app.jwtKeys != nil,
+		"token_format",
+		"jwt tokens are not enabled",
+	)
+	v.Check(
+		poll.VoterIssuer == "" || (app.oidc != nil && poll.VoterIssuer == app.oidc.Issuer),
+		"voter_issuer",
+		"must be the issuer users sign in with",
+	)
+	// a series belongs to whoever creates its polls, so anonymous polls
+	// can't join one
+	v.Check(
+		poll.Series == "" || ownerID != "" || app.apiKeyFromContext(r.Context()) != nil,
+		"series",
+		"requires a signed in user or an API key",
+	)
+	if poll.OrgID != "" {
+		_, err := uuid.Parse(poll.OrgID)
+		v.Check(err == nil, "org_id", "must be a valid id")
+		v.Check(ownerID != "", "org_id", "requires a signed in user")
+	}
+	if !v.Valid() {
 		app.failedValidationResponse(w, v.Errors)
 		return
 	}
 
-	token, err := data.GenerateToken()
-	if err != nil {
-		app.serverErrorResponse(w, err)
-		return
+	// viewers can't add polls to their organization
+	if poll.OrgID != "" {
+		role, err := app.models.Organizations.GetRole(poll.OrgID, ownerID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				v.AddError("org_id", "must be an organization you belong to")
+				app.failedValidationResponse(w, v.Errors)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+		if role == data.OrgRoleViewer {
+			app.orgRoleRequiredResponse(w)
+			return
+		}
+	}
+
+	actor := "anonymous"
+	if ownerID != "" {
+		actor = "user:" + ownerID
+	}
+
+	poll.OwnerID = ownerID
+	if key := app.apiKeyFromContext(r.Context()); key != nil {
+		poll.APIKeyID = key.ID
+		if ownerID == "" {
+			actor = "api_key:" + key.ID
+		}
 	}
-	poll.Token = token.Plaintext
 
-	err = app.models.Polls.Insert(poll, token.Hash)
+	var tokenHash []byte
+	if input.TokenFormat == tokenFormatOpaque {
+		token, err := data.GenerateToken()
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		poll.Token = token.Plaintext
+		tokenHash = token.Hash
+	}
+
+	err = app.models.Polls.Insert(r.Context(), poll, tokenHash, data.NewRevision(data.RevisionCreate, actor, nil))
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
 		return
 	}
 
+	// the poll ID is the token subject, so signing waits for the insert
+	if input.TokenFormat == tokenFormatJWT {
+		poll.Token, _, err = app.jwtKeys.Sign(poll.ID, data.AllScopes, app.config.jwt.ttl)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	pollsCreated.Inc()
+
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/v1/polls/%s", poll.ID))
 
 	err = app.writeJSON(w, http.StatusCreated, envelope{"poll": poll}, headers)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
 	}
 }
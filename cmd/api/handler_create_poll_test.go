@@ -1,12 +1,16 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/ivcp/polls/internal/auth/oidctest"
+	"github.com/ivcp/polls/internal/data"
 )
 
 func Test_app_createPollHandler(t *testing.T) {
@@ -18,6 +22,7 @@ func Test_app_createPollHandler(t *testing.T) {
 	tests := []struct {
 		name           string
 		json           string
+		authHeader     string
 		expectedStatus int
 		expectedBody   string
 	}{
@@ -183,11 +188,263 @@ func Test_app_createPollHandler(t *testing.T) {
 			expectedStatus: http.StatusCreated,
 			expectedBody:   `"question":"Test?"`,
 		},
+		{
+			name: "open text poll without options",
+			json: `{
+				"question":"Test?", 
+				"poll_type":"open_text"
+				}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"poll_type":"open_text"`,
+		},
+		{
+			name: "invalid poll_type",
+			json: `{
+				"question":"Test?", 
+				"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+				"poll_type":"test"
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"poll_type":"invalid poll_type value"}}`,
+		},
+		{
+			name: "quiz without correct option",
+			json: fmt.Sprintf(
+				`{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+					"expires_at":%q,
+					"poll_type":"quiz"
+					}`,
+				expiresValid,
+			),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"options":"must mark at least one option as correct"}}`,
+		},
+		{
+			name: "quiz without expires_at",
+			json: `{
+				"question":"Test?", 
+				"options":[{"value":"first","position":0,"is_correct":true}, {"value":"second","position":1}],
+				"poll_type":"quiz"
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"expires_at":"must be set for quiz polls"}}`,
+		},
+		{
+			name: "valid quiz",
+			json: fmt.Sprintf(
+				`{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0,"is_correct":true}, {"value":"second","position":1}],
+					"expires_at":%q,
+					"poll_type":"quiz",
+					"series":"trivia"
+					}`,
+				expiresValid,
+			),
+			authHeader:     "Bearer " + data.ExampleSessionToken,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"series_id":"`,
+		},
+		{
+			name: "series on anonymous quiz",
+			json: fmt.Sprintf(
+				`{
+					"question":"Test?", 
+					"options":[{"value":"first","position":0,"is_correct":true}, {"value":"second","position":1}],
+					"expires_at":%q,
+					"poll_type":"quiz",
+					"series":"trivia"
+					}`,
+				expiresValid,
+			),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"series":"requires a signed in user or an API key"}}`,
+		},
+		{
+			name: "series on single choice poll",
+			json: `{
+				"question":"Test?", 
+				"options":[{"value":"first","position":0}, {"value":"second","position":1}],
+				"series":"trivia"
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"series":"only supported for quiz polls"}}`,
+		},
+		{
+			name: "valid schedule poll",
+			json: `{
+				"question":"Meeting?", 
+				"poll_type":"schedule",
+				"options":[
+					{"position":0,"slot":{"start":"2030-03-04T09:00:00Z","end":"2030-03-04T10:00:00Z","time_zone":"Europe/Berlin"}},
+					{"position":1,"slot":{"start":"2030-03-04T10:00:00Z","end":"2030-03-04T11:00:00Z","time_zone":"Europe/Berlin"}}
+				]
+				}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"value":"2030-03-04 10:00 - 11:00 (Europe/Berlin)"`,
+		},
+		{
+			name: "overlapping time slots",
+			json: `{
+				"question":"Meeting?", 
+				"poll_type":"schedule",
+				"options":[
+					{"position":0,"slot":{"start":"2030-03-04T09:00:00Z","end":"2030-03-04T10:30:00Z","time_zone":"UTC"}},
+					{"position":1,"slot":{"start":"2030-03-04T10:00:00Z","end":"2030-03-04T11:00:00Z","time_zone":"UTC"}}
+				]
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"options":"time slots must not overlap"}}`,
+		},
+		{
+			name: "duplicate time slots",
+			json: `{
+				"question":"Meeting?", 
+				"poll_type":"schedule",
+				"options":[
+					{"value":"a","position":0,"slot":{"start":"2030-03-04T09:00:00Z","end":"2030-03-04T10:00:00Z","time_zone":"UTC"}},
+					{"value":"b","position":1,"slot":{"start":"2030-03-04T09:00:00Z","end":"2030-03-04T10:00:00Z","time_zone":"UTC"}}
+				]
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"options":"must not contain duplicate time slots"}}`,
+		},
+		{
+			name: "invalid time zone",
+			json: `{
+				"question":"Meeting?", 
+				"poll_type":"schedule",
+				"options":[
+					{"position":0,"slot":{"start":"2030-03-04T09:00:00Z","end":"2030-03-04T10:00:00Z","time_zone":"Mars/Olympus"}},
+					{"position":1,"slot":{"start":"2030-03-04T10:00:00Z","end":"2030-03-04T11:00:00Z","time_zone":"UTC"}}
+				]
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"options":"time slots must have a valid time_zone"}}`,
+		},
+		{
+			name: "schedule option without slot",
+			json: `{
+				"question":"Meeting?", 
+				"poll_type":"schedule",
+				"options":[{"value":"first","position":0}, {"value":"second","position":1}]
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"options":"every option must have a time slot"}}`,
+		},
+		{
+			name: "valid poll signed in",
+			json: `{
+				"question":"Test?", 
+				"options":[{"value":"first","position":0}, {"value":"second","position":1}]
+				}`,
+			authHeader:     "Bearer " + data.ExampleSessionToken,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"question":"Test?"`,
+		},
+		{
+			name: "invalid session",
+			json: `{
+				"question":"Test?", 
+				"options":[{"value":"first","position":0}, {"value":"second","position":1}]
+				}`,
+			authHeader:     "Bearer UNKNOWNSESSIONAAAAAAAAAAAA",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid or missing token",
+		},
+		{
+			name: "valid matrix poll",
+			json: `{
+				"question":"How do you feel about the office?", 
+				"poll_type":"matrix",
+				"scale":["Disagree","Neutral","Agree"],
+				"options":[{"value":"The coffee is good","position":0}, {"value":"The chairs are comfortable","position":1}]
+				}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"scale":["Disagree","Neutral","Agree"]`,
+		},
+		{
+			name: "matrix poll without scale",
+			json: `{
+				"question":"How do you feel about the office?", 
+				"poll_type":"matrix",
+				"options":[{"value":"The coffee is good","position":0}, {"value":"The chairs are comfortable","position":1}]
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"scale":"must contain between 2 and 11 labels"}}`,
+		},
+		{
+			name: "voter_auth poll with public ballots",
+			json: `{
+				"question":"Test?",
+				"voter_auth":true,
+				"public_ballots":true,
+				"options":[{"value":"first","position":0}, {"value":"second","position":1}]
+				}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"voter_auth":true,"public_ballots":true`,
+		},
+		{
+			name: "public ballots without voter_auth",
+			json: `{
+				"question":"Test?",
+				"public_ballots":true,
+				"options":[{"value":"first","position":0}, {"value":"second","position":1}]
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"public_ballots":"requires voter_auth"}}`,
+		},
+		{
+			name: "voter_auth on open text poll",
+			json: `{
+				"question":"Test?",
+				"poll_type":"open_text",
+				"voter_auth":true
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"voter_auth":"only supported for single_choice polls"}}`,
+		},
+		{
+			name: "voter_auth with write-ins",
+			json: `{
+				"question":"Test?",
+				"voter_auth":true,
+				"allow_write_in":true,
+				"options":[{"value":"first","position":0}, {"value":"second","position":1}]
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"voter_auth":"not supported with allow_write_in"}}`,
+		},
+		{
+			name: "scale on single choice poll",
+			json: `{
+				"question":"Test?", 
+				"scale":["No","Yes"],
+				"options":[{"value":"first","position":0}, {"value":"second","position":1}]
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"scale":"only supported for matrix polls"}}`,
+		},
+		{
+			name: "write-in on open text poll",
+			json: `{
+				"question":"Test?", 
+				"poll_type":"open_text",
+				"allow_write_in":true
+				}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"allow_write_in":"only supported for single_choice polls"}}`,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
 			rr := httptest.NewRecorder()
 			handler := http.HandlerFunc(app.createPollHandler)
 			handler.ServeHTTP(rr, req)
@@ -206,3 +463,202 @@ func Test_app_createPollHandler(t *testing.T) {
 		})
 	}
 }
+
+func Test_app_createPollHandlerTokenFormat(t *testing.T) {
+	body := func(format string) string {
+		return fmt.Sprintf(`{
+			"question":"Test?",
+			"options":[{"value":"first","position":0},{"value":"second","position":1}],
+			"token_format":%q
+			}`, format)
+	}
+
+	createPoll := func(payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(app.createPollHandler).ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := createPoll(body("jwt"))
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, but got %d", http.StatusUnprocessableEntity, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "jwt tokens are not enabled") {
+		t.Errorf("expected jwt tokens to be disabled, but got %q", rr.Body)
+	}
+
+	keys := withJWTKeys(t)
+
+	rr = createPoll(body("bearer"))
+	if !strings.Contains(rr.Body.String(), `{"error":{"token_format":"invalid token_format value"}}`) {
+		t.Errorf("expected invalid token_format error, but got %q", rr.Body)
+	}
+
+	rr = createPoll(body("jwt"))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, but got %d", http.StatusCreated, rr.Code)
+	}
+
+	var resp struct {
+		Poll struct {
+			ID    string `json:"id"`
+			Token string `json:"token"`
+		} `json:"poll"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := keys.Verify(resp.Poll.Token)
+	if err != nil {
+		t.Fatalf("expected a valid signed token, but got %v", err)
+	}
+	if claims.Subject != resp.Poll.ID {
+		t.Errorf("expected token subject %q, but got %q", resp.Poll.ID, claims.Subject)
+	}
+	if len(claims.Scopes) != len(data.AllScopes) {
+		t.Errorf("expected all scopes, but got %v", claims.Scopes)
+	}
+}
+
+func Test_app_createPollHandlerVoterRestrictions(t *testing.T) {
+	body := func(issuer, domain string) string {
+		return fmt.Sprintf(`{
+			"question":"Test?",
+			"options":[{"value":"first","position":0},{"value":"second","position":1}],
+			"voter_issuer":%q,
+			"voter_email_domain":%q
+			}`, issuer, domain)
+	}
+
+	createPoll := func(payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(app.createPollHandler).ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := createPoll(body("https://sso.example.com", ""))
+	if !strings.Contains(rr.Body.String(), `{"error":{"voter_issuer":"must be the issuer users sign in with"}}`) {
+		t.Errorf("expected voter_issuer to be rejected without sign in, but got %q", rr.Body)
+	}
+
+	issuer := withOIDC(t, oidctest.User{Subject: "carol", Email: "carol@example.com", EmailVerified: oidctest.Verified(true)})
+
+	tests := []struct {
+		name           string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "issuer and domain",
+			json:           body(issuer.URL, "@Example.com"),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"voter_email_domain":"example.com"`,
+		},
+		{
+			name:           "unknown issuer",
+			json:           body("https://other.example.com", ""),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"voter_issuer":"must be the issuer users sign in with"}}`,
+		},
+		{
+			name:           "domain without issuer",
+			json:           body("", "example.com"),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"voter_email_domain":"requires voter_issuer"}}`,
+		},
+		{
+			name:           "invalid domain",
+			json:           body(issuer.URL, "example"),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"voter_email_domain":"must be a valid domain"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := createPoll(test.json)
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_createPollHandlerOrganization(t *testing.T) {
+	body := func(orgID string) string {
+		return fmt.Sprintf(`{
+			"question":"Test?",
+			"options":[{"value":"first","position":0},{"value":"second","position":1}],
+			"is_private":true,
+			"org_id":%q
+			}`, orgID)
+	}
+
+	tests := []struct {
+		name           string
+		json           string
+		authHeader     string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "org owner",
+			json:           body(data.ExampleOrgID),
+			authHeader:     "Bearer " + data.ExampleSessionToken,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"org_id":"` + data.ExampleOrgID + `"`,
+		},
+		{
+			name:           "org viewer",
+			json:           body(data.ExampleOrgID),
+			authHeader:     "Bearer " + data.ExampleSessionTokenViewer,
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "your role in the organization does not permit this",
+		},
+		{
+			name:           "not a member",
+			json:           body(data.ExampleOrgID),
+			authHeader:     "Bearer " + data.ExampleSessionTokenOther,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"org_id":"must be an organization you belong to"}}`,
+		},
+		{
+			name:           "not signed in",
+			json:           body(data.ExampleOrgID),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"org_id":"requires a signed in user"}}`,
+		},
+		{
+			name:           "invalid org id",
+			json:           body("marketing"),
+			authHeader:     "Bearer " + data.ExampleSessionToken,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"org_id":"must be a valid id"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(app.createPollHandler).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
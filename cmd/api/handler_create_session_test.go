@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_app_createSessionHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "valid credentials",
+			json:           `{"email":"Alice@example.com","password":"pa55word"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"token":"`,
+		},
+		{
+			name:           "wrong password",
+			json:           `{"email":"alice@example.com","password":"wrongpassword"}`,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid authentication credentials",
+		},
+		{
+			name:           "unknown email",
+			json:           `{"email":"nobody@example.com","password":"pa55word"}`,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid authentication credentials",
+		},
+		{
+			name:           "missing password",
+			json:           `{"email":"alice@example.com"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"password":"must be provided"}}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.createSessionHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) createTokenHandler(w http.ResponseWriter, r *http.Request) {
+	pollID := app.pollIDfromContext(r.Context())
+
+	var input struct {
+		Label     string         `json:"label"`
+		Scopes    []string       `json:"scopes"`
+		ExpiresAt data.ExpiresAt `json:"expires_at"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	pollToken := &data.PollToken{
+		PollID: pollID,
+		Label:  strings.TrimSpace(input.Label),
+		Scopes: input.Scopes,
+	}
+	if !input.ExpiresAt.IsZero() {
+		pollToken.Expiry = &input.ExpiresAt.Time
+	}
+
+	v := validator.New()
+	if data.ValidatePollToken(v, pollToken); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	token, err := data.GenerateToken()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.models.Tokens.Insert(pollToken, token.Hash)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	pollToken.Plaintext = token.Plaintext
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"token": pollToken}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_createTokenHandler(t *testing.T) {
+	expiresValid := time.Now().Add(time.Hour).Format(time.RFC3339)
+	expiresInvalid := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		name           string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "valid token",
+			json:           `{"label":"dashboard","scopes":["results:read"],"expires_at":"` + expiresValid + `"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"label":"dashboard","scopes":["results:read"]`,
+		},
+		{
+			name:           "token without expiry",
+			json:           `{"scopes":["poll:edit","poll:delete"]}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"expires_at":null`,
+		},
+		{
+			name:           "no scopes",
+			json:           `{"label":"dashboard"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"scopes":"must contain at least one scope"}}`,
+		},
+		{
+			name:           "unknown scope",
+			json:           `{"scopes":["poll:own"]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"scopes":"invalid scope value"}}`,
+		},
+		{
+			name:           "duplicate scopes",
+			json:           `{"scopes":["poll:edit","poll:edit"]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"scopes":"must not contain duplicate scopes"}}`,
+		},
+		{
+			name:           "expiry in the past",
+			json:           `{"scopes":["poll:edit"],"expires_at":"` + expiresInvalid + `"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"expires_at":"must be in the future"}}`,
+		},
+		{
+			name:           "label too long",
+			json:           `{"scopes":["poll:edit"],"label":"` + strings.Repeat("a", 101) + `"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"label":"must not be more than 100 bytes long"}}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, data.ExamplePollIDValid))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.createTokenHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if rr.Code == http.StatusCreated && !strings.Contains(rr.Body.String(), `"token":"`) {
+				t.Errorf("expected plaintext token in response, but got %q", rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// createVoterCredentialHandler mints a credential for someone without an
+// account to vote on a voter_auth poll. The label is their name on public
+// ballots.
+func (app *application) createVoterCredentialHandler(w http.ResponseWriter, r *http.Request) {
+	pollID := app.pollIDfromContext(r.Context())
+
+	var input struct {
+		Label string `json:"label"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	poll, err := app.models.Polls.Get(r.Context(), pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !poll.VoterAuth {
+		app.badRequestResponse(w, errors.New("poll does not use voter_auth"))
+		return
+	}
+	if poll.VoterIssuer != "" || poll.VoterEmailDomain != "" {
+		app.badRequestResponse(w, errors.New("poll only accepts voters signed in with its identity provider"))
+		return
+	}
+
+	credential := &data.VoterCredential{Label: strings.TrimSpace(input.Label)}
+
+	v := validator.New()
+	if data.ValidateVoterCredential(v, credential); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	token, err := data.GenerateToken()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.models.Voters.InsertCredential(credential, pollID, token.Hash)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	credential.Plaintext = token.Plaintext
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"voter": credential}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_createVoterCredentialHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"valid credential", data.ExamplePollIDVoterAuth, `{"label":" Erin "}`, http.StatusCreated, `"label":"Erin"`},
+		{"missing label", data.ExamplePollIDVoterAuth, `{"label":""}`, http.StatusUnprocessableEntity, `{"error":{"label":"must be provided"}}`},
+		{"label too long", data.ExamplePollIDVoterAuth, `{"label":"` + strings.Repeat("a", 101) + `"}`, http.StatusUnprocessableEntity, `{"error":{"label":"must not be more than 100 bytes long"}}`},
+		{"anonymous poll", data.ExamplePollIDValid, `{"label":"Erin"}`, http.StatusBadRequest, "poll does not use voter_auth"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, test.pollID))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.createVoterCredentialHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if rr.Code == http.StatusCreated && !strings.Contains(rr.Body.String(), `"credential":"`) {
+				t.Errorf("expected the plaintext credential, but got %q", rr.Body)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 func (app *application) deleteOptionHandler(w http.ResponseWriter, r *http.Request) {
 	poll := app.pollFromContext(r.Context())
+	before := data.NewPollSnapshot(poll)
 
 	optionID, err := app.readIDParam(r, "optionID")
 	if err != nil {
@@ -49,20 +50,15 @@ func (app *application) deleteOptionHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	err = app.models.PollOptions.Delete(optionID)
+	revision := data.NewRevision(data.RevisionDeleteOption, app.actorFromContext(r.Context()), before)
+	err = app.models.PollOptions.Delete(r.Context(), optionID, revision)
 	if err != nil {
-		app.serverErrorResponse(w, err)
-		return
-	}
-
-	err = app.models.PollOptions.UpdatePosition(poll.Options)
-	if err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
 		return
 	}
 
 	err = app.writeJSON(w, http.StatusOK, envelope{"message": "option deleted successfully"}, nil)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
 	}
 }
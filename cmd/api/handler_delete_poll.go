@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/ivcp/polls/internal/data"
 )
@@ -10,19 +11,25 @@ import (
 func (app *application) deletePollHandler(w http.ResponseWriter, r *http.Request) {
 	id := app.pollIDfromContext(r.Context())
 
-	err := app.models.Polls.Delete(id)
+	revision := data.NewRevision(data.RevisionDelete, app.actorFromContext(r.Context()), nil)
+	err := app.models.Polls.Delete(r.Context(), id, revision)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
 			app.notFoundResponse(w, r)
 		default:
-			app.serverErrorResponse(w, err)
+			app.serverErrorResponse(w, r, err)
 		}
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"message": "poll successfully deleted"}, nil)
+	env := envelope{
+		"message":          "poll successfully deleted",
+		"restorable_until": time.Now().Add(app.config.deletion.gracePeriod).Truncate(time.Second),
+	}
+
+	err = app.writeJSON(w, http.StatusOK, env, nil)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
 	}
 }
@@ -18,7 +18,7 @@ func Test_app_deletePollHandler(t *testing.T) {
 		expectedStatus int
 		expectedBody   string
 	}{
-		{"delete a poll", data.ExamplePollIDValid, http.StatusOK, "poll successfully deleted"},
+		{"delete a poll", data.ExamplePollIDValid, http.StatusOK, `"restorable_until":`},
 		{"poll not found", uuid.NewString(), http.StatusNotFound, "the requested resource could not be found"},
 	}
 
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_deleteSessionHandler(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodDelete, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+data.ExampleSessionToken)
+	rr := httptest.NewRecorder()
+	handler := app.requireUser(http.HandlerFunc(app.deleteSessionHandler))
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "signed out successfully") {
+		t.Errorf("expected sign out message, but got %q", rr.Body)
+	}
+}
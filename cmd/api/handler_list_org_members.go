@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net/http"
+)
+
+func (app *application) listOrgMembersHandler(w http.ResponseWriter, r *http.Request) {
+	orgID, err := app.readIDParam(r, "orgID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	members, err := app.models.Organizations.GetMembers(orgID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"members": members}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
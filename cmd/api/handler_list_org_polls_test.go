@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_listOrgPollsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		orgID          string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "includes private polls",
+			orgID:          data.ExampleOrgID,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"id":"` + data.ExamplePollIDOrgPrivate + `"`,
+		},
+		{
+			name:           "organization without polls",
+			orgID:          uuid.NewString(),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"polls":[]`,
+		},
+		{
+			name:           "invalid sort value",
+			orgID:          data.ExampleOrgID,
+			query:          "?sort=test",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"sort":"invalid sort value"`,
+		},
+		{
+			name:           "invalid org id",
+			orgID:          "a",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid id",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/"+test.query, nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("orgID", test.orgID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.listOrgPollsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_listOrganizationsHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		userID       string
+		expectedBody string
+	}{
+		{"owner", data.ExampleUserID, `"role":"owner"`},
+		{"viewer", data.ExampleOrgViewerID, `"role":"viewer"`},
+		{"no organizations", "2c4e6a8b-0d1f-4a3c-9e5b-7d9f1b3d5e7a", `"organizations":[]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			user := &data.User{ID: test.userID}
+			req = req.WithContext(context.WithValue(req.Context(), ctxUserKey, user))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.listOrganizationsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Errorf("expected status %d, but got %d", http.StatusOK, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
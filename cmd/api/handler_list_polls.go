@@ -28,9 +28,9 @@ func (app *application) listPollsHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	polls, metadata, err := app.models.Polls.GetAll(input.Search, input.Filters)
+	polls, metadata, err := app.models.Polls.GetAll(r.Context(), input.Search, input.Filters)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
 		return
 	}
 
@@ -40,6 +40,6 @@ func (app *application) listPollsHandler(w http.ResponseWriter, r *http.Request)
 		envelope{"polls": polls, "metadata": metadata},
 		nil,
 	); err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
 	}
 }
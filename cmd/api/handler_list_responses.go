@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) listResponsesHandler(w http.ResponseWriter, r *http.Request) {
+	pollID := app.pollIDfromContext(r.Context())
+
+	v := validator.New()
+
+	status := app.readString(r.URL.Query(), "status", "")
+	if status != "" {
+		v.Check(
+			validator.PermittedValue(status, data.ResponseStatusSafelist...),
+			"status",
+			"invalid status value",
+		)
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	responses, err := app.models.PollResponses.GetAll(pollID, status)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"responses": responses}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) listUserPollsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.userFromContext(r.Context())
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	var filters data.Filters
+	filters.Page = app.readInt(qs, "page", 1, v)
+	filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	filters.Sort = app.readString(qs, "sort", "-created_at")
+	filters.SortSafelist = []string{"created_at", "question", "-created_at", "-question"}
+
+	if data.ValidateFilters(v, filters); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	polls, metadata, err := app.models.Polls.GetAllForOwner(r.Context(), user.ID, filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if err := app.writeJSON(
+		w,
+		http.StatusOK,
+		envelope{"polls": polls, "metadata": metadata},
+		nil,
+	); err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_listUserPollsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "owner with polls",
+			userID:         data.ExampleUserID,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"id":"` + data.ExamplePollIDValid + `"`,
+		},
+		{
+			name:           "owner without polls",
+			userID:         "2c4e6a8b-0d1f-4a3c-9e5b-7d9f1b3d5e7a",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"polls":[]`,
+		},
+		{
+			name:           "invalid sort value",
+			userID:         data.ExampleUserID,
+			query:          "?sort=test",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"sort":"invalid sort value"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/"+test.query, nil)
+			user := &data.User{ID: test.userID}
+			req = req.WithContext(context.WithValue(req.Context(), ctxUserKey, user))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.listUserPollsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
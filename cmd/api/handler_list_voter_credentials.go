@@ -0,0 +1,20 @@
+package main
+
+import (
+	"net/http"
+)
+
+func (app *application) listVoterCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	pollID := app.pollIDfromContext(r.Context())
+
+	credentials, err := app.models.Voters.GetCredentials(pollID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"voters": credentials}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_mergeResponseHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		responseID     string
+		targetID       string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"valid merge", data.ExampleResponseID2, data.ExampleResponseID1, http.StatusOK, "response merged successfully"},
+		{"merge into itself", data.ExampleResponseID1, data.ExampleResponseID1, http.StatusUnprocessableEntity, "must not be the same response"},
+		{"merge into merged", data.ExampleResponseID2, data.ExampleResponseIDMerged, http.StatusUnprocessableEntity, "merged responses cannot be merged again"},
+		{"unexisting target", data.ExampleResponseID2, uuid.NewString(), http.StatusNotFound, "the requested resource could not be found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"target_id":%q}`, test.targetID)
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("responseID", test.responseID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, data.ExamplePollIDOpenText))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.mergeResponseHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ivcp/polls/internal/auth"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// oidcCallbackHandler finishes a login started by oidcLoginHandler and
+// starts a session for the user, creating them on their first login.
+func (app *application) oidcCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	if app.oidc == nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	qs := r.URL.Query()
+	if providerErr := qs.Get("error"); providerErr != "" {
+		app.badRequestResponse(w, fmt.Errorf("identity provider returned %q", providerErr))
+		return
+	}
+
+	req, ok := readLoginCookie(r)
+	state := qs.Get("state")
+	if !ok || state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(req.State)) != 1 {
+		app.badRequestResponse(w, errors.New("invalid or expired login state"))
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Path:     "/v1/auth/oidc",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	identity, err := app.oidc.Exchange(ctx, qs.Get("code"), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidLogin):
+			app.logError(r, err)
+			app.invalidCredentialsResponse(w)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	user := &data.User{
+		Email:   strings.ToLower(identity.Email),
+		Name:    identity.Name,
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+	}
+
+	v := validator.New()
+	if data.ValidateUser(v, user); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	err = app.models.Users.UpsertOIDC(user)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateEmail):
+			v.AddError("email", "another account from the identity provider has this email address")
+			app.failedValidationResponse(w, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	token, err := app.models.Users.NewSession(user.ID, sessionTTL)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{
+		"session": envelope{
+			"token":  token.Plaintext,
+			"expiry": time.Now().Add(sessionTTL).UTC().Truncate(time.Second),
+		},
+		"user": user,
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/auth/oidctest"
+	"github.com/ivcp/polls/internal/data"
+)
+
+// startLogin runs the login handler and lets the test issuer approve it,
+// returning the login cookie and the query the browser comes back with.
+func startLogin(t *testing.T) (*http.Cookie, url.Values) {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, "/v1/auth/oidc/login", nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(app.oidcLoginHandler).ServeHTTP(rr, req)
+
+	location, err := rr.Result().Location()
+	if err != nil {
+		t.Fatalf("expected redirect to the issuer, but got status %d", rr.Code)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(location.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	callback, err := resp.Location()
+	if err != nil {
+		t.Fatalf("expected redirect back from the issuer, but got status %d", resp.StatusCode)
+	}
+
+	return rr.Result().Cookies()[0], callback.Query()
+}
+
+func Test_app_oidcCallbackHandler(t *testing.T) {
+	issuer := withOIDC(t, oidctest.User{Subject: "carol", Email: "Carol@Example.com", EmailVerified: oidctest.Verified(true), Name: "Carol"})
+
+	tests := []struct {
+		name           string
+		user           oidctest.User
+		query          func(query url.Values)
+		withoutCookie  bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "sign in",
+			user:           oidctest.User{Subject: "carol", Email: "Carol@Example.com", EmailVerified: oidctest.Verified(true), Name: "Carol"},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"email":"carol@example.com"`,
+		},
+		{
+			name:           "email of a password account",
+			user:           oidctest.User{Subject: "alice", Email: data.ExampleUserEmail, EmailVerified: oidctest.Verified(true)},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"email":"alice@example.com"`,
+		},
+		{
+			name:           "email of another identity",
+			user:           oidctest.User{Subject: "grace-2", Email: data.ExampleOrgViewerEmail, EmailVerified: oidctest.Verified(true)},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "another account from the identity provider has this email address",
+		},
+		{
+			name:           "no login cookie",
+			user:           oidctest.User{Subject: "carol", Email: "carol@example.com", EmailVerified: oidctest.Verified(true)},
+			withoutCookie:  true,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid or expired login state",
+		},
+		{
+			name:           "state mismatch",
+			user:           oidctest.User{Subject: "carol", Email: "carol@example.com", EmailVerified: oidctest.Verified(true)},
+			query:          func(q url.Values) { q.Set("state", "forged") },
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid or expired login state",
+		},
+		{
+			name:           "invalid code",
+			user:           oidctest.User{Subject: "carol", Email: "carol@example.com", EmailVerified: oidctest.Verified(true)},
+			query:          func(q url.Values) { q.Set("code", "forged") },
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid authentication credentials",
+		},
+		{
+			name:           "error from the provider",
+			user:           oidctest.User{Subject: "carol", Email: "carol@example.com", EmailVerified: oidctest.Verified(true)},
+			query:          func(q url.Values) { q.Set("error", "access_denied") },
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `identity provider returned \"access_denied\"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issuer.SetUser(test.user)
+			cookie, query := startLogin(t)
+			if test.query != nil {
+				test.query(query)
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, "/v1/auth/oidc/callback?"+query.Encode(), nil)
+			if !test.withoutCookie {
+				req.AddCookie(cookie)
+			}
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(app.oidcCallbackHandler).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if rr.Code == http.StatusCreated && !strings.Contains(rr.Body.String(), `"issuer":"`+issuer.URL+`"`) {
+				t.Errorf("expected user to carry the issuer, but got %q", rr.Body)
+			}
+		})
+	}
+}
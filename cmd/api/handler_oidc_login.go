@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ivcp/polls/internal/auth"
+)
+
+const (
+	oidcCookieName = "oidc_login"
+	oidcCookieTTL  = 10 * 60
+)
+
+// oidcLoginHandler sends the browser to the identity provider. The values
+// that tie the callback to this login are kept in a cookie.
+func (app *application) oidcLoginHandler(w http.ResponseWriter, r *http.Request) {
+	if app.oidc == nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	req, err := auth.NewLoginRequest()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Value:    strings.Join([]string{req.State, req.Nonce, req.Verifier}, "."),
+		Path:     "/v1/auth/oidc",
+		MaxAge:   oidcCookieTTL,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, app.oidc.AuthCodeURL(req), http.StatusFound)
+}
+
+func readLoginCookie(r *http.Request) (*auth.LoginRequest, bool) {
+	cookie, err := r.Cookie(oidcCookieName)
+	if err != nil {
+		return nil, false
+	}
+
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+
+	return &auth.LoginRequest{State: parts[0], Nonce: parts[1], Verifier: parts[2]}, true
+}
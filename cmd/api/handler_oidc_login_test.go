@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/auth/oidctest"
+)
+
+func Test_app_oidcLoginHandler(t *testing.T) {
+	serve := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/v1/auth/oidc/login", nil)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(app.oidcLoginHandler).ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := serve(); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d when sign in is disabled, but got %d", http.StatusNotFound, rr.Code)
+	}
+
+	issuer := withOIDC(t, oidctest.User{Subject: "carol", Email: "carol@example.com", EmailVerified: oidctest.Verified(true)})
+
+	rr := serve()
+	if rr.Code != http.StatusFound {
+		t.Fatalf("expected status %d, but got %d", http.StatusFound, rr.Code)
+	}
+
+	location, err := rr.Result().Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(location.String(), issuer.URL+"/authorize") {
+		t.Errorf("expected redirect to the issuer, but got %q", location)
+	}
+	if location.Query().Get("code_challenge_method") != "S256" {
+		t.Errorf("expected a PKCE challenge, but got %q", location.RawQuery)
+	}
+
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != oidcCookieName {
+		t.Fatalf("expected login cookie, but got %v", cookies)
+	}
+	if !cookies[0].HttpOnly || !cookies[0].Secure {
+		t.Errorf("expected login cookie to be HttpOnly and Secure")
+	}
+	state := strings.Split(cookies[0].Value, ".")[0]
+	if location.Query().Get("state") != state {
+		t.Errorf("expected state %q in redirect, but got %q", state, location.Query().Get("state"))
+	}
+}
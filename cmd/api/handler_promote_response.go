@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) promoteResponseHandler(w http.ResponseWriter, r *http.Request) {
+	poll := app.pollFromContext(r.Context())
+	before := data.NewPollSnapshot(poll)
+
+	responseID, err := app.readIDParam(r, "responseID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	response, err := app.models.PollResponses.Get(responseID, poll.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	v := validator.New()
+	v.Check(response.Status == data.ResponseStatusApproved, "status", "only approved responses can be promoted")
+	v.Check(response.OptionID == "", "status", "response has already been promoted")
+	if !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	newOption := &data.PollOption{
+		Value:     response.Value,
+		Position:  len(poll.Options),
+		VoteCount: response.Count,
+	}
+
+	poll.Options = append(poll.Options, newOption)
+
+	if data.ValidatePoll(v, poll); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	revision := data.NewRevision(data.RevisionPromoteResponse, app.actorFromContext(r.Context()), before)
+	err = app.models.PollResponses.Promote(response, newOption, poll.ID, revision)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrDuplicateOption):
+			app.duplicateOptionResponse(w)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"option": newOption}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
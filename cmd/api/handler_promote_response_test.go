@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_promoteResponseHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		responseID     string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"promote approved", data.ExampleResponseID1, http.StatusCreated, `"value":"Pizza"`},
+		{"promote pending", data.ExampleResponseID2, http.StatusUnprocessableEntity, "only approved responses can be promoted"},
+		{"unexisting response", uuid.NewString(), http.StatusNotFound, "the requested resource could not be found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("responseID", test.responseID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			poll, _ := app.models.Polls.Get(context.Background(), data.ExamplePollIDOpenText)
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.promoteResponseHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
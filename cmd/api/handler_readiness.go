@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// readinessHandler tells a load balancer whether to send traffic our way.
+// It fails while the database is unreachable or behind on migrations, and
+// once shutdown has begun.
+func (app *application) readinessHandler(w http.ResponseWriter, r *http.Request) {
+	if app.shuttingDown() {
+		app.errorJSONResponse(w, http.StatusServiceUnavailable, "server is shutting down")
+		return
+	}
+
+	if app.ready != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+
+		if err := app.ready(ctx); err != nil {
+			app.logError(r, err)
+			message := "database unavailable"
+			if errors.Is(err, errMigrationsPending) {
+				message = "migrations pending"
+			}
+			app.errorJSONResponse(w, http.StatusServiceUnavailable, message)
+			return
+		}
+	}
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"status": "ready"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
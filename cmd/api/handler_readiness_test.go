@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_app_readinessHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		ready          func(context.Context) error
+		shuttingDown   bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"ready", func(context.Context) error { return nil }, false, http.StatusOK, `"status":"ready"`},
+		{"database down", func(context.Context) error { return errors.New("ping database: refused") }, false, http.StatusServiceUnavailable, `"error":"database unavailable"`},
+		{"migrations pending", func(context.Context) error {
+			return fmt.Errorf("%w: database is at migration 22, expected 23", errMigrationsPending)
+		}, false, http.StatusServiceUnavailable, `"error":"migrations pending"`},
+		{"shutting down", func(context.Context) error { return nil }, true, http.StatusServiceUnavailable, "shutting down"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := &application{logger: app.logger, ready: test.ready, done: make(chan struct{})}
+			if test.shuttingDown {
+				close(a.done)
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(a.readinessHandler).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if strings.Contains(rr.Body.String(), "migration 22") || strings.Contains(rr.Body.String(), "refused") {
+				t.Errorf("expected the error to stay out of the body, but got %q", rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_readinessRateLimited(t *testing.T) {
+	limits := app.limiter.Load()
+	app.limiter.Store(&limiterSettings{RPS: 0.01, Burst: 1, Enabled: true})
+	t.Cleanup(func() {
+		app.limiter.Store(limits)
+	})
+	mux := testRoutes()
+
+	serve := func(target string) int {
+		req, _ := http.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("X-Forwarded-For", "10.0.44.1")
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	serve("/v1/healthcheck/ready")
+	if code := serve("/v1/healthcheck/ready"); code != http.StatusTooManyRequests {
+		t.Errorf("expected readiness to be rate limited, but got status %d", code)
+	}
+	if code := serve("/v1/healthcheck/live"); code != http.StatusOK {
+		t.Errorf("expected liveness to skip the rate limiter, but got status %d", code)
+	}
+}
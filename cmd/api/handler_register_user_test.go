@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_app_registerUserHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "valid user",
+			json:           `{"email":" Bob@Example.com ","name":"Bob","password":"pa55word"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"email":"bob@example.com","name":"Bob"`,
+		},
+		{
+			name:           "email taken",
+			json:           `{"email":"alice@example.com","password":"pa55word"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"email":"a user with this email address already exists"}}`,
+		},
+		{
+			name:           "invalid email",
+			json:           `{"email":"bob","password":"pa55word"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"email":"must be a valid email address"}}`,
+		},
+		{
+			name:           "short password",
+			json:           `{"email":"bob@example.com","password":"pass"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"password":"must be at least 8 bytes long"}}`,
+		},
+		{
+			name:           "long password",
+			json:           `{"email":"bob@example.com","password":"` + strings.Repeat("a", 73) + `"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"password":"must not be more than 72 bytes long"}}`,
+		},
+		{
+			name:           "unknown field",
+			json:           `{"email":"bob@example.com","password":"pa55word","admin":true}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `body contains unknown key \"admin\"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.registerUserHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if strings.Contains(rr.Body.String(), "pa55word") {
+				t.Errorf("response must not contain the password")
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func (app *application) removeOrgMemberHandler(w http.ResponseWriter, r *http.Request) {
+	orgID, err := app.readIDParam(r, "orgID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	userID, err := app.readIDParam(r, "userID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	err = app.models.Organizations.RemoveMember(orgID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrLastOwner):
+			app.lastOwnerResponse(w)
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "member successfully removed"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
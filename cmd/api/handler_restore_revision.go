@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// restoreRevisionHandler puts the poll back the way it was right after an
+// earlier revision. Like other edits, it's refused once voting has begun.
+func (app *application) restoreRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	poll := app.pollFromContext(r.Context())
+	before := data.NewPollSnapshot(poll)
+
+	revisionID, err := app.readIDParam(r, "revisionID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	revision, err := app.models.Revisions.Get(revisionID, poll.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	revision.Snapshot.Apply(poll)
+
+	v := validator.New()
+
+	if data.ValidatePoll(v, poll); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	restore := data.NewRevision(data.RevisionRestore, app.actorFromContext(r.Context()), before)
+	err = app.models.Polls.Restore(r.Context(), poll, restore)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateOption):
+			app.duplicateOptionResponse(w)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"poll": poll}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_restoreRevisionHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		revisionID     string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "restore revision",
+			revisionID:     data.ExampleRevisionID,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"question":"Earlier?"`,
+		},
+		{
+			name:           "revision expired since",
+			revisionID:     data.ExampleRevisionIDExpired,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"expires_at"`,
+		},
+		{
+			name:           "revision not found",
+			revisionID:     uuid.NewString(),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+		{
+			name:           "invalid revision id",
+			revisionID:     "a",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid id",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", nil)
+			poll, _ := app.models.Polls.Get(context.Background(), data.ExamplePollIDValid)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("revisionID", test.revisionID)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx)
+			ctx = context.WithValue(ctx, ctxPollKey, poll)
+			req = req.WithContext(ctx)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.restoreRevisionHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if rr.Code == http.StatusOK && strings.Contains(rr.Body.String(), data.ExampleOptionID3) {
+				t.Errorf("expected option added after the revision to be gone, but got %q", rr.Body)
+			}
+		})
+	}
+}
+
+func Test_app_restoreRevisionHandlerAfterResponses(t *testing.T) {
+	limits := app.limiter.Load()
+	app.limiter.Store(&limiterSettings{})
+	t.Cleanup(func() {
+		app.limiter.Store(limits)
+	})
+	keys := withJWTKeys(t)
+	token, _, err := keys.Sign(data.ExamplePollIDOpenTextAnswered, data.AllScopes, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, "/v1/polls/"+data.ExamplePollIDOpenTextAnswered+"/revisions/"+data.ExampleRevisionID+"/restore", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	testRoutes().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, but got %d", http.StatusForbidden, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "voting has begun") {
+		t.Errorf("expected body to contain %q, but got %q", "voting has begun", rr.Body)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/auth"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// revokeJWTHandler adds a signed token to the deny list. Signed tokens are
+// not stored, so the caller has to hand over the token itself.
+func (app *application) revokeJWTHandler(w http.ResponseWriter, r *http.Request) {
+	pollID := app.pollIDfromContext(r.Context())
+
+	var input struct {
+		Token string `json:"token"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(input.Token != "", "token", "must be provided")
+	v.Check(input.Token == "" || auth.LooksLikeJWT(input.Token), "token", "must be a signed token")
+	if !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if app.jwtKeys == nil {
+		app.badRequestResponse(w, errors.New("jwt tokens are not enabled"))
+		return
+	}
+
+	claims, err := app.jwtKeys.Verify(input.Token)
+	if err != nil {
+		v.AddError("token", "invalid or expired token")
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if claims.Subject != pollID {
+		app.badRequestResponse(w, errTokenWrongPoll)
+		return
+	}
+
+	err = app.models.Tokens.Deny(claims.ID, pollID, claims.ExpiresAt.Time)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "token successfully revoked"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_revokeJWTHandler(t *testing.T) {
+	keys := withJWTKeys(t)
+
+	valid, claims, err := keys.Sign(data.ExamplePollIDValid, data.AllScopes, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPoll, _, _ := keys.Sign(uuid.NewString(), data.AllScopes, time.Hour)
+	expired, _, _ := keys.Sign(data.ExamplePollIDValid, data.AllScopes, -time.Hour)
+
+	tests := []struct {
+		name           string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"revoke a token", fmt.Sprintf(`{"token":%q}`, valid), http.StatusOK, "token successfully revoked"},
+		{"token for another poll", fmt.Sprintf(`{"token":%q}`, otherPoll), http.StatusBadRequest, "token not valid for this poll"},
+		{"expired token", fmt.Sprintf(`{"token":%q}`, expired), http.StatusUnprocessableEntity, `{"error":{"token":"invalid or expired token"}}`},
+		{"database token", `{"token":"UBQ2Z7CLB2SJQBNTUCH4IMRI7A"}`, http.StatusUnprocessableEntity, `{"error":{"token":"must be a signed token"}}`},
+		{"no token", `{}`, http.StatusUnprocessableEntity, `{"error":{"token":"must be provided"}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			ctx := context.WithValue(req.Context(), ctxPollIDKey, data.ExamplePollIDValid)
+			req = req.WithContext(ctx)
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(app.revokeJWTHandler).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+
+	denied, _ := app.models.Tokens.IsDenied(claims.ID)
+	if !denied {
+		t.Errorf("expected token %s to be on the deny list", claims.ID)
+	}
+}
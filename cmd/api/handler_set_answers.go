@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) setAnswersHandler(w http.ResponseWriter, r *http.Request) {
+	poll := app.pollFromContext(r.Context())
+	before := data.NewPollSnapshot(poll)
+
+	if poll.PollType != data.PollTypeQuiz {
+		app.badRequestResponse(w, errors.New("answers can only be set on quiz polls"))
+		return
+	}
+
+	var input struct {
+		OptionIDs []string `json:"option_ids"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	correct := make(map[string]bool, len(input.OptionIDs))
+	for _, id := range input.OptionIDs {
+		correct[id] = true
+	}
+
+	matched := 0
+	for _, opt := range poll.Options {
+		opt.IsCorrect = correct[opt.ID]
+		if opt.IsCorrect {
+			matched++
+		}
+	}
+
+	if matched != len(correct) {
+		app.badRequestResponse(w, errors.New("invalid option id"))
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidatePoll(v, poll); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	revision := data.NewRevision(data.RevisionSetAnswers, app.actorFromContext(r.Context()), before)
+	err = app.models.PollOptions.SetCorrect(r.Context(), poll.ID, input.OptionIDs, revision)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "answers updated successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
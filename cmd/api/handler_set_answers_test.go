@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_setAnswersHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "valid answers",
+			pollID:         data.ExamplePollIDQuiz,
+			json:           `{"option_ids":["` + data.ExampleOptionID2 + `","` + data.ExampleOptionID3 + `"]}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "answers updated successfully",
+		},
+		{
+			name:           "no correct answer",
+			pollID:         data.ExamplePollIDQuiz,
+			json:           `{"option_ids":[]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must mark at least one option as correct",
+		},
+		{
+			name:           "unknown option",
+			pollID:         data.ExamplePollIDQuiz,
+			json:           `{"option_ids":["` + uuid.NewString() + `"]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid option id",
+		},
+		{
+			name:           "not a quiz",
+			pollID:         data.ExamplePollIDValid,
+			json:           `{"option_ids":["` + data.ExampleOptionID1 + `"]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "answers can only be set on quiz polls",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPut, "/", strings.NewReader(test.json))
+			poll, _ := app.models.Polls.Get(context.Background(), test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.setAnswersHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
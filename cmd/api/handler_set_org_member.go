@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// setOrgMemberHandler adds a registered user to the organization, or
+// changes the role of a member. Users are picked by ID, or by an email
+// verified by the identity provider. Anyone can register a password account
+// with an email they don't own, so those are never matched by email.
+func (app *application) setOrgMemberHandler(w http.ResponseWriter, r *http.Request) {
+	orgID, err := app.readIDParam(r, "orgID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input struct {
+		UserID string `json:"user_id"`
+		Email  string `json:"email"`
+		Role   string `json:"role"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
+
+	v := validator.New()
+	if input.UserID != "" {
+		_, err := uuid.Parse(input.UserID)
+		v.Check(err == nil, "user_id", "must be a valid ID")
+		v.Check(input.Email == "", "email", "must not be provided with user_id")
+	} else {
+		data.ValidateEmail(v, input.Email)
+	}
+	data.ValidateOrgRole(v, input.Role)
+	if !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	var user *data.User
+	if input.UserID != "" {
+		user, err = app.models.Users.Get(input.UserID)
+	} else {
+		user, err = app.models.Users.GetByVerifiedEmail(input.Email)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound) && input.UserID != "":
+			v.AddError("user_id", "no user with this ID")
+			app.failedValidationResponse(w, v.Errors)
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("email", "no user from the identity provider with this email address")
+			app.failedValidationResponse(w, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.models.Organizations.SetMember(orgID, user.ID, input.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrLastOwner):
+			app.lastOwnerResponse(w)
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	member := &data.OrgMember{
+		UserID: user.ID,
+		Email:  user.Email,
+		Name:   user.Name,
+		Role:   input.Role,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"member": member}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
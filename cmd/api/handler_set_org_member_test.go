@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_setOrgMemberHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		orgID          string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"add member", data.ExampleOrgID, `{"email":" Grace@Example.com ","role":"editor"}`, http.StatusOK, `"user_id":"` + data.ExampleOrgViewerID + `"`},
+		{"invalid role", data.ExampleOrgID, `{"email":"grace@example.com","role":"admin"}`, http.StatusUnprocessableEntity, `{"error":{"role":"invalid role value"}}`},
+		{"missing role", data.ExampleOrgID, `{"email":"grace@example.com"}`, http.StatusUnprocessableEntity, `{"error":{"role":"must be provided"}}`},
+		{"add member by ID", data.ExampleOrgID, `{"user_id":"` + data.ExampleOrgViewerID + `","role":"viewer"}`, http.StatusOK, `"email":"grace@example.com"`},
+		{"unknown user", data.ExampleOrgID, `{"email":"nobody@example.com","role":"viewer"}`, http.StatusUnprocessableEntity, `{"error":{"email":"no user from the identity provider with this email address"}}`},
+		{"unverified email", data.ExampleOrgID, `{"email":"alice@example.com","role":"viewer"}`, http.StatusUnprocessableEntity, `{"error":{"email":"no user from the identity provider with this email address"}}`},
+		{"unknown user ID", data.ExampleOrgID, `{"user_id":"` + uuid.NewString() + `","role":"viewer"}`, http.StatusUnprocessableEntity, `{"error":{"user_id":"no user with this ID"}}`},
+		{"invalid user ID", data.ExampleOrgID, `{"user_id":"1","role":"viewer"}`, http.StatusUnprocessableEntity, `{"error":{"user_id":"must be a valid ID"}}`},
+		{"both user ID and email", data.ExampleOrgID, `{"user_id":"` + data.ExampleOrgViewerID + `","email":"grace@example.com","role":"viewer"}`, http.StatusUnprocessableEntity, `{"error":{"email":"must not be provided with user_id"}}`},
+		{"demote last owner", data.ExampleOrgID, `{"user_id":"` + data.ExampleUserID + `","role":"editor"}`, http.StatusConflict, "the organization must keep at least one owner"},
+		{"organization not found", uuid.NewString(), `{"email":"grace@example.com","role":"viewer"}`, http.StatusNotFound, "the requested resource could not be found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPut, "/", strings.NewReader(test.json))
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("orgID", test.orgID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.setOrgMemberHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/ivcp/polls/internal/auth"
+)
+
+func (app *application) showJWKSHandler(w http.ResponseWriter, r *http.Request) {
+	keys := []auth.JWK{}
+	if app.jwtKeys != nil {
+		keys = app.jwtKeys.JWKS()
+	}
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"keys": keys}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
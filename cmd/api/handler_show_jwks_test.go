@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_app_showJWKSHandler(t *testing.T) {
+	serve := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(app.showJWKSHandler).ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve()
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"keys":[]`) {
+		t.Errorf("expected an empty key set, but got %q", rr.Body)
+	}
+
+	withJWTKeys(t)
+
+	rr = serve()
+	for _, want := range []string{`"kty":"OKP"`, `"crv":"Ed25519"`, `"kid":"test"`, `"alg":"EdDSA"`} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("expected body to contain %q, but got %q", want, rr.Body)
+		}
+	}
+}
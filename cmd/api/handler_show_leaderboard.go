@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func (app *application) showLeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	seriesID, err := app.readIDParam(r, "seriesID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	series, leaderboard, err := app.models.QuizAnswers.Leaderboard(seriesID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"series": series, "leaderboard": leaderboard}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
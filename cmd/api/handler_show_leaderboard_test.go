@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_showLeaderboardHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		series         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"series with answers", data.ExampleSeriesID, http.StatusOK, `{"voter":"alice","score":2,"answered":2}`},
+		{"series name is shown", data.ExampleSeriesID, http.StatusOK, `"name":"` + data.ExampleSeries + `"`},
+		{"unknown series", uuid.NewString(), http.StatusNotFound, "the requested resource could not be found"},
+		{"invalid series id", data.ExampleSeries, http.StatusBadRequest, "invalid id"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("seriesID", test.series)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.showLeaderboardHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec describes every route in routes.go. Keep it in step with the
+// handlers, the tests replay requests against it.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+func (app *application) showOpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
@@ -3,8 +3,10 @@ package main
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
 )
 
 func (app *application) showPollHandler(w http.ResponseWriter, r *http.Request) {
@@ -14,19 +16,56 @@ func (app *application) showPollHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	poll, err := app.models.Polls.Get(id)
+	poll, err := app.models.Polls.Get(r.Context(), id)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
 			app.notFoundResponse(w, r)
 		default:
-			app.serverErrorResponse(w, err)
+			app.serverErrorResponse(w, r, err)
 		}
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"poll": poll}, nil)
+	visible, err := app.pollVisible(r, poll)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if !visible {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+	if loc := app.readLocation(r.URL.Query(), "tz", v); loc != nil {
+		for _, opt := range poll.Options {
+			if opt.Slot != nil {
+				opt.Slot = opt.Slot.In(loc)
+			}
+		}
+	}
+	if !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	env := envelope{"poll": poll}
+
+	// quiz answers are revealed once the quiz closes
+	if poll.PollType == data.PollTypeQuiz &&
+		!poll.ExpiresAt.Time.IsZero() && poll.ExpiresAt.Time.Before(time.Now()) {
+		correct := []string{}
+		for _, opt := range poll.Options {
+			if opt.IsCorrect {
+				correct = append(correct, opt.ID)
+			}
+		}
+		env["correct_options"] = correct
+	}
+
+	err = app.writeJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
 	}
 }
@@ -16,8 +16,11 @@ func Test_app_showPollHandler(t *testing.T) {
 	tests := []struct {
 		name           string
 		id             string
+		query          string
+		authHeader     string
 		expectedStatus int
 		expectedBody   string
+		unexpectedBody string
 	}{
 		{
 			name:           "valid id",
@@ -37,6 +40,53 @@ func Test_app_showPollHandler(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   `invalid id`,
 		},
+		{
+			name:           "open quiz hides answers",
+			id:             data.ExamplePollIDQuiz,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"poll_type":"quiz"`,
+			unexpectedBody: "correct_options",
+		},
+		{
+			name:           "closed quiz reveals answers",
+			id:             data.ExamplePollIDQuizClosed,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"correct_options":["` + data.ExampleOptionID1 + `"]`,
+		},
+		{
+			name:           "schedule poll in time zone",
+			id:             data.ExamplePollIDSchedule,
+			query:          "?tz=America/New_York",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"start":"2030-03-04T04:00:00-05:00"`,
+		},
+		{
+			name:           "invalid time zone",
+			id:             data.ExamplePollIDSchedule,
+			query:          "?tz=Mars/Olympus",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must be a valid time zone",
+		},
+		{
+			name:           "private org poll without session",
+			id:             data.ExamplePollIDOrgPrivate,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `the requested resource could not be found`,
+		},
+		{
+			name:           "private org poll as non-member",
+			id:             data.ExamplePollIDOrgPrivate,
+			authHeader:     "Bearer " + data.ExampleSessionTokenOther,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `the requested resource could not be found`,
+		},
+		{
+			name:           "private org poll as member",
+			id:             data.ExamplePollIDOrgPrivate,
+			authHeader:     "Bearer " + data.ExampleSessionTokenViewer,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"org_id":"` + data.ExampleOrgID + `"`,
+		},
 		{
 			name:           "no record found",
 			id:             uuid.NewString(),
@@ -47,10 +97,13 @@ func Test_app_showPollHandler(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req, _ := http.NewRequest(http.MethodGet, "/"+test.query, nil)
 			chiCtx := chi.NewRouteContext()
 			chiCtx.URLParams.Add("pollID", test.id)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
 			rr := httptest.NewRecorder()
 			handler := http.HandlerFunc(app.showPollHandler)
 			handler.ServeHTTP(rr, req)
@@ -62,6 +115,10 @@ func Test_app_showPollHandler(t *testing.T) {
 			if !strings.Contains(rr.Body.String(), test.expectedBody) {
 				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
 			}
+
+			if test.unexpectedBody != "" && strings.Contains(rr.Body.String(), test.unexpectedBody) {
+				t.Errorf("expected body not to contain %q, but got %q", test.unexpectedBody, rr.Body)
+			}
 		})
 	}
 }
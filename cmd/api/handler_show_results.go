@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
 )
 
 func (app *application) showResultsHandler(w http.ResponseWriter, r *http.Request) {
@@ -15,29 +16,55 @@ func (app *application) showResultsHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	poll, err := app.models.Polls.Get(pollID)
+	poll, err := app.models.Polls.Get(r.Context(), pollID)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
 			app.notFoundResponse(w, r)
 		default:
-			app.serverErrorResponse(w, err)
+			app.serverErrorResponse(w, r, err)
 		}
 		return
 	}
 
-	switch poll.ResultsVisibility {
-	case "after_vote":
+	visible, err := app.pollVisible(r, poll)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if !visible {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// owners and results viewers see results regardless of visibility
+	privileged := false
+	if r.Header.Get("Authorization") != "" {
+		access, err := app.authenticatePoll(r, poll)
+		if err != nil {
+			app.pollAuthErrorResponse(w, r, err)
+			return
+		}
+		if !validator.PermittedValue(data.ScopeResultsRead, access.scopes...) {
+			app.missingScopeResponse(w, data.ScopeResultsRead)
+			return
+		}
+		privileged = true
+	}
+
+	switch {
+	case privileged:
+	case poll.ResultsVisibility == "after_vote":
 		if poll.ExpiresAt.Time.Before(time.Now()) {
 			ip := r.Header.Get("X-Forwarded-For")
 			if ip == "" {
-				app.serverErrorResponse(w, errors.New("no ip found"))
+				app.serverErrorResponse(w, r, errors.New("no ip found"))
 				return
 			}
 
-			voted, err := app.checkIP(pollID, ip)
+			voted, err := app.checkIP(r.Context(), pollID, ip)
 			if err != nil {
-				app.serverErrorResponse(w, err)
+				app.serverErrorResponse(w, r, err)
 				return
 			}
 			if !voted {
@@ -46,16 +73,16 @@ func (app *application) showResultsHandler(w http.ResponseWriter, r *http.Reques
 			}
 		}
 
-	case "after_deadline":
+	case poll.ResultsVisibility == "after_deadline":
 		if !poll.ExpiresAt.Time.IsZero() && poll.ExpiresAt.Time.After(time.Now()) {
 			app.cannotShowResultsResponse(w, "when poll expires")
 			return
 		}
 	}
 
-	options, err := app.models.PollOptions.GetResults(pollID)
+	options, err := app.models.PollOptions.GetResults(r.Context(), pollID)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
 		return
 	}
 
@@ -77,8 +104,139 @@ func (app *application) showResultsHandler(w http.ResponseWriter, r *http.Reques
 		})
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"results": results}, nil)
+	env := envelope{"results": results}
+
+	if privileged {
+		turnout, err := app.models.Polls.GetTurnout(r.Context(), pollID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		env["turnout"] = turnout
+	}
+
+	if poll.PublicBallots {
+		ballots, err := app.models.Voters.GetBallots(pollID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		env["ballots"] = ballots
+	}
+
+	if poll.PollType == data.PollTypeSchedule {
+		v := validator.New()
+		loc := app.readLocation(r.URL.Query(), "tz", v)
+		if !v.Valid() {
+			app.failedValidationResponse(w, v.Errors)
+			return
+		}
+
+		tallies, err := app.models.Availability.GetResults(pollID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		type slotResult struct {
+			ID       string         `json:"id"`
+			Value    string         `json:"value"`
+			Slot     *data.TimeSlot `json:"slot"`
+			Yes      int            `json:"yes"`
+			IfNeedBe int            `json:"if_need_be"`
+			No       int            `json:"no"`
+		}
+
+		slots := make([]slotResult, 0, len(tallies))
+		for _, tally := range tallies {
+			for _, opt := range poll.Options {
+				if opt.ID != tally.OptionID {
+					continue
+				}
+				slot := opt.Slot
+				if slot != nil && loc != nil {
+					slot = slot.In(loc)
+				}
+				slots = append(slots, slotResult{
+					ID:       opt.ID,
+					Value:    opt.Value,
+					Slot:     slot,
+					Yes:      tally.Yes,
+					IfNeedBe: tally.IfNeedBe,
+					No:       tally.No,
+				})
+			}
+		}
+		env["slots"] = slots
+	}
+
+	if poll.PollType == data.PollTypeMatrix {
+		distributions, err := app.models.Ratings.GetDistributions(pollID, len(poll.Scale))
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		type scalePoint struct {
+			Label string `json:"label"`
+			Value int    `json:"value"`
+			Count int    `json:"count"`
+		}
+
+		type rowResult struct {
+			ID           string       `json:"id"`
+			Value        string       `json:"value"`
+			Position     int          `json:"position"`
+			Distribution []scalePoint `json:"distribution"`
+			data.RowStatistics
+		}
+
+		rows := make([]rowResult, 0, len(distributions))
+		for _, d := range distributions {
+			for _, opt := range poll.Options {
+				if opt.ID != d.OptionID {
+					continue
+				}
+				points := make([]scalePoint, 0, len(poll.Scale))
+				for i, label := range poll.Scale {
+					points = append(points, scalePoint{Label: label, Value: i + 1, Count: d.Counts[i]})
+				}
+				rows = append(rows, rowResult{
+					ID:            opt.ID,
+					Value:         opt.Value,
+					Position:      opt.Position,
+					Distribution:  points,
+					RowStatistics: d.Statistics(),
+				})
+			}
+		}
+		env["rows"] = rows
+	}
+
+	if poll.PollType == data.PollTypeOpenText {
+		responses, err := app.models.PollResponses.GetAll(pollID, data.ResponseStatusApproved)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		type responseResult struct {
+			Value string `json:"value"`
+			Count int    `json:"count"`
+		}
+
+		responseResults := make([]responseResult, 0, len(responses))
+		for _, res := range responses {
+			responseResults = append(responseResults, responseResult{
+				Value: res.Value,
+				Count: res.Count,
+			})
+		}
+		env["responses"] = responseResults
+	}
+
+	err = app.writeJSON(w, http.StatusOK, env, nil)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
@@ -16,13 +17,22 @@ func Test_app_showResultsHandler(t *testing.T) {
 		name           string
 		pollID         string
 		ip             string
+		query          string
+		authHeader     string
 		expectedStatus int
+		expectedBody   string
 	}{
 		{
 			name:           "show results valid",
 			pollID:         data.ExamplePollIDValid,
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:           "public ballots",
+			pollID:         data.ExamplePollIDVoterAuth,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"ballots":[{"voter":"Erin","option_id":"` + data.ExampleOptionID1 + `","voted_at":"2024-02-26T17:19:44Z"}]`,
+		},
 		{
 			name:           "invalid poll id",
 			pollID:         uuid.NewString(),
@@ -40,27 +50,107 @@ func Test_app_showResultsHandler(t *testing.T) {
 			ip:             "0.0.0.1",
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:           "show open text results",
+			pollID:         data.ExamplePollIDOpenText,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "schedule results ranked by availability",
+			pollID:         data.ExamplePollIDSchedule,
+			query:          "?tz=Europe/Berlin",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"slots":[{"id":"` + data.ExampleOptionID2 + `"`,
+		},
+		{
+			name:           "matrix results with statistics",
+			pollID:         data.ExamplePollIDMatrix,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"label":"Agree","value":3,"count":2}],"responses":4,"mean":2.25,"median":2.5,"std_dev":0.82915619758885`,
+		},
 		{
 			name:           "don't show results before deadline",
 			pollID:         data.ExamplePollIDAfterDeadline,
 			ip:             "0.0.0.1",
 			expectedStatus: http.StatusForbidden,
 		},
+		{
+			name:           "owner sees results before deadline",
+			pollID:         data.ExamplePollIDAfterDeadline,
+			ip:             "0.0.0.1",
+			authHeader:     "Bearer " + data.ExampleSessionToken,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"turnout":{"ballots":3,"distinct_ips":2,"last_vote_at":"2024-02-26T17:19:44Z"}`,
+		},
+		{
+			name:           "results viewer token",
+			pollID:         data.ExamplePollIDValid,
+			authHeader:     "Bearer " + data.ExampleTokenResultsOnly,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"turnout":`,
+		},
+		{
+			name:           "token of another poll",
+			pollID:         data.ExamplePollIDAfterDeadline,
+			authHeader:     "Bearer " + data.ExampleTokenResultsOnly,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "token not valid for this poll",
+		},
+		{
+			name:           "session of another user",
+			pollID:         data.ExamplePollIDAfterDeadline,
+			authHeader:     "Bearer " + data.ExampleSessionTokenOther,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "token not valid for this poll",
+		},
+		{
+			name:           "invalid token",
+			pollID:         data.ExamplePollIDAfterDeadline,
+			authHeader:     "Bearer invalid",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid or missing token",
+		},
+		{
+			name:           "private org poll hidden from non-members",
+			pollID:         data.ExamplePollIDOrgPrivate,
+			authHeader:     "Bearer " + data.ExampleSessionTokenOther,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+		{
+			name:           "private org poll shown to members",
+			pollID:         data.ExamplePollIDOrgPrivate,
+			authHeader:     "Bearer " + data.ExampleSessionTokenViewer,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"turnout"`,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req, _ := http.NewRequest(http.MethodGet, "/"+test.query, nil)
 			chiCtx := chi.NewRouteContext()
 			chiCtx.URLParams.Add("pollID", test.pollID)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
 			req.Header.Set("X-Forwarded-For", test.ip)
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
 			rr := httptest.NewRecorder()
 			handler := http.HandlerFunc(app.showResultsHandler)
 			handler.ServeHTTP(rr, req)
 			if rr.Code != test.expectedStatus {
 				t.Errorf("expected status code %d, but got %d", test.expectedStatus, rr.Code)
 			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			if test.authHeader == "" && strings.Contains(rr.Body.String(), "turnout") {
+				t.Errorf("public results must not contain turnout")
+			}
+			if test.pollID != data.ExamplePollIDVoterAuth && strings.Contains(rr.Body.String(), `"ballots":[`) {
+				t.Errorf("results must only contain ballots when they are public")
+			}
 		})
 	}
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) submitAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var ballot data.AvailabilityBallot
+
+	err = app.readJSON(w, r, &ballot)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	poll, err := app.models.Polls.Get(r.Context(), pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !poll.ExpiresAt.Time.IsZero() && poll.ExpiresAt.Time.Before(time.Now()) {
+		app.pollExpiredResponse(w)
+		return
+	}
+
+	if poll.PollType != data.PollTypeSchedule {
+		app.badRequestResponse(w, errors.New("poll does not accept availability answers"))
+		return
+	}
+
+	ballot.Voter = strings.TrimSpace(ballot.Voter)
+
+	v := validator.New()
+	if data.ValidateAvailabilityBallot(v, &ballot, poll); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	ip := r.Header.Get("X-Forwarded-For")
+	if ip == "" {
+		app.serverErrorResponse(w, r, errors.New("no ip found"))
+		return
+	}
+
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	voted, err := app.checkIP(r.Context(), poll.ID, ip)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if voted {
+		app.cannotVoteResponse(w)
+		return
+	}
+
+	err = app.models.Availability.Insert(&ballot, poll.ID, ip)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	votesCast.WithLabelValues(poll.PollType).Inc()
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "vote successful"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_submitAvailabilityHandler(t *testing.T) {
+	validBallot := `{"voter":"alice","answers":[
+		{"option_id":"` + data.ExampleOptionID1 + `","answer":"yes"},
+		{"option_id":"` + data.ExampleOptionID2 + `","answer":"if_need_be"}
+	]}`
+
+	tests := []struct {
+		name           string
+		pollID         string
+		json           string
+		ip             string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "valid ballot",
+			pollID:         data.ExamplePollIDSchedule,
+			json:           validBallot,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "vote successful",
+		},
+		{
+			name:           "ip already voted",
+			pollID:         data.ExamplePollIDSchedule,
+			json:           validBallot,
+			ip:             "0.0.0.1",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "you have already voted on this poll",
+		},
+		{
+			name:   "invalid answer",
+			pollID: data.ExamplePollIDSchedule,
+			json: `{"voter":"alice","answers":[
+				{"option_id":"` + data.ExampleOptionID1 + `","answer":"maybe"}
+			]}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "answer must be one of yes, if_need_be or no",
+		},
+		{
+			name:   "unknown option",
+			pollID: data.ExamplePollIDSchedule,
+			json: `{"voter":"alice","answers":[
+				{"option_id":"` + uuid.NewString() + `","answer":"yes"}
+			]}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must only contain options of this poll",
+		},
+		{
+			name:   "same option twice",
+			pollID: data.ExamplePollIDSchedule,
+			json: `{"voter":"alice","answers":[
+				{"option_id":"` + data.ExampleOptionID1 + `","answer":"yes"},
+				{"option_id":"` + data.ExampleOptionID1 + `","answer":"no"}
+			]}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must not answer the same option twice",
+		},
+		{
+			name:           "not a schedule poll",
+			pollID:         data.ExamplePollIDValid,
+			json:           validBallot,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "poll does not accept availability answers",
+		},
+		{
+			name:           "unexisting poll",
+			pollID:         uuid.NewString(),
+			json:           validBallot,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			req.Header.Set("X-Forwarded-For", test.ip)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.submitAvailabilityHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
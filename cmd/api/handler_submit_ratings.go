@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) submitRatingsHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var ballot data.RatingBallot
+
+	err = app.readJSON(w, r, &ballot)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	poll, err := app.models.Polls.Get(r.Context(), pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !poll.ExpiresAt.Time.IsZero() && poll.ExpiresAt.Time.Before(time.Now()) {
+		app.pollExpiredResponse(w)
+		return
+	}
+
+	if poll.PollType != data.PollTypeMatrix {
+		app.badRequestResponse(w, errors.New("poll does not accept ratings"))
+		return
+	}
+
+	v := validator.New()
+	if data.ValidateRatingBallot(v, &ballot, poll); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	ip := r.Header.Get("X-Forwarded-For")
+	if ip == "" {
+		app.serverErrorResponse(w, r, errors.New("no ip found"))
+		return
+	}
+
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	voted, err := app.checkIP(r.Context(), poll.ID, ip)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if voted {
+		app.cannotVoteResponse(w)
+		return
+	}
+
+	err = app.models.Ratings.Insert(&ballot, poll.ID, ip)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	votesCast.WithLabelValues(poll.PollType).Inc()
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "vote successful"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_submitRatingsHandler(t *testing.T) {
+	validBallot := `{"ratings":[
+		{"option_id":"` + data.ExampleOptionID1 + `","value":3},
+		{"option_id":"` + data.ExampleOptionID2 + `","value":1}
+	]}`
+
+	tests := []struct {
+		name           string
+		pollID         string
+		json           string
+		ip             string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "valid ballot",
+			pollID:         data.ExamplePollIDMatrix,
+			json:           validBallot,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "vote successful",
+		},
+		{
+			name:           "ip already voted",
+			pollID:         data.ExamplePollIDMatrix,
+			json:           validBallot,
+			ip:             "0.0.0.1",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "you have already voted on this poll",
+		},
+		{
+			name:   "value outside scale",
+			pollID: data.ExamplePollIDMatrix,
+			json: `{"ratings":[
+				{"option_id":"` + data.ExampleOptionID1 + `","value":4},
+				{"option_id":"` + data.ExampleOptionID2 + `","value":1}
+			]}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "value must be between 1 and 3",
+		},
+		{
+			name:   "row missing",
+			pollID: data.ExamplePollIDMatrix,
+			json: `{"ratings":[
+				{"option_id":"` + data.ExampleOptionID1 + `","value":2}
+			]}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must rate every row",
+		},
+		{
+			name:   "row rated twice",
+			pollID: data.ExamplePollIDMatrix,
+			json: `{"ratings":[
+				{"option_id":"` + data.ExampleOptionID1 + `","value":2},
+				{"option_id":"` + data.ExampleOptionID1 + `","value":3}
+			]}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must not rate the same row twice",
+		},
+		{
+			name:           "not a matrix poll",
+			pollID:         data.ExamplePollIDValid,
+			json:           validBallot,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "poll does not accept ratings",
+		},
+		{
+			name:           "unexisting poll",
+			pollID:         uuid.NewString(),
+			json:           validBallot,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			req.Header.Set("X-Forwarded-For", test.ip)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.submitRatingsHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) submitResponseHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input struct {
+		Value string `json:"value"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	poll, err := app.models.Polls.Get(r.Context(), pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !poll.ExpiresAt.Time.IsZero() && poll.ExpiresAt.Time.Before(time.Now()) {
+		app.pollExpiredResponse(w)
+		return
+	}
+
+	if poll.PollType != data.PollTypeOpenText {
+		app.badRequestResponse(w, errors.New("poll does not accept text responses"))
+		return
+	}
+
+	response := &data.PollResponse{Value: strings.TrimSpace(input.Value)}
+
+	v := validator.New()
+	if data.ValidateResponse(v, response.Value); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	ip := r.Header.Get("X-Forwarded-For")
+	if ip == "" {
+		app.serverErrorResponse(w, r, errors.New("no ip found"))
+		return
+	}
+
+	app.mutex.Lock()
+	voted, err := app.checkIP(r.Context(), poll.ID, ip)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		app.mutex.Unlock()
+		return
+	}
+	if voted {
+		app.cannotVoteResponse(w)
+		app.mutex.Unlock()
+		return
+	}
+
+	err = app.models.PollResponses.Insert(response, poll.ID, ip)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		app.mutex.Unlock()
+		return
+	}
+
+	app.mutex.Unlock()
+
+	votesCast.WithLabelValues(poll.PollType).Inc()
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"message": "response submitted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
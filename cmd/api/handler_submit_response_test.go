@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_submitResponseHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		json           string
+		ip             string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "valid response",
+			pollID:         data.ExamplePollIDOpenText,
+			json:           `{"value":"Pizza"}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "response submitted successfully",
+		},
+		{
+			name:           "ip already voted",
+			pollID:         data.ExamplePollIDOpenText,
+			json:           `{"value":"Pizza"}`,
+			ip:             "0.0.0.1",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "you have already voted on this poll",
+		},
+		{
+			name:           "empty response",
+			pollID:         data.ExamplePollIDOpenText,
+			json:           `{"value":"  "}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"value":"must not be empty"}}`,
+		},
+		{
+			name:           "response too long",
+			pollID:         data.ExamplePollIDOpenText,
+			json:           `{"value":"` + strings.Repeat("a", 501) + `"}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"value":"must not be more than 500 bytes long"}}`,
+		},
+		{
+			name:           "poll not open text",
+			pollID:         data.ExamplePollIDValid,
+			json:           `{"value":"Pizza"}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "poll does not accept text responses",
+		},
+		{
+			name:           "expired poll",
+			pollID:         data.ExamplePollIDExpiredPoll,
+			json:           `{"value":"Pizza"}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "poll has expired",
+		},
+		{
+			name:           "unexisting poll",
+			pollID:         uuid.NewString(),
+			json:           `{"value":"Pizza"}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			req.Header.Set("X-Forwarded-For", test.ip)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.submitResponseHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
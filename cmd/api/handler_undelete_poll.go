@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// undeletePollHandler brings back a deleted poll within the grace period.
+// It takes the same credentials as deleting the poll did.
+func (app *application) undeletePollHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	poll, err := app.models.Polls.GetDeleted(r.Context(), pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	access, err := app.authenticatePoll(r, poll)
+	if err != nil {
+		app.pollAuthErrorResponse(w, r, err)
+		return
+	}
+	if !validator.PermittedValue(data.ScopePollDelete, access.scopes...) {
+		app.missingScopeResponse(w, data.ScopePollDelete)
+		return
+	}
+
+	deletedAfter := time.Now().Add(-app.config.deletion.gracePeriod)
+	if !poll.DeletedAt.After(deletedAfter) {
+		app.restoreWindowClosedResponse(w)
+		return
+	}
+
+	revision := data.NewRevision(data.RevisionUndelete, access.actor, nil)
+	err = app.models.Polls.Undelete(r.Context(), poll.ID, deletedAfter, revision)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.restoreWindowClosedResponse(w)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "poll successfully restored"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
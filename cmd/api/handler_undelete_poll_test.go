@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_undeletePollHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		authHeader     string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"restore as owner", data.ExamplePollIDDeleted, "Bearer " + data.ExampleSessionToken, http.StatusOK, "poll successfully restored"},
+		{"grace period over", data.ExamplePollIDDeletedOld, "Bearer " + data.ExampleSessionToken, http.StatusGone, "deleted too long ago"},
+		{"not the owner", data.ExamplePollIDDeleted, "Bearer " + data.ExampleSessionTokenOther, http.StatusBadRequest, "token"},
+		{"no token", data.ExamplePollIDDeleted, "", http.StatusUnauthorized, "invalid or missing"},
+		{"not deleted", data.ExamplePollIDValid, "Bearer " + data.ExampleSessionToken, http.StatusNotFound, "could not be found"},
+		{"unknown poll", uuid.NewString(), "Bearer " + data.ExampleSessionToken, http.StatusNotFound, "could not be found"},
+		{"invalid id", "abc", "Bearer " + data.ExampleSessionToken, http.StatusBadRequest, "invalid id"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.undeletePollHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
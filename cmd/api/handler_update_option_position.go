@@ -10,6 +10,7 @@ import (
 
 func (app *application) updateOptionPositionHandler(w http.ResponseWriter, r *http.Request) {
 	poll := app.pollFromContext(r.Context())
+	before := data.NewPollSnapshot(poll)
 
 	var input struct {
 		Options []struct {
@@ -51,14 +52,15 @@ func (app *application) updateOptionPositionHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
-	err = app.models.PollOptions.UpdatePosition(optionsToUpdate)
+	revision := data.NewRevision(data.RevisionReorderOptions, app.actorFromContext(r.Context()), before)
+	err = app.models.PollOptions.UpdatePosition(r.Context(), optionsToUpdate, revision)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
 		return
 	}
 
 	err = app.writeJSON(w, http.StatusOK, envelope{"message": "options updated successfully"}, nil)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
 	}
 }
@@ -54,7 +54,7 @@ func Test_app_updateOptionPositionHandler(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			req, _ := http.NewRequest(http.MethodPatch, "/", strings.NewReader(test.json))
-			poll, _ := app.models.Polls.Get(data.ExamplePollIDValid)
+			poll, _ := app.models.Polls.Get(context.Background(), data.ExamplePollIDValid)
 			req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
 			rr := httptest.NewRecorder()
 			handler := http.HandlerFunc(app.updateOptionPositionHandler)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -10,6 +11,7 @@ import (
 
 func (app *application) updateOptionValueHandler(w http.ResponseWriter, r *http.Request) {
 	poll := app.pollFromContext(r.Context())
+	before := data.NewPollSnapshot(poll)
 
 	var input struct {
 		Value string `json:"value"`
@@ -50,14 +52,20 @@ func (app *application) updateOptionValueHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	err = app.models.PollOptions.UpdateValue(optionToUpdate)
+	revision := data.NewRevision(data.RevisionUpdateOption, app.actorFromContext(r.Context()), before)
+	err = app.models.PollOptions.UpdateValue(r.Context(), optionToUpdate, revision)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		switch {
+		case errors.Is(err, data.ErrDuplicateOption):
+			app.duplicateOptionResponse(w)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
 	err = app.writeJSON(w, http.StatusCreated, envelope{"message": "option updated successfully"}, nil)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
 	}
 }
@@ -49,7 +49,7 @@ func Test_app_updateOptionValueHandler(t *testing.T) {
 			chiCtx := chi.NewRouteContext()
 			chiCtx.URLParams.Add("optionID", test.optionID)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
-			poll, _ := app.models.Polls.Get(data.ExamplePollIDValid)
+			poll, _ := app.models.Polls.Get(context.Background(), data.ExamplePollIDValid)
 			req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
 			rr := httptest.NewRecorder()
 			handler := http.HandlerFunc(app.updateOptionValueHandler)
@@ -11,11 +11,13 @@ import (
 
 func (app *application) updatePollHandler(w http.ResponseWriter, r *http.Request) {
 	poll := app.pollFromContext(r.Context())
+	before := data.NewPollSnapshot(poll)
 
 	var input struct {
-		Question    *string        `json:"question"`
-		Description *string        `json:"description"`
-		ExpiresAt   data.ExpiresAt `json:"expires_at"`
+		Question     *string        `json:"question"`
+		Description  *string        `json:"description"`
+		ExpiresAt    data.ExpiresAt `json:"expires_at"`
+		AllowWriteIn *bool          `json:"allow_write_in"`
 	}
 
 	err := app.readJSON(w, r, &input)
@@ -36,7 +38,12 @@ func (app *application) updatePollHandler(w http.ResponseWriter, r *http.Request
 		poll.ExpiresAt = input.ExpiresAt
 	}
 
-	if input.Question == nil && input.Description == nil && input.ExpiresAt.IsZero() {
+	if input.AllowWriteIn != nil {
+		poll.AllowWriteIn = *input.AllowWriteIn
+	}
+
+	if input.Question == nil && input.Description == nil &&
+		input.ExpiresAt.IsZero() && input.AllowWriteIn == nil {
 		app.badRequestResponse(w, errors.New("no fields provided for update"))
 		return
 	}
@@ -48,14 +55,15 @@ func (app *application) updatePollHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err = app.models.Polls.Update(poll)
+	revision := data.NewRevision(data.RevisionUpdatePoll, app.actorFromContext(r.Context()), before)
+	err = app.models.Polls.Update(r.Context(), poll, revision)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
 		return
 	}
 
 	err = app.writeJSON(w, http.StatusOK, envelope{"poll": poll}, nil)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
 	}
 }
@@ -37,6 +37,13 @@ func Test_app_updatePollHandler(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedBody:   `"question":"changed","description":"added description"`,
 		},
+		{
+			name:           "enable write-ins",
+			id:             data.ExamplePollIDValid,
+			json:           `{"allow_write_in":true}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"allow_write_in":true`,
+		},
 		{
 			name:           "empty json",
 			id:             data.ExamplePollIDValid,
@@ -49,7 +56,7 @@ func Test_app_updatePollHandler(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			req, _ := http.NewRequest(http.MethodPatch, "/", strings.NewReader(test.json))
-			poll, _ := app.models.Polls.Get(test.id)
+			poll, _ := app.models.Polls.Get(context.Background(), test.id)
 			t.Log(poll.ID)
 			req = req.WithContext(context.WithValue(req.Context(), ctxPollKey, poll))
 			rr := httptest.NewRecorder()
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) updateResponseStatusHandler(w http.ResponseWriter, r *http.Request) {
+	pollID := app.pollIDfromContext(r.Context())
+
+	responseID, err := app.readIDParam(r, "responseID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input struct {
+		Status string `json:"status"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	response, err := app.models.PollResponses.Get(responseID, pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	v := validator.New()
+	v.Check(validator.PermittedValue(
+		input.Status,
+		data.ResponseStatusPending,
+		data.ResponseStatusApproved,
+		data.ResponseStatusHidden,
+	), "status", "invalid status value")
+	v.Check(response.Status != data.ResponseStatusMerged, "status", "merged responses cannot be moderated")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	response.Status = input.Status
+
+	err = app.models.PollResponses.UpdateStatus(response, pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"response": response}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_updateResponseStatusHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		responseID     string
+		json           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"approve", data.ExampleResponseID2, `{"status":"approved"}`, http.StatusOK, `"status":"approved"`},
+		{"hide", data.ExampleResponseID1, `{"status":"hidden"}`, http.StatusOK, `"status":"hidden"`},
+		{"invalid status", data.ExampleResponseID1, `{"status":"merged"}`, http.StatusUnprocessableEntity, "invalid status value"},
+		{"merged response", data.ExampleResponseIDMerged, `{"status":"approved"}`, http.StatusUnprocessableEntity, "merged responses cannot be moderated"},
+		{"unexisting response", uuid.NewString(), `{"status":"approved"}`, http.StatusNotFound, "the requested resource could not be found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPatch, "/", strings.NewReader(test.json))
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("responseID", test.responseID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			req = req.WithContext(context.WithValue(req.Context(), ctxPollIDKey, data.ExamplePollIDOpenText))
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.updateResponseStatusHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
@@ -3,9 +3,11 @@ package main
 import (
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
 )
 
 func (app *application) voteOptionHandler(w http.ResponseWriter, r *http.Request) {
@@ -21,13 +23,13 @@ func (app *application) voteOptionHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	poll, err := app.models.Polls.Get(pollID)
+	poll, err := app.models.Polls.Get(r.Context(), pollID)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
 			app.notFoundResponse(w, r)
 		default:
-			app.serverErrorResponse(w, err)
+			app.serverErrorResponse(w, r, err)
 		}
 		return
 	}
@@ -37,41 +39,124 @@ func (app *application) voteOptionHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	ip := r.Header.Get("X-Forwarded-For")
-	if ip == "" {
-		app.serverErrorResponse(w, errors.New("no ip found"))
+	switch poll.PollType {
+	case data.PollTypeOpenText:
+		app.badRequestResponse(w, errors.New("poll only accepts text responses"))
+		return
+	case data.PollTypeSchedule:
+		app.badRequestResponse(w, errors.New("poll only accepts availability answers"))
+		return
+	case data.PollTypeMatrix:
+		app.badRequestResponse(w, errors.New("poll only accepts ratings"))
 		return
 	}
 
-	app.mutex.Lock()
-	voted, err := app.checkIP(poll.ID, ip)
-	if err != nil {
-		app.serverErrorResponse(w, err)
-		app.mutex.Unlock()
-		return
+	var answer *data.QuizAnswer
+	if poll.PollType == data.PollTypeQuiz {
+		var input struct {
+			Voter string `json:"voter"`
+		}
+
+		err = app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, err)
+			return
+		}
+
+		answer = &data.QuizAnswer{
+			PollID:   poll.ID,
+			OptionID: optionID,
+			Voter:    strings.TrimSpace(input.Voter),
+		}
+
+		v := validator.New()
+		if data.ValidateVoterName(v, answer.Voter); !v.Valid() {
+			app.failedValidationResponse(w, v.Errors)
+			return
+		}
+
+		for _, opt := range poll.Options {
+			if opt.ID == optionID {
+				answer.Correct = opt.IsCorrect
+			}
+		}
 	}
-	if voted {
-		app.cannotVoteResponse(w)
-		app.mutex.Unlock()
+
+	ip := r.Header.Get("X-Forwarded-For")
+	if ip == "" {
+		app.serverErrorResponse(w, r, errors.New("no ip found"))
 		return
 	}
 
-	err = app.models.PollOptions.Vote(optionID, poll.ID, ip)
-	if err != nil {
-		switch {
-		case errors.Is(err, data.ErrRecordNotFound):
-			app.notFoundResponse(w, r)
-		default:
-			app.serverErrorResponse(w, err)
+	// quiz answers are stored with the vote, so a counted vote always
+	// makes it onto the leaderboard
+	vote := func(voter *data.Voter) error {
+		if answer != nil {
+			return app.models.QuizAnswers.Vote(r.Context(), answer, ip, voter)
 		}
+		if voter != nil {
+			return app.models.PollOptions.VoteAs(r.Context(), optionID, poll.ID, ip, voter)
+		}
+		return app.models.PollOptions.Vote(r.Context(), optionID, poll.ID, ip)
+	}
+
+	if poll.VoterAuth {
+		voter := app.voterFromContext(r.Context())
+		if voter == nil {
+			app.signInRequiredResponse(w)
+			return
+		}
+
+		err = vote(voter)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrAlreadyVoted):
+				app.cannotVoteResponse(w)
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+	} else {
+		app.mutex.Lock()
+		voted, err := app.checkIP(r.Context(), poll.ID, ip)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			app.mutex.Unlock()
+			return
+		}
+		if voted {
+			app.cannotVoteResponse(w)
+			app.mutex.Unlock()
+			return
+		}
+
+		err = vote(nil)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			app.mutex.Unlock()
+			return
+		}
+
 		app.mutex.Unlock()
-		return
 	}
 
-	app.mutex.Unlock()
+	votesCast.WithLabelValues(poll.PollType).Inc()
+
+	env := envelope{"message": "vote successful"}
+	if answer != nil {
+		env["correct"] = answer.Correct
+	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"message": "vote successful"}, nil)
+	err = app.writeJSON(w, http.StatusOK, env, nil)
 	if err != nil {
-		app.serverErrorResponse(w, err)
+		app.serverErrorResponse(w, r, err)
 	}
 }
@@ -16,7 +16,10 @@ func Test_app_voteOptionHandler(t *testing.T) {
 	tests := []struct {
 		name           string
 		pollID         string
+		optionID       string
+		json           string
 		ip             string
+		voter          *data.Voter
 		expectedStatus int
 		expectedBody   string
 	}{
@@ -48,6 +51,68 @@ func Test_app_voteOptionHandler(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedBody:   "vote successful",
 		},
+		{
+			name:           "open text poll",
+			pollID:         data.ExamplePollIDOpenText,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "poll only accepts text responses",
+		},
+		{
+			name:           "matrix poll",
+			pollID:         data.ExamplePollIDMatrix,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "poll only accepts ratings",
+		},
+		{
+			name:           "quiz correct answer",
+			pollID:         data.ExamplePollIDQuiz,
+			json:           `{"voter":"alice"}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"correct":true`,
+		},
+		{
+			name:           "quiz wrong answer",
+			pollID:         data.ExamplePollIDQuiz,
+			optionID:       data.ExampleOptionID2,
+			json:           `{"voter":"alice"}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"correct":false`,
+		},
+		{
+			name:           "quiz without voter",
+			pollID:         data.ExamplePollIDQuiz,
+			json:           `{"voter":""}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"voter":"must be provided"}}`,
+		},
+		{
+			name:           "voter_auth ignores ip",
+			pollID:         data.ExamplePollIDVoterAuth,
+			ip:             "0.0.0.1",
+			voter:          &data.Voter{ID: "credential:" + data.ExampleVoterCredentialID, Name: "Erin"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "vote successful",
+		},
+		{
+			name:           "voter_auth account already voted",
+			pollID:         data.ExamplePollIDVoterAuth,
+			ip:             "0.0.0.0",
+			voter:          &data.Voter{ID: "user:" + data.ExampleUserID, Name: "Alice"},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "you have already voted on this poll",
+		},
+		{
+			name:           "voter_auth without voter",
+			pollID:         data.ExamplePollIDVoterAuth,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "you must be signed in to vote on this poll",
+		},
 		{
 			name:           "unexisting poll",
 			pollID:         uuid.NewString(),
@@ -58,11 +123,17 @@ func Test_app_voteOptionHandler(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			req, _ := http.NewRequest(http.MethodPost, "/", nil)
+			if test.optionID == "" {
+				test.optionID = data.ExampleOptionID1
+			}
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
 			chiCtx := chi.NewRouteContext()
 			chiCtx.URLParams.Add("pollID", test.pollID)
-			chiCtx.URLParams.Add("optionID", data.ExampleOptionID1)
+			chiCtx.URLParams.Add("optionID", test.optionID)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			if test.voter != nil {
+				req = req.WithContext(context.WithValue(req.Context(), ctxVoterKey, test.voter))
+			}
 			req.Header.Set("X-Forwarded-For", test.ip)
 			rr := httptest.NewRecorder()
 			handler := http.HandlerFunc(app.voteOptionHandler)
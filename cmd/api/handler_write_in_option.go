@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (app *application) writeInOptionHandler(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input struct {
+		Value string `json:"value"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	poll, err := app.models.Polls.Get(r.Context(), pollID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !poll.ExpiresAt.Time.IsZero() && poll.ExpiresAt.Time.Before(time.Now()) {
+		app.pollExpiredResponse(w)
+		return
+	}
+
+	if !poll.AllowWriteIn {
+		app.badRequestResponse(w, errors.New("poll does not accept write-in options"))
+		return
+	}
+
+	value := strings.TrimSpace(input.Value)
+
+	v := validator.New()
+	if data.ValidateResponse(v, value); !v.Valid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	ip := r.Header.Get("X-Forwarded-For")
+	if ip == "" {
+		app.serverErrorResponse(w, r, errors.New("no ip found"))
+		return
+	}
+
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	voted, err := app.checkIP(r.Context(), poll.ID, ip)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if voted {
+		app.cannotVoteResponse(w)
+		return
+	}
+
+	// a write-in matching an existing option is counted as a vote for it
+	option := &data.PollOption{Value: value}
+	created, err := app.models.PollOptions.WriteIn(r.Context(), option, poll.ID, ip)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateOption):
+			app.failedValidationResponse(w, map[string]string{"value": "matches an option that was just added, try again"})
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	votesCast.WithLabelValues(poll.PollType).Inc()
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+
+	err = app.writeJSON(w, status, envelope{"message": "vote successful", "option": option}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
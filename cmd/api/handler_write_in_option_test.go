@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_writeInOptionHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		json           string
+		ip             string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "new write-in",
+			pollID:         data.ExamplePollIDWriteIn,
+			json:           `{"value":"Three"}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"value":"Three"`,
+		},
+		{
+			name:           "write-in matches existing option",
+			pollID:         data.ExamplePollIDWriteIn,
+			json:           `{"value":"  tWo "}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"id":"` + data.ExampleOptionID2 + `"`,
+		},
+		{
+			name:           "ip already voted",
+			pollID:         data.ExamplePollIDWriteIn,
+			json:           `{"value":"Three"}`,
+			ip:             "0.0.0.1",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "you have already voted on this poll",
+		},
+		{
+			name:           "empty write-in",
+			pollID:         data.ExamplePollIDWriteIn,
+			json:           `{"value":" "}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":{"value":"must not be empty"}}`,
+		},
+		{
+			name:           "write-in not allowed",
+			pollID:         data.ExamplePollIDValid,
+			json:           `{"value":"Four"}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "poll does not accept write-in options",
+		},
+		{
+			name:           "expired poll",
+			pollID:         data.ExamplePollIDExpiredPoll,
+			json:           `{"value":"Four"}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "poll has expired",
+		},
+		{
+			name:           "unexisting poll",
+			pollID:         uuid.NewString(),
+			json:           `{"value":"Four"}`,
+			ip:             "0.0.0.0",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the requested resource could not be found",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(test.json))
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			req.Header.Set("X-Forwarded-For", test.ip)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(app.writeInOptionHandler)
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+		})
+	}
+}
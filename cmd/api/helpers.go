@@ -17,6 +17,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/auth"
 	"github.com/ivcp/polls/internal/data"
 	"github.com/ivcp/polls/internal/validator"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -27,8 +28,34 @@ type contextKey string
 const (
 	ctxPollIDKey contextKey = "pollID"
 	ctxPollKey   contextKey = "poll"
+	ctxUserKey   contextKey = "user"
+	ctxScopesKey contextKey = "scopes"
+	ctxAPIKeyKey contextKey = "apiKey"
+	ctxVoterKey  contextKey = "voter"
+	ctxActorKey  contextKey = "actor"
+
+	ctxRequestIDKey  contextKey = "requestID"
+	ctxAPIKeyNameKey contextKey = "apiKeyName"
 )
 
+// clientIP is the address the proxy in front of us saw the request from.
+func clientIP(r *http.Request) string {
+	if ip := r.Header.Get("X-Forwarded-For"); ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// requestIDFromContext is empty outside of a request.
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxRequestIDKey).(string)
+	return id
+}
+
 func (app *application) pollIDfromContext(ctx context.Context) string {
 	return ctx.Value(ctxPollIDKey).(string)
 }
@@ -37,6 +64,65 @@ func (app *application) pollFromContext(ctx context.Context) *data.Poll {
 	return ctx.Value(ctxPollKey).(*data.Poll)
 }
 
+func (app *application) userFromContext(ctx context.Context) *data.User {
+	return ctx.Value(ctxUserKey).(*data.User)
+}
+
+// apiKeyFromContext returns nil for requests made without an API key.
+func (app *application) apiKeyFromContext(ctx context.Context) *data.APIKey {
+	key, _ := ctx.Value(ctxAPIKeyKey).(*data.APIKey)
+	return key
+}
+
+// setAPIKeyName tells the access log which key the request was made with.
+// The key is only known further down the chain, so logRequests leaves a
+// slot for it in the context.
+func setAPIKeyName(ctx context.Context, name string) {
+	if slot, ok := ctx.Value(ctxAPIKeyNameKey).(*string); ok {
+		*slot = name
+	}
+}
+
+func (app *application) voterFromContext(ctx context.Context) *data.Voter {
+	voter, _ := ctx.Value(ctxVoterKey).(*data.Voter)
+	return voter
+}
+
+func (app *application) scopesFromContext(ctx context.Context) []string {
+	scopes, _ := ctx.Value(ctxScopesKey).([]string)
+	return scopes
+}
+
+func (app *application) actorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(ctxActorKey).(string)
+	return actor
+}
+
+// bearerToken returns the token from the Authorization header. ok is false
+// when the header is missing or malformed.
+func (app *application) bearerToken(r *http.Request) (token string, ok bool) {
+	authorizationHeader := r.Header.Get("Authorization")
+	if authorizationHeader == "" {
+		return "", false
+	}
+
+	headerParts := strings.Split(authorizationHeader, " ")
+	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+		return "", false
+	}
+
+	if auth.LooksLikeJWT(headerParts[1]) {
+		return headerParts[1], true
+	}
+
+	v := validator.New()
+	if data.ValidateTokenPlaintext(v, headerParts[1]); !v.Valid() {
+		return "", false
+	}
+
+	return headerParts[1], true
+}
+
 func (app *application) readIDParam(r *http.Request, idKey string) (string, error) {
 	param := chi.URLParam(r, idKey)
 	if param == "" {
@@ -140,8 +226,26 @@ func (app *application) readInt(qs url.Values, key string, defaultValue int, v *
 	return i
 }
 
-func (app *application) checkIP(pollID string, ip string) (bool, error) {
-	ips, err := app.models.Polls.GetVotedIPs(pollID)
+// readLocation reads an IANA time zone name from the query string. It
+// returns nil when the key is not set.
+func (app *application) readLocation(qs url.Values, key string, v *validator.Validator) *time.Location {
+	s := qs.Get(key)
+
+	if s == "" {
+		return nil
+	}
+
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		v.AddError(key, "must be a valid time zone")
+		return nil
+	}
+
+	return loc
+}
+
+func (app *application) checkIP(ctx context.Context, pollID string, ip string) (bool, error) {
+	ips, err := app.models.Polls.GetVotedIPs(ctx, pollID)
 	if err != nil {
 		return false, fmt.Errorf("checkIP %s", err)
 	}
@@ -157,6 +261,8 @@ func (app *application) checkIP(pollID string, ip string) (bool, error) {
 }
 
 func (app *application) setMetrics(db *pgxpool.Pool) {
+	promRegistry.MustRegister(newPoolCollector(db))
+
 	expvar.NewString("version").Set(version)
 	expvar.Publish("goroutines", expvar.Func(func() any {
 		return runtime.NumGoroutine()
@@ -1,15 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/ivcp/polls/internal/auth"
 	"github.com/ivcp/polls/internal/data"
 	_ "github.com/jackc/pgx/v5"
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -17,79 +20,152 @@ import (
 
 var version = "1.0.0"
 
-type config struct {
-	port int
-	env  string
-	db   struct {
-		dsn string
-	}
-	limiter struct {
-		rps     float64
-		burst   int
-		enabled bool
-	}
-}
-
 type application struct {
-	config config
-	logger *log.Logger
-	models data.Models
-	mutex  sync.Mutex
+	config  config
+	logger  *slog.Logger
+	models  data.Models
+	mutex   sync.Mutex
+	jwtKeys *auth.KeySet
+	oidc    *auth.OIDC
+	ready   func(context.Context) error
+	done    chan struct{}
+	wg      sync.WaitGroup
+	// changed at runtime through the admin API
+	limiter  atomic.Pointer[limiterSettings]
+	features map[string]*atomic.Bool
 }
 
 func main() {
-	var cfg config
-	var app application
-	logger := log.New(os.Stdout, "", log.Ldate|log.Ltime)
+	app := application{done: make(chan struct{})}
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	app.logger = logger
 
-	port, err := strconv.Atoi(os.Getenv("SERVER_PORT"))
+	cfg, cmd, err := loadConfig(os.Args[1:], os.Getenv)
 	if err != nil {
-		logger.Fatal(err)
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		logger.Error(err.Error())
+		os.Exit(2)
 	}
-	cfg.port = port
-	dsn := os.Getenv("DB_DSN")
-	if dsn == "" {
-		logger.Fatal("dsn string not set")
+
+	if cmd.printConfig {
+		if err := printConfig(os.Stdout, &cfg); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
 	}
-	cfg.db.dsn = dsn
-	env := os.Getenv("SERVER_ENV")
-	if env == "" {
-		logger.Fatal("dsn string not set")
+
+	app.config = cfg
+	app.initRuntime()
+
+	if len(cmd.args) > 0 {
+		os.Exit(app.runCommand(cmd.args))
 	}
-	cfg.env = env
 
-	flag.Float64Var(&cfg.limiter.rps, "limiter-rps", 2, "Rate limiter maximum requests persecond")
-	flag.IntVar(&cfg.limiter.burst, "limiter-burst", 4, "Rate limiter maximum burst")
-	flag.BoolVar(&cfg.limiter.enabled, "limiter-enabled", true, "Enable rate limiter")
+	if cfg.jwt.keys != "" {
+		app.jwtKeys, err = auth.ParseKeySet(cfg.jwt.keys)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+	}
 
-	flag.Parse()
+	if cfg.oidc.issuer != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		app.oidc, err = auth.NewOIDC(
+			ctx, cfg.oidc.issuer, cfg.oidc.clientID, cfg.oidc.clientSecret, cfg.oidc.redirectURL,
+		)
+		cancel()
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+	}
 
-	app.config = cfg
+	shutdownTracing, err := app.setupTracing(context.Background())
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
 
 	db, err := app.connectToDB()
 	if err != nil {
-		logger.Fatal(err)
+		logger.Error(err.Error())
+		os.Exit(1)
 	}
-	defer db.Close()
 
-	if err = app.runMigrations(db, "../migrations"); err != nil {
-		logger.Fatal(err)
+	if cfg.db.autoMigrate {
+		if err = app.runMigrations(db); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	app.ready, err = app.dbReadiness(db)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
 	}
 
 	app.models = data.NewModels(db)
 
 	app.setMetrics(db)
 
+	app.purgeDeletedPolls()
+
 	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.port),
-		Handler:      app.routes(),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		Addr:              fmt.Sprintf(":%d", cfg.port),
+		Handler:           app.routes(),
+		IdleTimeout:       cfg.http.idleTimeout,
+		ReadTimeout:       cfg.http.readTimeout,
+		ReadHeaderTimeout: cfg.http.readHeaderTimeout,
+		WriteTimeout:      cfg.http.writeTimeout,
+	}
+
+	admin, err := app.adminServer()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	err = app.serve(srv, admin)
+	if err != nil {
+		logger.Error(err.Error())
 	}
 
-	logger.Printf("Starting %s server on %s", cfg.env, srv.Addr)
-	err = srv.ListenAndServe()
-	logger.Fatal(err)
+	if err := shutdownTracing(context.Background()); err != nil {
+		logger.Error(err.Error())
+	}
+	db.Close()
+
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// runCommand runs a subcommand instead of the server and returns the exit
+// code.
+func (app *application) runCommand(args []string) int {
+	if args[0] != "migrate" {
+		app.logger.Error(fmt.Sprintf("unknown command %q", args[0]))
+		return 2
+	}
+
+	db, err := app.connectToDB()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer db.Close()
+
+	if err := app.migrate(db, args[1:]); err != nil {
+		app.logger.Error(err.Error())
+		if errors.Is(err, errMigrateUsage) {
+			return 2
+		}
+		return 1
+	}
+	return 0
 }
@@ -4,17 +4,23 @@ import (
 	"context"
 	"errors"
 	"expvar"
-	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/auth"
 	"github.com/ivcp/polls/internal/data"
 	"github.com/ivcp/polls/internal/validator"
 	"golang.org/x/time/rate"
 )
 
+var (
+	apiKeyRequests   = expvar.NewMap("total_requests_by_api_key")
+	apiKeyRejections = expvar.NewMap("total_rejections_by_api_key")
+)
+
 func (app *application) rateLimit(next http.Handler) http.Handler {
 	type client struct {
 		limiter  *rate.Limiter
@@ -23,9 +29,8 @@ func (app *application) rateLimit(next http.Handler) http.Handler {
 
 	clients := make(map[string]*client)
 
-	go func() {
-		for {
-			time.Sleep(time.Minute)
+	app.background(func() {
+		app.every(time.Minute, func() {
 			app.mutex.Lock()
 			for ip, client := range clients {
 				if time.Since(client.lastSeen) > 3*time.Minute {
@@ -33,15 +38,73 @@ func (app *application) rateLimit(next http.Handler) http.Handler {
 				}
 			}
 			app.mutex.Unlock()
-		}
-	}()
+		})
+	})
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if app.config.limiter.enabled {
+		limits := app.limiter.Load()
+
+		// integrations share egress IPs, so API keys are limited per key
+		if plaintext := r.Header.Get("X-API-Key"); plaintext != "" {
+			key, err := app.models.APIKeys.GetByPlaintext(plaintext)
+			if err != nil {
+				switch {
+				case errors.Is(err, data.ErrRecordNotFound):
+					app.invalidAPIKeyResponse(w)
+				default:
+					app.serverErrorResponse(w, r, err)
+				}
+				return
+			}
+			setAPIKeyName(r.Context(), key.Name)
+
+			if limits.Enabled {
+				app.mutex.Lock()
+				id := "key:" + key.ID
+				if _, ok := clients[id]; !ok {
+					clients[id] = &client{limiter: rate.NewLimiter(rate.Limit(key.RPS), key.Burst)}
+				}
+				clients[id].lastSeen = time.Now()
+				clients[id].limiter.SetLimit(rate.Limit(key.RPS))
+				clients[id].limiter.SetBurst(key.Burst)
+				allowed := clients[id].limiter.Allow()
+				app.mutex.Unlock()
+
+				if !allowed {
+					apiKeyRejections.Add(key.Name, 1)
+					apiKeyRejectionsTotal.WithLabelValues(key.Name, "rate").Inc()
+					app.logger.Warn("api key exceeded its rate limit", "api_key", key.Name)
+					app.rateLimitExcededResponse(w, key.RPS)
+					return
+				}
+			}
+
+			requests, err := app.models.APIKeys.RecordUsage(key.ID)
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+				return
+			}
+			if key.DailyQuota > 0 && requests > key.DailyQuota {
+				apiKeyRejections.Add(key.Name, 1)
+				apiKeyRejectionsTotal.WithLabelValues(key.Name, "quota").Inc()
+				app.logger.Warn("api key exceeded its daily quota", "api_key", key.Name, "quota", key.DailyQuota)
+				app.quotaExceededResponse(w)
+				return
+			}
+
+			apiKeyRequests.Add(key.Name, 1)
+			apiKeyRequestsTotal.WithLabelValues(key.Name).Inc()
+
+			ctx := context.WithValue(r.Context(), ctxAPIKeyKey, key)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		if limits.Enabled {
 
 			ip := r.Header.Get("X-Forwarded-For")
 			if ip == "" {
-				app.serverErrorResponse(w, errors.New("no ip found"))
+				app.serverErrorResponse(w, r, errors.New("no ip found"))
 				return
 			}
 
@@ -49,18 +112,17 @@ func (app *application) rateLimit(next http.Handler) http.Handler {
 
 			if _, ok := clients[ip]; !ok {
 				clients[ip] = &client{
-					limiter: rate.NewLimiter(
-						rate.Limit(app.config.limiter.rps),
-						app.config.limiter.burst,
-					),
+					limiter: rate.NewLimiter(rate.Limit(limits.RPS), limits.Burst),
 				}
 			}
 
 			clients[ip].lastSeen = time.Now()
+			clients[ip].limiter.SetLimit(rate.Limit(limits.RPS))
+			clients[ip].limiter.SetBurst(limits.Burst)
 
 			if !clients[ip].limiter.Allow() {
 				app.mutex.Unlock()
-				app.rateLimitExcededResponse(w)
+				app.rateLimitExcededResponse(w, limits.RPS)
 				return
 			}
 
@@ -70,48 +132,318 @@ func (app *application) rateLimit(next http.Handler) http.Handler {
 	})
 }
 
+var (
+	errInvalidToken   = errors.New("invalid or missing token")
+	errTokenWrongPoll = errors.New("token not valid for this poll")
+)
+
+// pollAccess is what a request may do with a poll and on whose behalf.
+// Actor names the token, key or account in the revision log.
+type pollAccess struct {
+	scopes []string
+	user   *data.User
+	actor  string
+}
+
+// authenticatePoll resolves the bearer token of a request to the scopes it
+// grants on a poll. Poll tokens carry their own scopes, a session of the
+// account that owns the poll gets every scope. Without a bearer token, the
+// API key that created the poll gets every scope too.
+func (app *application) authenticatePoll(r *http.Request, poll *data.Poll) (*pollAccess, error) {
+	if key := app.apiKeyFromContext(r.Context()); key != nil && r.Header.Get("Authorization") == "" {
+		if poll.APIKeyID != key.ID {
+			return nil, errTokenWrongPoll
+		}
+		return &pollAccess{scopes: data.AllScopes, actor: "api_key:" + key.ID}, nil
+	}
+
+	token, ok := app.bearerToken(r)
+	if !ok {
+		return nil, errInvalidToken
+	}
+
+	if auth.LooksLikeJWT(token) {
+		claims, err := app.verifyJWT(token)
+		if err != nil {
+			return nil, err
+		}
+		if claims.Subject != poll.ID {
+			return nil, errTokenWrongPoll
+		}
+		return &pollAccess{scopes: claims.Scopes, actor: "jwt:" + claims.ID}, nil
+	}
+
+	pollToken, err := app.models.Polls.CheckToken(r.Context(), token)
+	switch {
+	case err == nil:
+		if pollToken.PollID != poll.ID {
+			return nil, errTokenWrongPoll
+		}
+		return &pollAccess{scopes: pollToken.Scopes, actor: "token:" + pollToken.ID}, nil
+	case !errors.Is(err, data.ErrRecordNotFound):
+		return nil, err
+	}
+
+	user, err := app.models.Users.GetForSession(token)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return nil, errInvalidToken
+		}
+		return nil, err
+	}
+
+	access := &pollAccess{user: user, actor: "user:" + user.ID}
+
+	if poll.OwnerID == user.ID {
+		access.scopes = data.AllScopes
+		return access, nil
+	}
+
+	// members of the poll's organization act on it by their role
+	if poll.OrgID == "" {
+		return nil, errTokenWrongPoll
+	}
+	role, err := app.models.Organizations.GetRole(poll.OrgID, user.ID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return nil, errTokenWrongPoll
+		}
+		return nil, err
+	}
+
+	access.scopes = data.OrgRoleScopes(role)
+	return access, nil
+}
+
+// pollVisible reports whether the request may see the poll. Private polls
+// of an organization are only visible to its members and to whoever holds
+// a token for the poll.
+func (app *application) pollVisible(r *http.Request, poll *data.Poll) (bool, error) {
+	if poll.OrgID == "" || !poll.IsPrivate {
+		return true, nil
+	}
+
+	_, err := app.authenticatePoll(r, poll)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, errInvalidToken), errors.Is(err, errTokenWrongPoll):
+		return false, nil
+	}
+	return false, err
+}
+
+// verifyJWT checks the signature and expiry of a signed owner token and that
+// it has not been revoked.
+func (app *application) verifyJWT(token string) (*auth.PollClaims, error) {
+	if app.jwtKeys == nil {
+		return nil, errInvalidToken
+	}
+
+	claims, err := app.jwtKeys.Verify(token)
+	if err != nil {
+		return nil, errInvalidToken
+	}
+
+	denied, err := app.models.Tokens.IsDenied(claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if denied {
+		return nil, errInvalidToken
+	}
+
+	return claims, nil
+}
+
 func (app *application) requireToken(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authorizationHeader := r.Header.Get("Authorization")
-		if authorizationHeader == "" {
-			app.invalidTokenResponse(w)
+		pollID, err := app.readIDParam(r, "pollID")
+		if err != nil {
+			app.badRequestResponse(w, err)
 			return
 		}
 
-		headerParts := strings.Split(authorizationHeader, " ")
-		if len(headerParts) != 2 || headerParts[0] != "Bearer" {
-			app.invalidTokenResponse(w)
+		poll, err := app.models.Polls.Get(r.Context(), pollID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
 			return
 		}
 
-		token := headerParts[1]
+		access, err := app.authenticatePoll(r, poll)
+		if err != nil {
+			app.pollAuthErrorResponse(w, r, err)
+			return
+		}
 
-		v := validator.New()
+		ctx := context.WithValue(r.Context(), ctxPollIDKey, pollID)
+		ctx = context.WithValue(ctx, ctxScopesKey, access.scopes)
+		ctx = context.WithValue(ctx, ctxActorKey, access.actor)
+		if access.user != nil {
+			ctx = context.WithValue(ctx, ctxUserKey, access.user)
+		}
 
-		if data.ValidateTokenPlaintext(v, token); !v.Valid() {
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requireScope must run after requireToken. The token needs every one of
+// the given scopes.
+func (app *application) requireScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			granted := app.scopesFromContext(r.Context())
+			for _, scope := range scopes {
+				if !validator.PermittedValue(scope, granted...) {
+					app.missingScopeResponse(w, scope)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (app *application) requireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := app.bearerToken(r)
+		if !ok {
 			app.invalidTokenResponse(w)
 			return
 		}
 
-		pollID, err := app.models.Polls.CheckToken(token)
+		user, err := app.models.Users.GetForSession(token)
 		if err != nil {
-			app.invalidTokenResponse(w)
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.invalidTokenResponse(w)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
 			return
 		}
 
-		paramPollID, err := app.readIDParam(r, "pollID")
+		ctx := context.WithValue(r.Context(), ctxUserKey, user)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requireOrgRole must run after requireUser. The user needs one of the
+// given roles in the organization of the route, non-members get a 404.
+func (app *application) requireOrgRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			orgID, err := app.readIDParam(r, "orgID")
+			if err != nil {
+				app.badRequestResponse(w, err)
+				return
+			}
+
+			user := app.userFromContext(r.Context())
+
+			role, err := app.models.Organizations.GetRole(orgID, user.ID)
+			if err != nil {
+				switch {
+				case errors.Is(err, data.ErrRecordNotFound):
+					app.notFoundResponse(w, r)
+				default:
+					app.serverErrorResponse(w, r, err)
+				}
+				return
+			}
+
+			if !validator.PermittedValue(role, roles...) {
+				app.orgRoleRequiredResponse(w)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireVoter establishes who is voting on polls that need to know. Voters
+// sign in with a session, or on voter_auth polls use a credential from the
+// owner. Polls without voter_auth or restrictions are open to everyone.
+func (app *application) requireVoter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pollID, err := app.readIDParam(r, "pollID")
 		if err != nil {
 			app.badRequestResponse(w, err)
 			return
 		}
 
-		if pollID != paramPollID {
-			app.badRequestResponse(w, fmt.Errorf("token not valid for this poll"))
+		poll, err := app.models.Polls.Get(r.Context(), pollID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), ctxPollIDKey, pollID)
+		visible, err := app.pollVisible(r, poll)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		if !visible {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		restricted := poll.VoterIssuer != "" || poll.VoterEmailDomain != ""
+		if !poll.VoterAuth && !restricted {
+			next.ServeHTTP(w, r)
+			return
+		}
 
+		token, ok := app.bearerToken(r)
+		if !ok {
+			app.signInRequiredResponse(w)
+			return
+		}
+
+		var voter *data.Voter
+
+		user, err := app.models.Users.GetForSession(token)
+		switch {
+		case err == nil:
+			if !data.VoterAllowed(poll, user) {
+				app.voterNotAllowedResponse(w)
+				return
+			}
+			voter = data.UserVoter(user)
+		case !errors.Is(err, data.ErrRecordNotFound):
+			app.serverErrorResponse(w, r, err)
+			return
+		// credentials don't say who the voter is, so they can't satisfy
+		// issuer or domain restrictions
+		case poll.VoterAuth && !restricted:
+			credential, err := app.models.Voters.GetForCredential(poll.ID, token)
+			if err != nil {
+				switch {
+				case errors.Is(err, data.ErrRecordNotFound):
+					app.signInRequiredResponse(w)
+				default:
+					app.serverErrorResponse(w, r, err)
+				}
+				return
+			}
+			voter = data.CredentialVoter(credential)
+		default:
+			app.signInRequiredResponse(w)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), ctxVoterKey, voter)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -122,13 +454,13 @@ func (app *application) checkPollExpired(next http.Handler) http.Handler {
 		defer app.mutex.Unlock()
 
 		id := app.pollIDfromContext(r.Context())
-		poll, err := app.models.Polls.Get(id)
+		poll, err := app.models.Polls.Get(r.Context(), id)
 		if err != nil {
 			switch {
 			case errors.Is(err, data.ErrRecordNotFound):
 				app.notFoundResponse(w, r)
 			default:
-				app.serverErrorResponse(w, err)
+				app.serverErrorResponse(w, r, err)
 			}
 			return
 		}
@@ -147,17 +479,11 @@ func (app *application) checkVoteStarted(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		id := app.pollIDfromContext(r.Context())
 
-		results, err := app.models.PollOptions.GetResults(id)
+		votingStarted, err := app.models.Polls.HasVotes(r.Context(), id)
 		if err != nil {
-			app.serverErrorResponse(w, err)
+			app.serverErrorResponse(w, r, err)
 			return
 		}
-		votingStarted := false
-		for _, option := range results {
-			if option.VoteCount > 0 {
-				votingStarted = true
-			}
-		}
 
 		if votingStarted {
 			app.cannotEditResponse(w)
@@ -170,15 +496,27 @@ func (app *application) checkVoteStarted(next http.Handler) http.Handler {
 
 func (app *application) enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Origin")
 		w.Header().Add("Vary", "Access-Control-Request-Method")
 
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		// any origin is allowed unless trusted origins are configured
+		trusted := app.config.cors.trustedOrigins
+		origin := r.Header.Get("Origin")
+		switch {
+		case len(trusted) == 0:
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		case origin != "" && validator.PermittedValue(origin, trusted...):
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
 
 		if r.Method == http.MethodOptions &&
 			r.Header.Get("Origin") != "" &&
 			r.Header.Get("Access-Control-Request-Method") != "" {
 
-			w.Header().Set("Access-Control-Allow-Methods", "OPTIONS, PATCH, DELETE")
+			w.Header().Set("Access-Control-Allow-Methods", "OPTIONS, PUT, PATCH, DELETE")
 			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
 			w.WriteHeader(http.StatusOK)
 			return
@@ -243,3 +581,103 @@ func (app *application) metrics(next http.Handler) http.Handler {
 		}
 	})
 }
+
+// observeRequests records request durations for Prometheus. It labels them
+// by route pattern so poll IDs don't each get their own series.
+func (app *application) observeRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		mw := &metricsResponseWriter{wrapped: w, statusCode: http.StatusOK}
+		next.ServeHTTP(mw, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		httpRequestDuration.WithLabelValues(route, metricMethod(r.Method), strconv.Itoa(mw.statusCode)).Observe(time.Since(start).Seconds())
+	})
+}
+
+// metricMethod keeps the method label to the standard methods, so made up
+// ones can't each add a series.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "other"
+}
+
+const maxRequestIDLength = 128
+
+// requestID keeps the caller's X-Request-ID when it looks sane and makes one
+// up otherwise. Either way it's echoed back in the response.
+func (app *application) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set("X-Request-ID", id)
+		ctx := context.WithValue(r.Context(), ctxRequestIDKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func (app *application) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		mw := &metricsResponseWriter{wrapped: w, statusCode: http.StatusOK}
+		apiKey := new(string)
+		ctx := context.WithValue(r.Context(), ctxAPIKeyNameKey, apiKey)
+		r = r.WithContext(ctx)
+		next.ServeHTTP(mw, r)
+
+		route, pollID := "unmatched", ""
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			pollID = rctx.URLParam("pollID")
+		}
+
+		app.logger.Info("request",
+			"request_id", requestIDFromContext(r.Context()),
+			"method", r.Method,
+			"route", route,
+			"status", mw.statusCode,
+			"latency", time.Since(start),
+			"ip", clientIP(r),
+			"poll_id", pollID,
+			"api_key", *apiKey,
+		)
+	})
+}
+
+// requireFeature answers 404 while the feature is switched off, as if the
+// route didn't exist.
+func (app *application) requireFeature(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !app.featureEnabled(name) {
+				app.notFoundResponse(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
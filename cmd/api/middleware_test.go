@@ -1,22 +1,28 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/auth"
 	"github.com/ivcp/polls/internal/data"
 )
 
 func Test_app_rateLimit(t *testing.T) {
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
 
-	app.config.limiter.rps = 2
-	app.config.limiter.burst = 4
-	app.config.limiter.enabled = true
+	app.limiter.Store(&limiterSettings{RPS: 2, Burst: 4, Enabled: true})
 
 	handlerToTest := app.rateLimit(nextHandler)
 	req, _ := http.NewRequest(http.MethodGet, "/", nil)
@@ -33,7 +39,7 @@ func Test_app_rateLimit(t *testing.T) {
 		}
 	}
 
-	app.config.limiter.enabled = false
+	app.limiter.Store(&limiterSettings{RPS: 2, Burst: 4, Enabled: false})
 	rr = httptest.NewRecorder()
 	for i := 0; i < 10; i++ {
 		handlerToTest.ServeHTTP(rr, req)
@@ -43,10 +49,129 @@ func Test_app_rateLimit(t *testing.T) {
 	}
 }
 
+func Test_app_rateLimitTunedAtRuntime(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	a := &application{logger: app.logger, models: app.models, done: make(chan struct{})}
+	defer close(a.done)
+	a.limiter.Store(&limiterSettings{RPS: 0.01, Burst: 1, Enabled: true})
+	handlerToTest := a.rateLimit(nextHandler)
+
+	serve := func() int {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Forwarded-For", "10.0.0.1")
+		rr := httptest.NewRecorder()
+		handlerToTest.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	serve()
+	if code := serve(); code != http.StatusTooManyRequests {
+		t.Fatalf("expected status code %d, but got %d", http.StatusTooManyRequests, code)
+	}
+
+	// tokens earned before the change come at the old rate
+	a.limiter.Store(&limiterSettings{RPS: 1000, Burst: 1000, Enabled: true})
+	serve()
+	time.Sleep(5 * time.Millisecond)
+	if code := serve(); code != http.StatusOK {
+		t.Errorf("expected the new limit to apply to a client already seen, but got %d", code)
+	}
+}
+
+func Test_app_rateLimitAPIKey(t *testing.T) {
+	tests := []struct {
+		name           string
+		apiKey         string
+		expectedStatus int
+		expectKey      bool
+	}{
+		{"valid key", data.ExampleAPIKey, http.StatusOK, true},
+		{"unknown key", "pk_UNKNOWN", http.StatusUnauthorized, false},
+		{"daily quota exceeded", data.ExampleAPIKeyOverQuota, http.StatusTooManyRequests, false},
+	}
+
+	app.limiter.Store(&limiterSettings{RPS: 2, Burst: 4, Enabled: true})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotKey *data.APIKey
+			nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotKey = app.apiKeyFromContext(r.Context())
+			})
+			handlerToTest := app.rateLimit(nextHandler)
+
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-API-Key", test.apiKey)
+			rr := httptest.NewRecorder()
+			handlerToTest.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if test.expectKey && (gotKey == nil || gotKey.ID != data.ExampleAPIKeyID) {
+				t.Errorf("expected api key in context")
+			}
+		})
+	}
+
+	t.Run("per key limits", func(t *testing.T) {
+		nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+		handlerToTest := app.rateLimit(nextHandler)
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", data.ExampleAPIKey)
+		req.Header.Set("X-Forwarded-For", "0.0.0.0")
+		// well over the per-IP burst, but within the key's burst of 100
+		for i := 0; i < 50; i++ {
+			rr := httptest.NewRecorder()
+			handlerToTest.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("request %d: expected status code %d, but got %d", i, http.StatusOK, rr.Code)
+			}
+		}
+	})
+}
+
+func Test_app_rateLimitRetryAfter(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	a := &application{logger: app.logger, models: app.models, done: make(chan struct{})}
+	defer close(a.done)
+	a.limiter.Store(&limiterSettings{RPS: 0.25, Burst: 1, Enabled: true})
+	handlerToTest := a.rateLimit(nextHandler)
+
+	serve := func(header, value string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(header, value)
+		rr := httptest.NewRecorder()
+		handlerToTest.ServeHTTP(rr, req)
+		return rr
+	}
+
+	serve("X-Forwarded-For", "10.0.0.2")
+	rr := serve("X-Forwarded-For", "10.0.0.2")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status code %d, but got %d", http.StatusTooManyRequests, rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "4" {
+		t.Errorf("expected Retry-After 4, but got %q", got)
+	}
+
+	rr = serve("X-API-Key", data.ExampleAPIKeyOverQuota)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status code %d, but got %d", http.StatusTooManyRequests, rr.Code)
+	}
+	seconds, err := strconv.Atoi(rr.Header().Get("Retry-After"))
+	if err != nil || seconds < 1 || seconds > 24*60*60 {
+		t.Errorf("expected Retry-After until midnight UTC, but got %q", rr.Header().Get("Retry-After"))
+	}
+}
+
 func Test_app_requireToken(t *testing.T) {
 	tests := []struct {
 		name           string
 		authHeader     string
+		apiKey         string
 		expectedStatus int
 	}{
 		{
@@ -73,6 +198,26 @@ func Test_app_requireToken(t *testing.T) {
 			authHeader:     "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A",
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:           "session of poll owner",
+			authHeader:     "Bearer " + data.ExampleSessionToken,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "session of another user",
+			authHeader:     "Bearer " + data.ExampleSessionTokenOther,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "api key that created the poll",
+			apiKey:         data.ExampleAPIKeyID,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "another api key",
+			apiKey:         "0b2d4f6a-8c1e-4d3b-9f5a-7c9e1b3d5f7a",
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
@@ -84,6 +229,10 @@ func Test_app_requireToken(t *testing.T) {
 			chiCtx := chi.NewRouteContext()
 			chiCtx.URLParams.Add("pollID", data.ExamplePollIDValid)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			if test.apiKey != "" {
+				key := &data.APIKey{ID: test.apiKey}
+				req = req.WithContext(context.WithValue(req.Context(), ctxAPIKeyKey, key))
+			}
 			rr := httptest.NewRecorder()
 			if test.authHeader != "" {
 				req.Header.Set("Authorization", test.authHeader)
@@ -93,13 +242,335 @@ func Test_app_requireToken(t *testing.T) {
 			if rr.Code != test.expectedStatus {
 				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
 			}
-			if test.authHeader == "" && rr.Header().Get("WWW-Authenticate") != "Bearer" {
+			if test.authHeader == "" && test.apiKey == "" && rr.Header().Get("WWW-Authenticate") != "Bearer" {
 				t.Errorf("header WWW-Authenticate not set")
 			}
 		})
 	}
 }
 
+func Test_app_requireTokenJWT(t *testing.T) {
+	keys := withJWTKeys(t)
+
+	valid, claims, err := keys.Sign(data.ExamplePollIDValid, data.AllScopes, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPoll, _, _ := keys.Sign(uuid.NewString(), data.AllScopes, time.Hour)
+	expired, _, _ := keys.Sign(data.ExamplePollIDValid, data.AllScopes, -time.Hour)
+
+	seed, _ := auth.GenerateSeed()
+	foreignKeys, _ := auth.ParseKeySet("test=" + seed)
+	foreign, _, _ := foreignKeys.Sign(data.ExamplePollIDValid, data.AllScopes, time.Hour)
+
+	resultsOnly, _, _ := keys.Sign(data.ExamplePollIDValid, []string{data.ScopeResultsRead}, time.Hour)
+
+	var gotScopes []string
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotScopes = app.scopesFromContext(r.Context())
+	})
+	handlerToTest := app.requireToken(nextHandler)
+
+	serve := func(token string) int {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("pollID", data.ExamplePollIDValid)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handlerToTest.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedStatus int
+	}{
+		{"valid token", valid, http.StatusOK},
+		{"token for another poll", otherPoll, http.StatusBadRequest},
+		{"expired token", expired, http.StatusUnauthorized},
+		{"signed by unknown key", foreign, http.StatusUnauthorized},
+		{"malformed token", "a.b.c", http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code := serve(test.token); code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, code)
+			}
+		})
+	}
+
+	t.Run("scopes come from the token", func(t *testing.T) {
+		if code := serve(resultsOnly); code != http.StatusOK {
+			t.Fatalf("expected status %d, but got %d", http.StatusOK, code)
+		}
+		if len(gotScopes) != 1 || gotScopes[0] != data.ScopeResultsRead {
+			t.Errorf("expected scopes [%s], but got %v", data.ScopeResultsRead, gotScopes)
+		}
+	})
+
+	t.Run("revoked token", func(t *testing.T) {
+		err := app.models.Tokens.Deny(claims.ID, data.ExamplePollIDValid, claims.ExpiresAt.Time)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code := serve(valid); code != http.StatusUnauthorized {
+			t.Errorf("expected status %d, but got %d", http.StatusUnauthorized, code)
+		}
+	})
+
+	t.Run("jwt tokens disabled", func(t *testing.T) {
+		app.jwtKeys = nil
+		defer func() { app.jwtKeys = keys }()
+		if code := serve(resultsOnly); code != http.StatusUnauthorized {
+			t.Errorf("expected status %d, but got %d", http.StatusUnauthorized, code)
+		}
+	})
+}
+
+func Test_app_requireUser(t *testing.T) {
+	tests := []struct {
+		name           string
+		authHeader     string
+		expectedStatus int
+	}{
+		{"no auth header set", "", http.StatusUnauthorized},
+		{"poll token", "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A", http.StatusUnauthorized},
+		{"valid session", "Bearer " + data.ExampleSessionToken, http.StatusOK},
+	}
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.userFromContext(r.Context()).ID != data.ExampleUserID {
+			t.Errorf("expected user in context")
+		}
+	})
+	handlerToTest := app.requireUser(nextHandler)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			rr := httptest.NewRecorder()
+			handlerToTest.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func Test_app_requireTokenOrgRole(t *testing.T) {
+	tests := []struct {
+		name           string
+		authHeader     string
+		expectedStatus int
+		expectedScopes []string
+	}{
+		{"org owner", "Bearer " + data.ExampleSessionToken, http.StatusOK, data.AllScopes},
+		{"org viewer", "Bearer " + data.ExampleSessionTokenViewer, http.StatusOK, []string{data.ScopeResultsRead}},
+		{"not a member", "Bearer " + data.ExampleSessionTokenOther, http.StatusBadRequest, nil},
+	}
+
+	var scopes []string
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scopes = app.scopesFromContext(r.Context())
+	})
+	handlerToTest := app.requireToken(nextHandler)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scopes = nil
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", data.ExamplePollIDOrgPrivate)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			req.Header.Set("Authorization", test.authHeader)
+			rr := httptest.NewRecorder()
+			handlerToTest.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !reflect.DeepEqual(scopes, test.expectedScopes) {
+				t.Errorf("expected scopes %v, but got %v", test.expectedScopes, scopes)
+			}
+		})
+	}
+}
+
+func Test_app_requireTokenActor(t *testing.T) {
+	tests := []struct {
+		name          string
+		authHeader    string
+		apiKey        string
+		expectedActor string
+	}{
+		{"poll token", "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A", "", "token:" + data.ExampleTokenID},
+		{"owner session", "Bearer " + data.ExampleSessionToken, "", "user:" + data.ExampleUserID},
+		{"api key", "", data.ExampleAPIKeyID, "api_key:" + data.ExampleAPIKeyID},
+	}
+
+	var actor string
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = app.actorFromContext(r.Context())
+	})
+	handlerToTest := app.requireToken(nextHandler)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actor = ""
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", data.ExamplePollIDValid)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			if test.apiKey != "" {
+				key := &data.APIKey{ID: test.apiKey}
+				req = req.WithContext(context.WithValue(req.Context(), ctxAPIKeyKey, key))
+			}
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			handlerToTest.ServeHTTP(httptest.NewRecorder(), req)
+
+			if actor != test.expectedActor {
+				t.Errorf("expected actor %q, but got %q", test.expectedActor, actor)
+			}
+		})
+	}
+}
+
+func Test_app_requireOrgRole(t *testing.T) {
+	tests := []struct {
+		name           string
+		orgID          string
+		userID         string
+		roles          []string
+		expectedStatus int
+	}{
+		{"owner", data.ExampleOrgID, data.ExampleUserID, []string{data.OrgRoleOwner}, http.StatusOK},
+		{"viewer with any role", data.ExampleOrgID, data.ExampleOrgViewerID, data.OrgRoles, http.StatusOK},
+		{"viewer where owner required", data.ExampleOrgID, data.ExampleOrgViewerID, []string{data.OrgRoleOwner}, http.StatusForbidden},
+		{"not a member", data.ExampleOrgID, uuid.NewString(), data.OrgRoles, http.StatusNotFound},
+		{"invalid org id", "a", data.ExampleUserID, data.OrgRoles, http.StatusBadRequest},
+	}
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("orgID", test.orgID)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx)
+			ctx = context.WithValue(ctx, ctxUserKey, &data.User{ID: test.userID})
+			req = req.WithContext(ctx)
+			rr := httptest.NewRecorder()
+			app.requireOrgRole(test.roles...)(nextHandler).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func Test_app_requireScope(t *testing.T) {
+	tests := []struct {
+		name           string
+		authHeader     string
+		scopes         []string
+		expectedStatus int
+	}{
+		{"full token", "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A", []string{data.ScopePollDelete}, http.StatusOK},
+		{"owner session", "Bearer " + data.ExampleSessionToken, data.AllScopes, http.StatusOK},
+		{"scoped token allowed", "Bearer " + data.ExampleTokenResultsOnly, []string{data.ScopeResultsRead}, http.StatusOK},
+		{"scoped token denied", "Bearer " + data.ExampleTokenResultsOnly, []string{data.ScopePollEdit}, http.StatusForbidden},
+		{"scoped token missing one", "Bearer " + data.ExampleTokenResultsOnly, data.AllScopes, http.StatusForbidden},
+	}
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handlerToTest := app.requireToken(app.requireScope(test.scopes...)(nextHandler))
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", data.ExamplePollIDValid)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			req.Header.Set("Authorization", test.authHeader)
+			rr := httptest.NewRecorder()
+			handlerToTest.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func Test_app_requireVoter(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		authHeader     string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"unrestricted poll", data.ExamplePollIDValid, "", http.StatusOK, ""},
+		{"not signed in", data.ExamplePollIDRestricted, "", http.StatusUnauthorized, "you must be signed in to vote on this poll"},
+		{"invalid session", data.ExamplePollIDRestricted, "Bearer " + data.ExampleTokenResultsOnly, http.StatusUnauthorized, "you must be signed in to vote on this poll"},
+		{"password account", data.ExamplePollIDRestricted, "Bearer " + data.ExampleSessionTokenOther, http.StatusForbidden, "your account is not allowed to vote on this poll"},
+		{"other email domain", data.ExamplePollIDRestricted, "Bearer " + data.ExampleSessionTokenSSOExt, http.StatusForbidden, "your account is not allowed to vote on this poll"},
+		{"staff account", data.ExamplePollIDRestricted, "Bearer " + data.ExampleSessionTokenSSO, http.StatusOK, ""},
+		{"voter credential on restricted poll", data.ExamplePollIDRestricted, "Bearer " + data.ExampleVoterCredential, http.StatusUnauthorized, "you must be signed in to vote on this poll"},
+		{"voter_auth without credentials", data.ExamplePollIDVoterAuth, "", http.StatusUnauthorized, "you must be signed in to vote on this poll"},
+		{"voter_auth with session", data.ExamplePollIDVoterAuth, "Bearer " + data.ExampleSessionTokenOther, http.StatusOK, ""},
+		{"voter_auth with credential", data.ExamplePollIDVoterAuth, "Bearer " + data.ExampleVoterCredential, http.StatusOK, ""},
+		{"voter_auth with unknown credential", data.ExamplePollIDVoterAuth, "Bearer " + data.ExampleTokenResultsOnly, http.StatusUnauthorized, "you must be signed in to vote on this poll"},
+		{"poll not found", uuid.NewString(), "", http.StatusNotFound, "the requested resource could not be found"},
+		{"private org poll without session", data.ExamplePollIDOrgPrivate, "", http.StatusNotFound, "the requested resource could not be found"},
+		{"private org poll as non-member", data.ExamplePollIDOrgPrivate, "Bearer " + data.ExampleSessionTokenOther, http.StatusNotFound, "the requested resource could not be found"},
+		{"private org poll as member", data.ExamplePollIDOrgPrivate, "Bearer " + data.ExampleSessionTokenViewer, http.StatusOK, ""},
+	}
+
+	var voter *data.Voter
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		voter = app.voterFromContext(r.Context())
+	})
+	handlerToTest := app.requireVoter(nextHandler)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			voter = nil
+			req, _ := http.NewRequest(http.MethodPost, "/", nil)
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("pollID", test.pollID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			rr := httptest.NewRecorder()
+			handlerToTest.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatus {
+				t.Errorf("expected status %d, but got %d", test.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), test.expectedBody) {
+				t.Errorf("expected body to contain %q, but got %q", test.expectedBody, rr.Body)
+			}
+			restricted := test.pollID == data.ExamplePollIDRestricted || test.pollID == data.ExamplePollIDVoterAuth
+			if restricted && rr.Code == http.StatusOK && voter == nil {
+				t.Errorf("expected voter in context")
+			}
+		})
+	}
+}
+
 func Test_app_checkPollExpired(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -135,6 +606,7 @@ func Test_app_checkVoteStarted(t *testing.T) {
 		expectedStatus int
 	}{
 		{"voting started", data.ExamplePollIDVotingStarted, http.StatusForbidden},
+		{"open text responses submitted", data.ExamplePollIDOpenTextAnswered, http.StatusForbidden},
 		{"no votes yet", uuid.NewString(), http.StatusOK},
 	}
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
@@ -184,9 +656,9 @@ func Test_app_enableCORS(t *testing.T) {
 			}
 
 			if req.Method == http.MethodOptions {
-				if result.Header.Get("Access-Control-Allow-Methods") != "OPTIONS, PATCH, DELETE" {
+				if result.Header.Get("Access-Control-Allow-Methods") != "OPTIONS, PUT, PATCH, DELETE" {
 					t.Errorf(
-						"Access-Control-Allow-Methods not set to OPTIONS, PATCH, DELETE, got %q",
+						"Access-Control-Allow-Methods not set to OPTIONS, PUT, PATCH, DELETE, got %q",
 						result.Header.Get("Access-Control-Allow-Methods"),
 					)
 				}
@@ -200,3 +672,171 @@ func Test_app_enableCORS(t *testing.T) {
 		})
 	}
 }
+
+func Test_app_requestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expectID string
+	}{
+		{"keeps the caller's id", "abc-123", "abc-123"},
+		{"generates a missing id", "", ""},
+		{"replaces an id with spaces", "abc 123", ""},
+		{"replaces a long id", strings.Repeat("a", maxRequestIDLength+1), ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ctxID string
+			nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctxID = requestIDFromContext(r.Context())
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			if test.header != "" {
+				req.Header.Set("X-Request-ID", test.header)
+			}
+			rr := httptest.NewRecorder()
+			app.requestID(nextHandler).ServeHTTP(rr, req)
+
+			id := rr.Header().Get("X-Request-ID")
+			if test.expectID != "" && id != test.expectID {
+				t.Errorf("expected request id %q, but got %q", test.expectID, id)
+			}
+			if test.expectID == "" && uuid.Validate(id) != nil {
+				t.Errorf("expected a generated request id, but got %q", id)
+			}
+			if ctxID != id {
+				t.Errorf("expected request id %q in context, but got %q", id, ctxID)
+			}
+		})
+	}
+}
+
+func Test_app_logRequests(t *testing.T) {
+	var buf bytes.Buffer
+	logger := app.logger
+	app.logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	t.Cleanup(func() { app.logger = logger })
+
+	mux := chi.NewRouter()
+	mux.Use(app.requestID)
+	mux.Use(app.logRequests)
+	mux.Get("/v1/polls/{pollID}", func(w http.ResponseWriter, r *http.Request) {
+		app.serverErrorResponse(w, r, errors.New("boom"))
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/v1/polls/"+data.ExamplePollIDValid, nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Set("X-Forwarded-For", "0.0.0.1")
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if !strings.Contains(rr.Body.String(), `"request_id":"req-1"`) {
+		t.Errorf("expected the request id in the error response, but got %q", rr.Body)
+	}
+
+	logs := buf.String()
+	for _, expected := range []string{
+		`"msg":"boom","request_id":"req-1"`,
+		`"method":"GET","route":"/v1/polls/{pollID}","status":500`,
+		`"ip":"0.0.0.1","poll_id":"` + data.ExamplePollIDValid + `"`,
+	} {
+		if !strings.Contains(logs, expected) {
+			t.Errorf("expected logs to contain %q, but got %q", expected, logs)
+		}
+	}
+}
+
+func Test_app_logRequestsAPIKey(t *testing.T) {
+	var buf bytes.Buffer
+	logger := app.logger
+	app.logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	t.Cleanup(func() { app.logger = logger })
+
+	mux := chi.NewRouter()
+	mux.Use(app.logRequests)
+	mux.Use(app.rateLimit)
+	mux.Get("/v1/polls", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name     string
+		key      string
+		expected string
+	}{
+		{"key", data.ExampleAPIKey, `"poll_id":"","api_key":"backend"`},
+		{"key over quota", data.ExampleAPIKeyOverQuota, `"poll_id":"","api_key":"over-quota"`},
+		{"no key", "", `"poll_id":"","api_key":""`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf.Reset()
+			req, _ := http.NewRequest(http.MethodGet, "/v1/polls", nil)
+			req.Header.Set("X-Forwarded-For", "0.0.0.2")
+			if test.key != "" {
+				req.Header.Set("X-API-Key", test.key)
+			}
+			mux.ServeHTTP(httptest.NewRecorder(), req)
+
+			if !strings.Contains(buf.String(), test.expected) {
+				t.Errorf("expected logs to contain %q, but got %q", test.expected, buf.String())
+			}
+		})
+	}
+}
+
+func Test_app_enableCORSTrustedOrigins(t *testing.T) {
+	app.config.cors.trustedOrigins = stringList{"https://example.com"}
+	t.Cleanup(func() { app.config.cors.trustedOrigins = nil })
+
+	tests := []struct {
+		name           string
+		origin         string
+		expectedOrigin string
+	}{
+		{"trusted origin", "https://example.com", "https://example.com"},
+		{"other origin", "https://evil.example", ""},
+		{"no origin", "", ""},
+	}
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handlerToTest := app.enableCORS(nextHandler)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			if test.origin != "" {
+				req.Header.Set("Origin", test.origin)
+			}
+			rr := httptest.NewRecorder()
+			handlerToTest.ServeHTTP(rr, req)
+
+			if got := rr.Header().Get("Access-Control-Allow-Origin"); got != test.expectedOrigin {
+				t.Errorf("expected Access-Control-Allow-Origin %q, but got %q", test.expectedOrigin, got)
+			}
+		})
+	}
+}
+
+func Test_app_requireFeature(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, enabled := range []bool{true, false} {
+		a := &application{logger: app.logger}
+		a.config.features.writeIns = enabled
+		a.initRuntime()
+
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		rr := httptest.NewRecorder()
+		a.requireFeature(featureWriteIns)(nextHandler).ServeHTTP(rr, req)
+
+		expected := http.StatusOK
+		if !enabled {
+			expected = http.StatusNotFound
+		}
+		if rr.Code != expected {
+			t.Errorf("enabled %t: expected status code %d, but got %d", enabled, expected, rr.Code)
+		}
+	}
+}
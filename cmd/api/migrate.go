@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+)
+
+var errMigrateUsage = errors.New("usage: migrate up|down|status|version")
+
+// migrate runs a goose command against the embedded migrations: up applies
+// every pending one, down rolls back the last one, status lists them and
+// version prints the current one.
+func (app *application) migrate(db *pgxpool.Pool, args []string) error {
+	if len(args) != 1 {
+		return errMigrateUsage
+	}
+	switch args[0] {
+	case "up", "down", "status", "version":
+	default:
+		return errMigrateUsage
+	}
+
+	if err := setupGoose(); err != nil {
+		return fmt.Errorf("migrate %s: %w", args[0], err)
+	}
+	if err := goose.Run(args[0], stdlib.OpenDBFromPool(db), "."); err != nil {
+		return fmt.Errorf("migrate %s: %w", args[0], err)
+	}
+	return nil
+}
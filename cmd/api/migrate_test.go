@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+)
+
+func Test_app_migrateUsage(t *testing.T) {
+	tests := [][]string{
+		nil,
+		{"sideways"},
+		{"up", "now"},
+	}
+
+	for _, args := range tests {
+		if err := app.migrate(nil, args); !errors.Is(err, errMigrateUsage) {
+			t.Errorf("expected usage error for %v, but got %v", args, err)
+		}
+	}
+}
+
+func Test_embeddedMigrations(t *testing.T) {
+	if err := setupGoose(); err != nil {
+		t.Fatal(err)
+	}
+	collected, err := goose.CollectMigrations(".", 0, goose.MaxVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collected) == 0 {
+		t.Fatal("expected the migrations to be embedded")
+	}
+	for i, m := range collected {
+		if m.Version != int64(i+1) {
+			t.Errorf("expected migration %d, but got %d (%s)", i+1, m.Version, m.Source)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const specOwnerToken = "Bearer UBQ2Z7CLB2SJQBNTUCH4IMRI7A"
+
+type specOperation struct {
+	RequestBody *struct {
+		Content map[string]json.RawMessage `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Ref     string                     `json:"$ref"`
+		Content map[string]json.RawMessage `json:"content"`
+	} `json:"responses"`
+}
+
+func loadSpec(t *testing.T) (map[string]map[string]specOperation, *jsonschema.Compiler) {
+	t.Helper()
+
+	var spec struct {
+		Paths      map[string]map[string]specOperation `json:"paths"`
+		Components struct {
+			Responses map[string]struct {
+				Content map[string]json.RawMessage `json:"content"`
+			} `json:"responses"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	// inline the shared responses so every operation lists its content
+	for _, path := range spec.Paths {
+		for method, op := range path {
+			for status, res := range op.Responses {
+				if name, ok := strings.CutPrefix(res.Ref, "#/components/responses/"); ok {
+					shared, ok := spec.Components.Responses[name]
+					if !ok {
+						t.Fatalf("response %s is not defined", res.Ref)
+					}
+					res.Content = shared.Content
+					op.Responses[status] = res
+				}
+			}
+			path[method] = op
+		}
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	compiler.AssertFormat = true
+	if err := compiler.AddResource("openapi.json", bytes.NewReader(openAPISpec)); err != nil {
+		t.Fatal(err)
+	}
+
+	return spec.Paths, compiler
+}
+
+// compileSchema compiles the schema at the JSON pointer made of tokens.
+func compileSchema(t *testing.T, compiler *jsonschema.Compiler, tokens ...string) *jsonschema.Schema {
+	t.Helper()
+
+	escape := strings.NewReplacer("~", "~0", "/", "~1")
+	pointer := ""
+	for _, token := range tokens {
+		pointer += "/" + escape.Replace(token)
+	}
+
+	schema, err := compiler.Compile("openapi.json#" + pointer)
+	if err != nil {
+		t.Fatalf("compile %s: %v", pointer, err)
+	}
+	return schema
+}
+
+func Test_openAPISpec_coversRoutes(t *testing.T) {
+	paths, _ := loadSpec(t)
+
+	registered := map[string]bool{}
+	err := chi.Walk(testRoutes().(chi.Routes), func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		registered[method+" "+route] = true
+		if _, ok := paths[route][strings.ToLower(method)]; !ok {
+			t.Errorf("route %s %s is not in the spec", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for route, ops := range paths {
+		for method := range ops {
+			if !registered[strings.ToUpper(method)+" "+route] {
+				t.Errorf("spec has %s %s, but it is not registered", strings.ToUpper(method), route)
+			}
+		}
+	}
+}
+
+// Test_openAPISpec_responses replays requests from the handler tests through
+// the router and checks that the spec documents the status and describes the
+// body of each response.
+func Test_openAPISpec_responses(t *testing.T) {
+	limits := app.limiter.Load()
+	app.limiter.Store(&limiterSettings{})
+	t.Cleanup(func() {
+		app.limiter.Store(limits)
+	})
+	keys := withJWTKeys(t)
+	ownerJWT := func(pollID string) string {
+		token, _, err := keys.Sign(pollID, data.AllScopes, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	paths, compiler := loadSpec(t)
+	mux := testRoutes()
+
+	pollPath := "/v1/polls/" + data.ExamplePollIDValid
+	openTextPath := "/v1/polls/" + data.ExamplePollIDOpenText
+	openTextToken := "Bearer " + ownerJWT(data.ExamplePollIDOpenText)
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		authHeader string
+		body       string
+		status     int
+	}{
+		{"healthcheck", http.MethodGet, "/v1/healthcheck", "", "", http.StatusOK},
+		{"liveness", http.MethodGet, "/v1/healthcheck/live", "", "", http.StatusOK},
+		{"readiness", http.MethodGet, "/v1/healthcheck/ready", "", "", http.StatusOK},
+		{"openapi", http.MethodGet, "/v1/openapi.json", "", "", http.StatusOK},
+
+		{"create poll", http.MethodPost, "/v1/polls", "", `{"question":"Test?","options":[{"value":"One","position":0},{"value":"Two","position":1}],"expires_at":"2030-01-01T00:00:00Z"}`, http.StatusCreated},
+		{"create poll as user", http.MethodPost, "/v1/polls", "Bearer " + data.ExampleSessionToken, `{"question":"Test?","options":[{"value":"One","position":0},{"value":"Two","position":1}],"token_format":"jwt"}`, http.StatusCreated},
+		{"create poll badly-formed", http.MethodPost, "/v1/polls", "", `{"question":`, http.StatusBadRequest},
+		{"create poll invalid", http.MethodPost, "/v1/polls", "", `{"question":"","options":[]}`, http.StatusUnprocessableEntity},
+		{"list polls", http.MethodGet, "/v1/polls?search=test&page=1&page_size=5&sort=question", "", "", http.StatusOK},
+		{"list polls invalid page", http.MethodGet, "/v1/polls?page=0", "", "", http.StatusUnprocessableEntity},
+		{"show poll", http.MethodGet, pollPath, "", "", http.StatusOK},
+		{"show poll invalid id", http.MethodGet, "/v1/polls/a", "", "", http.StatusBadRequest},
+		{"show poll not found", http.MethodGet, "/v1/polls/" + data.ExampleOrgID, "", "", http.StatusNotFound},
+		{"show closed quiz", http.MethodGet, "/v1/polls/" + data.ExamplePollIDQuizClosed, "", "", http.StatusOK},
+		{"show schedule poll", http.MethodGet, "/v1/polls/" + data.ExamplePollIDSchedule + "?tz=America/New_York", "", "", http.StatusOK},
+		{"show matrix poll", http.MethodGet, "/v1/polls/" + data.ExamplePollIDMatrix, "", "", http.StatusOK},
+		{"show poll invalid time zone", http.MethodGet, "/v1/polls/" + data.ExamplePollIDSchedule + "?tz=Mars/Olympus", "", "", http.StatusUnprocessableEntity},
+		{"update poll", http.MethodPatch, pollPath, specOwnerToken, `{"question":"Updated?"}`, http.StatusOK},
+		{"update poll without token", http.MethodPatch, pollPath, "", `{"question":"Updated?"}`, http.StatusUnauthorized},
+		{"update poll missing scope", http.MethodPatch, pollPath, "Bearer " + data.ExampleTokenResultsOnly, `{"question":"Updated?"}`, http.StatusForbidden},
+		{"delete poll", http.MethodDelete, pollPath, specOwnerToken, "", http.StatusOK},
+		{"restore poll", http.MethodPost, "/v1/polls/" + data.ExamplePollIDDeleted + "/restore", "Bearer " + data.ExampleSessionToken, "", http.StatusOK},
+		{"restore poll too late", http.MethodPost, "/v1/polls/" + data.ExamplePollIDDeletedOld + "/restore", "Bearer " + data.ExampleSessionToken, "", http.StatusGone},
+
+		{"add option", http.MethodPost, pollPath + "/options", specOwnerToken, `{"value":"Four"}`, http.StatusCreated},
+		{"reorder options", http.MethodPatch, pollPath + "/options", specOwnerToken, `{"options":[{"id":"` + data.ExampleOptionID1 + `","position":1},{"id":"` + data.ExampleOptionID2 + `","position":0},{"id":"` + data.ExampleOptionID3 + `","position":2}]}`, http.StatusOK},
+		{"update option", http.MethodPatch, pollPath + "/options/" + data.ExampleOptionID1, specOwnerToken, `{"value":"Uno"}`, http.StatusCreated},
+		{"delete option", http.MethodDelete, pollPath + "/options/" + data.ExampleOptionID1, specOwnerToken, "", http.StatusOK},
+		{"set answers", http.MethodPut, "/v1/polls/" + data.ExamplePollIDQuiz + "/answers", "Bearer " + ownerJWT(data.ExamplePollIDQuiz), `{"option_ids":["` + data.ExampleOptionID1 + `"]}`, http.StatusOK},
+
+		{"vote", http.MethodPost, pollPath + "/options/" + data.ExampleOptionID1, "", "", http.StatusOK},
+		{"vote on expired poll", http.MethodPost, "/v1/polls/" + data.ExamplePollIDExpiredPoll + "/options/" + data.ExampleOptionID1, "", "", http.StatusForbidden},
+		{"vote without account", http.MethodPost, "/v1/polls/" + data.ExamplePollIDVoterAuth + "/options/" + data.ExampleOptionID1, "", "", http.StatusUnauthorized},
+		{"answer quiz", http.MethodPost, "/v1/polls/" + data.ExamplePollIDQuiz + "/options/" + data.ExampleOptionID1, "", `{"voter":"Alice"}`, http.StatusOK},
+		{"results", http.MethodGet, pollPath + "/results", "", "", http.StatusOK},
+		{"results as owner", http.MethodGet, pollPath + "/results", specOwnerToken, "", http.StatusOK},
+		{"results with ballots", http.MethodGet, "/v1/polls/" + data.ExamplePollIDVoterAuth + "/results", "", "", http.StatusOK},
+		{"results of schedule poll", http.MethodGet, "/v1/polls/" + data.ExamplePollIDSchedule + "/results?tz=Europe/Zagreb", "", "", http.StatusOK},
+		{"results of matrix poll", http.MethodGet, "/v1/polls/" + data.ExamplePollIDMatrix + "/results", "", "", http.StatusOK},
+		{"results of open text poll", http.MethodGet, "/v1/polls/" + data.ExamplePollIDOpenText + "/results", "", "", http.StatusOK},
+		{"results before deadline", http.MethodGet, "/v1/polls/" + data.ExamplePollIDAfterDeadline + "/results", "", "", http.StatusForbidden},
+		{"submit response", http.MethodPost, "/v1/polls/" + data.ExamplePollIDOpenText + "/responses", "", `{"value":"Blue"}`, http.StatusCreated},
+		{"submit empty response", http.MethodPost, "/v1/polls/" + data.ExamplePollIDOpenText + "/responses", "", `{"value":""}`, http.StatusUnprocessableEntity},
+		{"write in", http.MethodPost, "/v1/polls/" + data.ExamplePollIDWriteIn + "/write-ins", "", `{"value":"Purple"}`, http.StatusCreated},
+		{"submit availability", http.MethodPost, "/v1/polls/" + data.ExamplePollIDSchedule + "/availability", "", `{"voter":"Alice","answers":[{"option_id":"` + data.ExampleOptionID1 + `","answer":"yes"},{"option_id":"` + data.ExampleOptionID2 + `","answer":"no"}]}`, http.StatusOK},
+		{"submit ratings", http.MethodPost, "/v1/polls/" + data.ExamplePollIDMatrix + "/ratings", "", `{"ratings":[{"option_id":"` + data.ExampleOptionID1 + `","value":1},{"option_id":"` + data.ExampleOptionID2 + `","value":3}]}`, http.StatusOK},
+		{"leaderboard", http.MethodGet, "/v1/quizzes/" + data.ExampleSeriesID + "/leaderboard", "", "", http.StatusOK},
+
+		{"list responses", http.MethodGet, openTextPath + "/responses?status=pending", openTextToken, "", http.StatusOK},
+		{"list responses invalid status", http.MethodGet, openTextPath + "/responses?status=lost", openTextToken, "", http.StatusUnprocessableEntity},
+		{"update response status", http.MethodPatch, openTextPath + "/responses/" + data.ExampleResponseID1, openTextToken, `{"status":"approved"}`, http.StatusOK},
+		{"merge response", http.MethodPost, openTextPath + "/responses/" + data.ExampleResponseID1 + "/merge", openTextToken, `{"target_id":"` + data.ExampleResponseID2 + `"}`, http.StatusOK},
+		{"promote response", http.MethodPost, openTextPath + "/responses/" + data.ExampleResponseID1 + "/promote", openTextToken, "", http.StatusCreated},
+
+		{"create token", http.MethodPost, pollPath + "/tokens", specOwnerToken, `{"label":"ci","scopes":["results:read"]}`, http.StatusCreated},
+		{"list tokens", http.MethodGet, pollPath + "/tokens", specOwnerToken, "", http.StatusOK},
+		{"delete token", http.MethodDelete, pollPath + "/tokens/" + data.ExampleTokenID, specOwnerToken, "", http.StatusOK},
+		{"revoke signed token", http.MethodPost, pollPath + "/tokens/revoke", specOwnerToken, `{"token":"` + ownerJWT(data.ExamplePollIDValid) + `"}`, http.StatusOK},
+		{"revoke database token", http.MethodPost, pollPath + "/tokens/revoke", specOwnerToken, `{"token":"UBQ2Z7CLB2SJQBNTUCH4IMRI7A"}`, http.StatusUnprocessableEntity},
+		{"jwks", http.MethodGet, "/.well-known/jwks.json", "", "", http.StatusOK},
+
+		{"list revisions", http.MethodGet, pollPath + "/revisions", specOwnerToken, "", http.StatusOK},
+		{"restore revision", http.MethodPost, pollPath + "/revisions/" + data.ExampleRevisionID + "/restore", specOwnerToken, "", http.StatusOK},
+
+		{"create voter credential", http.MethodPost, "/v1/polls/" + data.ExamplePollIDVoterAuth + "/voters", "Bearer " + ownerJWT(data.ExamplePollIDVoterAuth), `{"label":"Bob"}`, http.StatusCreated},
+		{"list voter credentials", http.MethodGet, pollPath + "/voters", specOwnerToken, "", http.StatusOK},
+		{"delete voter credential", http.MethodDelete, pollPath + "/voters/" + data.ExampleVoterCredentialID, specOwnerToken, "", http.StatusOK},
+
+		{"register", http.MethodPost, "/v1/users", "", `{"email":"bob@example.com","name":"Bob","password":"pa55word"}`, http.StatusCreated},
+		{"register taken email", http.MethodPost, "/v1/users", "", `{"email":"` + data.ExampleUserEmail + `","name":"Alice","password":"pa55word"}`, http.StatusUnprocessableEntity},
+		{"list user polls", http.MethodGet, "/v1/users/me/polls", "Bearer " + data.ExampleSessionToken, "", http.StatusOK},
+		{"list user polls signed out", http.MethodGet, "/v1/users/me/polls", "", "", http.StatusUnauthorized},
+		{"sign in", http.MethodPost, "/v1/sessions", "", `{"email":"` + data.ExampleUserEmail + `","password":"` + data.ExampleUserPassword + `"}`, http.StatusCreated},
+		{"sign in wrong password", http.MethodPost, "/v1/sessions", "", `{"email":"` + data.ExampleUserEmail + `","password":"wrong"}`, http.StatusUnauthorized},
+		{"sign out", http.MethodDelete, "/v1/sessions", "Bearer " + data.ExampleSessionToken, "", http.StatusOK},
+		{"oidc login not configured", http.MethodGet, "/v1/auth/oidc/login", "", "", http.StatusNotFound},
+		{"oidc callback not configured", http.MethodGet, "/v1/auth/oidc/callback?code=a&state=b", "", "", http.StatusNotFound},
+
+		{"create organization", http.MethodPost, "/v1/orgs", "Bearer " + data.ExampleSessionToken, `{"name":"Acme"}`, http.StatusCreated},
+		{"list organizations", http.MethodGet, "/v1/orgs", "Bearer " + data.ExampleSessionToken, "", http.StatusOK},
+		{"list organization polls", http.MethodGet, "/v1/orgs/" + data.ExampleOrgID + "/polls", "Bearer " + data.ExampleSessionToken, "", http.StatusOK},
+		{"list organization polls as outsider", http.MethodGet, "/v1/orgs/" + data.ExampleOrgID + "/polls", "Bearer " + data.ExampleSessionTokenOther, "", http.StatusNotFound},
+		{"list organization members", http.MethodGet, "/v1/orgs/" + data.ExampleOrgID + "/members", "Bearer " + data.ExampleSessionTokenViewer, "", http.StatusOK},
+		{"set organization member", http.MethodPut, "/v1/orgs/" + data.ExampleOrgID + "/members", "Bearer " + data.ExampleSessionToken, `{"email":"` + data.ExampleOrgViewerEmail + `","role":"editor"}`, http.StatusOK},
+		{"set organization member as viewer", http.MethodPut, "/v1/orgs/" + data.ExampleOrgID + "/members", "Bearer " + data.ExampleSessionTokenViewer, `{"email":"` + data.ExampleOrgViewerEmail + `","role":"editor"}`, http.StatusForbidden},
+		{"remove organization member", http.MethodDelete, "/v1/orgs/" + data.ExampleOrgID + "/members/" + data.ExampleOrgViewerID, "Bearer " + data.ExampleSessionToken, "", http.StatusOK},
+		{"remove last owner", http.MethodDelete, "/v1/orgs/" + data.ExampleOrgID + "/members/" + data.ExampleUserID, "Bearer " + data.ExampleSessionToken, "", http.StatusConflict},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+			req.Header.Set("X-Forwarded-For", "192.0.2.1")
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != test.status {
+				t.Fatalf("expected status %d, but got %d: %s", test.status, rr.Code, rr.Body)
+			}
+
+			rctx := chi.NewRouteContext()
+			if !mux.(chi.Routes).Match(rctx, test.method, req.URL.Path) {
+				t.Fatalf("no route matches %s %s", test.method, req.URL.Path)
+			}
+			route := rctx.RoutePattern()
+			method := strings.ToLower(test.method)
+			op, ok := paths[route][method]
+			if !ok {
+				t.Fatalf("spec has no operation %s %s", test.method, route)
+			}
+
+			if test.body != "" && rr.Code < 300 {
+				if op.RequestBody == nil {
+					t.Fatalf("spec has no request body for %s %s", test.method, route)
+				}
+				schema := compileSchema(t, compiler, "paths", route, method, "requestBody", "content", "application/json", "schema")
+				validate(t, schema, []byte(test.body), "request")
+			}
+
+			status := strconv.Itoa(rr.Code)
+			res, ok := op.Responses[status]
+			if !ok {
+				t.Fatalf("spec has no %s response for %s %s", status, test.method, route)
+			}
+
+			contentType, _, _ := mime.ParseMediaType(rr.Header().Get("Content-Type"))
+			if _, ok := res.Content[contentType]; len(res.Content) > 0 && !ok {
+				t.Fatalf("spec has no %s content for the %s response", contentType, status)
+			}
+			if contentType != "application/json" {
+				return
+			}
+
+			var pointer []string
+			if name, ok := strings.CutPrefix(res.Ref, "#/components/responses/"); ok {
+				pointer = []string{"components", "responses", name}
+			} else {
+				pointer = []string{"paths", route, method, "responses", status}
+			}
+			pointer = append(pointer, "content", "application/json", "schema")
+			validate(t, compileSchema(t, compiler, pointer...), rr.Body.Bytes(), "response")
+		})
+	}
+}
+
+func validate(t *testing.T, schema *jsonschema.Schema, body []byte, kind string) {
+	t.Helper()
+
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.Fatalf("%s is not JSON: %v", kind, err)
+	}
+	if err := schema.Validate(v); err != nil {
+		t.Errorf("%s does not match the spec: %v\n%s", kind, err, body)
+	}
+}
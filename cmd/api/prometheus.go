@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var promRegistry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "polls_http_request_duration_seconds",
+		Help:    "Time taken to serve HTTP requests, by route pattern, method and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	limiterRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "polls_limiter_rejections_total",
+		Help: "Requests turned away by the rate limiter, by the limit that was hit.",
+	}, []string{"limit"})
+
+	apiKeyRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "polls_api_key_requests_total",
+		Help: "Requests let through for each API key.",
+	}, []string{"api_key"})
+
+	apiKeyRejectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "polls_api_key_rejections_total",
+		Help: "Requests turned away for each API key, by the limit that was hit.",
+	}, []string{"api_key", "limit"})
+
+	votesCast = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "polls_votes_cast_total",
+		Help: "Votes and responses accepted, by poll type.",
+	}, []string{"poll_type"})
+
+	pollsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "polls_created_total",
+		Help: "Polls created.",
+	})
+
+	duplicateVotes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "polls_duplicate_votes_total",
+		Help: "Votes rejected because the voter had already voted.",
+	})
+)
+
+func init() {
+	promRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		limiterRejections,
+		apiKeyRequestsTotal,
+		apiKeyRejectionsTotal,
+		votesCast,
+		pollsCreated,
+		duplicateVotes,
+	)
+}
+
+func (app *application) prometheusHandler() http.Handler {
+	return promhttp.HandlerFor(promRegistry, promhttp.HandlerOpts{})
+}
+
+// poolCollector reads the connection pool stats on every scrape.
+type poolCollector struct {
+	db *pgxpool.Pool
+
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	constructingConns *prometheus.Desc
+	totalConns        *prometheus.Desc
+	maxConns          *prometheus.Desc
+	acquireCount      *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	emptyAcquireCount *prometheus.Desc
+	canceledAcquires  *prometheus.Desc
+	newConns          *prometheus.Desc
+	destroyedConns    *prometheus.Desc
+}
+
+func newPoolCollector(db *pgxpool.Pool) *poolCollector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc("polls_db_pool_"+name, help, labels, nil)
+	}
+	return &poolCollector{
+		db:                db,
+		acquiredConns:     desc("acquired_connections", "Connections currently in use."),
+		idleConns:         desc("idle_connections", "Idle connections in the pool."),
+		constructingConns: desc("constructing_connections", "Connections being opened."),
+		totalConns:        desc("connections", "Connections in the pool."),
+		maxConns:          desc("max_connections", "Largest size the pool can grow to."),
+		acquireCount:      desc("acquires_total", "Connections acquired from the pool."),
+		acquireDuration:   desc("acquire_duration_seconds_total", "Time spent waiting to acquire connections."),
+		emptyAcquireCount: desc("empty_acquires_total", "Acquires that had to wait because the pool was empty."),
+		canceledAcquires:  desc("canceled_acquires_total", "Acquires canceled before a connection was available."),
+		newConns:          desc("new_connections_total", "Connections opened."),
+		destroyedConns:    desc("destroyed_connections_total", "Connections closed by the pool, by reason.", "reason"),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.db.Stat()
+	gauge := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
+	}
+	counter := func(desc *prometheus.Desc, v float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, labels...)
+	}
+
+	gauge(c.acquiredConns, float64(stat.AcquiredConns()))
+	gauge(c.idleConns, float64(stat.IdleConns()))
+	gauge(c.constructingConns, float64(stat.ConstructingConns()))
+	gauge(c.totalConns, float64(stat.TotalConns()))
+	gauge(c.maxConns, float64(stat.MaxConns()))
+	counter(c.acquireCount, float64(stat.AcquireCount()))
+	counter(c.acquireDuration, stat.AcquireDuration().Seconds())
+	counter(c.emptyAcquireCount, float64(stat.EmptyAcquireCount()))
+	counter(c.canceledAcquires, float64(stat.CanceledAcquireCount()))
+	counter(c.newConns, float64(stat.NewConnsCount()))
+	counter(c.destroyedConns, float64(stat.MaxIdleDestroyCount()), "max_idle")
+	counter(c.destroyedConns, float64(stat.MaxLifetimeDestroyCount()), "max_lifetime")
+}
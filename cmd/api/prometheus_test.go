@@ -0,0 +1,99 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test_app_prometheusMetrics(t *testing.T) {
+	mux := chi.NewRouter()
+	mux.Use(app.observeRequests)
+	mux.Get("/v1/polls/{pollID}", app.showPollHandler)
+	mux.Method(http.MethodGet, "/metrics", app.prometheusHandler())
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/v1/polls/" + data.ExamplePollIDValid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	res, err = http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d", http.StatusOK, res.StatusCode)
+	}
+
+	body := new(strings.Builder)
+	if _, err := io.Copy(body, res.Body); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `polls_http_request_duration_seconds_count{method="GET",route="/v1/polls/{pollID}",status="200"}`
+	if !strings.Contains(body.String(), expected) {
+		t.Errorf("expected metrics to contain %q", expected)
+	}
+	if strings.Contains(body.String(), data.ExamplePollIDValid) {
+		t.Errorf("expected poll IDs to be left out of the labels")
+	}
+}
+
+func Test_app_observeRequestsUnknownMethod(t *testing.T) {
+	mux := chi.NewRouter()
+	mux.Use(app.observeRequests)
+	mux.Get("/v1/polls/{pollID}", app.showPollHandler)
+
+	series := make([]int, 0, 3)
+	for _, method := range []string{"BREW", "WHEN", "PROPFIND"} {
+		req, _ := http.NewRequest(method, "/v1/polls/"+data.ExamplePollIDValid, nil)
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+		series = append(series, testutil.CollectAndCount(httpRequestDuration))
+	}
+
+	if series[0] != series[1] || series[1] != series[2] {
+		t.Errorf("expected made up methods to share a series, but the count went %v", series)
+	}
+}
+
+func Test_app_duplicateVoteMetric(t *testing.T) {
+	before := testutil.ToFloat64(duplicateVotes)
+
+	app.cannotVoteResponse(httptest.NewRecorder())
+
+	if got := testutil.ToFloat64(duplicateVotes); got != before+1 {
+		t.Errorf("expected duplicate votes to be %v, but got %v", before+1, got)
+	}
+}
+
+func Test_app_apiKeyMetrics(t *testing.T) {
+	requests := apiKeyRequestsTotal.WithLabelValues("backend")
+	rejections := apiKeyRejectionsTotal.WithLabelValues("over-quota", "quota")
+	requestsBefore, rejectionsBefore := testutil.ToFloat64(requests), testutil.ToFloat64(rejections)
+
+	handlerToTest := app.rateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, key := range []string{data.ExampleAPIKey, data.ExampleAPIKeyOverQuota} {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", key)
+		handlerToTest.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if got := testutil.ToFloat64(requests); got != requestsBefore+1 {
+		t.Errorf("expected requests for the key to be %v, but got %v", requestsBefore+1, got)
+	}
+	if got := testutil.ToFloat64(rejections); got != rejectionsBefore+1 {
+		t.Errorf("expected quota rejections for the key to be %v, but got %v", rejectionsBefore+1, got)
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+func (app *application) purgeDeletedPolls() {
+	app.background(func() {
+		app.every(app.config.deletion.purgeInterval, app.purgeOnce)
+	})
+}
+
+// purgeOnce removes the polls that can no longer be restored.
+func (app *application) purgeOnce() {
+	purged, err := app.models.Polls.Purge(context.Background(), time.Now().Add(-app.config.deletion.gracePeriod))
+	if err != nil {
+		app.logger.Error("purge deleted polls", "error", err)
+		return
+	}
+	if purged > 0 {
+		app.logger.Info("purged deleted polls", "count", purged)
+	}
+}
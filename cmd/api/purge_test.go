@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_app_purgeOnce(t *testing.T) {
+	var buf bytes.Buffer
+	logger := app.logger
+	app.logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	t.Cleanup(func() { app.logger = logger })
+
+	app.purgeOnce()
+
+	expected := fmt.Sprintf(`"msg":"purged deleted polls","count":%d`, data.ExamplePurgedPolls)
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("expected log to contain %q, but got %q", expected, buf.String())
+	}
+}
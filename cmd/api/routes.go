@@ -1,34 +1,96 @@
 package main
 
 import (
-	"expvar"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/ivcp/polls/internal/data"
 )
 
 func (app *application) routes() http.Handler {
 	mux := chi.NewRouter()
 
+	mux.Use(app.requestID)
+	mux.Use(app.traceRequests)
+	mux.Use(app.logRequests)
 	mux.Use(app.metrics)
+	mux.Use(app.observeRequests)
 	mux.Use(middleware.Recoverer)
 	mux.Use(app.enableCORS)
 	mux.NotFound(app.notFoundResponse)
 
+	// probes come from the orchestrator, not through the proxy. Readiness
+	// queries the database, so it's rate limited all the same.
+	mux.Get("/v1/healthcheck/live", app.healthcheckHandler)
+
 	mux.Group(func(mux chi.Router) {
 		mux.Use(app.rateLimit)
 		mux.Get("/v1/healthcheck", app.healthcheckHandler)
+		mux.Get("/v1/healthcheck/ready", app.readinessHandler)
+		mux.Get("/v1/openapi.json", app.showOpenAPIHandler)
 		mux.Post("/v1/polls", app.createPollHandler)
-		mux.Get("/v1/polls", app.listPollsHandler)
+		mux.With(app.requireFeature(featurePollListing)).Get("/v1/polls", app.listPollsHandler)
 		mux.Get("/v1/polls/{pollID}", app.showPollHandler)
 		mux.Get("/v1/polls/{pollID}/results", app.showResultsHandler)
-		mux.Post("/v1/polls/{pollID}/options/{optionID}", app.voteOptionHandler)
+		mux.Get("/v1/auth/oidc/login", app.oidcLoginHandler)
+		mux.Get("/v1/auth/oidc/callback", app.oidcCallbackHandler)
+		mux.Get("/v1/quizzes/{seriesID}/leaderboard", app.showLeaderboardHandler)
+		mux.With(app.requireFeature(featureRegistration)).Post("/v1/users", app.registerUserHandler)
+		mux.Post("/v1/sessions", app.createSessionHandler)
+		mux.Get("/.well-known/jwks.json", app.showJWKSHandler)
+		mux.Post("/v1/polls/{pollID}/restore", app.undeletePollHandler)
+
+		mux.Group(func(mux chi.Router) {
+			mux.Use(app.requireVoter)
+			mux.Post("/v1/polls/{pollID}/options/{optionID}", app.voteOptionHandler)
+			mux.Post("/v1/polls/{pollID}/responses", app.submitResponseHandler)
+			mux.With(app.requireFeature(featureWriteIns)).Post("/v1/polls/{pollID}/write-ins", app.writeInOptionHandler)
+			mux.Post("/v1/polls/{pollID}/availability", app.submitAvailabilityHandler)
+			mux.Post("/v1/polls/{pollID}/ratings", app.submitRatingsHandler)
+		})
+
+		mux.Group(func(mux chi.Router) {
+			mux.Use(app.requireUser)
+			mux.Delete("/v1/sessions", app.deleteSessionHandler)
+			mux.Get("/v1/users/me/polls", app.listUserPollsHandler)
+			mux.Post("/v1/orgs", app.createOrganizationHandler)
+			mux.Get("/v1/orgs", app.listOrganizationsHandler)
+			mux.Group(func(mux chi.Router) {
+				mux.Use(app.requireOrgRole(data.OrgRoles...))
+				mux.Get("/v1/orgs/{orgID}/polls", app.listOrgPollsHandler)
+				mux.Get("/v1/orgs/{orgID}/members", app.listOrgMembersHandler)
+			})
+			mux.Group(func(mux chi.Router) {
+				mux.Use(app.requireOrgRole(data.OrgRoleOwner))
+				mux.Put("/v1/orgs/{orgID}/members", app.setOrgMemberHandler)
+				mux.Delete("/v1/orgs/{orgID}/members/{userID}", app.removeOrgMemberHandler)
+			})
+		})
 
 		mux.Group(func(mux chi.Router) {
 			mux.Use(app.requireToken)
-			mux.Delete("/v1/polls/{pollID}", app.deletePollHandler)
+			mux.With(app.requireScope(data.ScopePollDelete)).Delete("/v1/polls/{pollID}", app.deletePollHandler)
+			mux.With(app.requireScope(data.ScopeResultsRead)).Get("/v1/polls/{pollID}/responses", app.listResponsesHandler)
+			mux.Group(func(mux chi.Router) {
+				mux.Use(app.requireScope(data.AllScopes...))
+				mux.Post("/v1/polls/{pollID}/tokens", app.createTokenHandler)
+				mux.Get("/v1/polls/{pollID}/tokens", app.listTokensHandler)
+				mux.Delete("/v1/polls/{pollID}/tokens/{tokenID}", app.deleteTokenHandler)
+				mux.Post("/v1/polls/{pollID}/tokens/revoke", app.revokeJWTHandler)
+				mux.Get("/v1/polls/{pollID}/revisions", app.listRevisionsHandler)
+			})
+			mux.Group(func(mux chi.Router) {
+				mux.Use(app.requireScope(data.ScopeVotersManage))
+				mux.Post("/v1/polls/{pollID}/voters", app.createVoterCredentialHandler)
+				mux.Get("/v1/polls/{pollID}/voters", app.listVoterCredentialsHandler)
+				mux.Delete("/v1/polls/{pollID}/voters/{voterID}", app.deleteVoterCredentialHandler)
+				mux.Patch("/v1/polls/{pollID}/responses/{responseID}", app.updateResponseStatusHandler)
+				mux.Post("/v1/polls/{pollID}/responses/{responseID}/merge", app.mergeResponseHandler)
+				mux.With(app.checkPollExpired).Post("/v1/polls/{pollID}/responses/{responseID}/promote", app.promoteResponseHandler)
+			})
 			mux.Group(func(mux chi.Router) {
+				mux.Use(app.requireScope(data.ScopePollEdit))
 				mux.Use(app.checkPollExpired)
 				mux.Use(app.checkVoteStarted)
 				mux.Patch("/v1/polls/{pollID}", app.updatePollHandler)
@@ -36,11 +98,11 @@ func (app *application) routes() http.Handler {
 				mux.Patch("/v1/polls/{pollID}/options/{optionID}", app.updateOptionValueHandler)
 				mux.Patch("/v1/polls/{pollID}/options", app.updateOptionPositionHandler)
 				mux.Delete("/v1/polls/{pollID}/options/{optionID}", app.deleteOptionHandler)
+				mux.Put("/v1/polls/{pollID}/answers", app.setAnswersHandler)
+				mux.Post("/v1/polls/{pollID}/revisions/{revisionID}/restore", app.restoreRevisionHandler)
 			})
 		})
 	})
 
-	mux.Method(http.MethodGet, "/v1/metrics", expvar.Handler())
-
 	return mux
 }
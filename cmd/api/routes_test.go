@@ -25,8 +25,44 @@ func Test_app_routes(t *testing.T) {
 		{"/v1/polls/{pollID}/options/{optionID}", http.MethodDelete},
 		{"/v1/polls/{pollID}/options", http.MethodPatch},
 		{"/v1/polls/{pollID}/results", http.MethodGet},
+		{"/v1/polls/{pollID}/responses", http.MethodPost},
+		{"/v1/polls/{pollID}/write-ins", http.MethodPost},
+		{"/v1/polls/{pollID}/availability", http.MethodPost},
+		{"/v1/polls/{pollID}/ratings", http.MethodPost},
+		{"/v1/polls/{pollID}/answers", http.MethodPut},
+		{"/v1/quizzes/{seriesID}/leaderboard", http.MethodGet},
+		{"/v1/polls/{pollID}/responses", http.MethodGet},
+		{"/v1/polls/{pollID}/responses/{responseID}", http.MethodPatch},
+		{"/v1/polls/{pollID}/responses/{responseID}/merge", http.MethodPost},
+		{"/v1/polls/{pollID}/responses/{responseID}/promote", http.MethodPost},
+		{"/v1/users", http.MethodPost},
+		{"/v1/users/me/polls", http.MethodGet},
+		{"/v1/sessions", http.MethodPost},
+		{"/v1/sessions", http.MethodDelete},
+		{"/v1/polls/{pollID}/tokens", http.MethodPost},
+		{"/v1/polls/{pollID}/tokens", http.MethodGet},
+		{"/v1/polls/{pollID}/tokens/{tokenID}", http.MethodDelete},
+		{"/v1/polls/{pollID}/tokens/revoke", http.MethodPost},
+		{"/.well-known/jwks.json", http.MethodGet},
+		{"/v1/polls/{pollID}/restore", http.MethodPost},
+		{"/v1/auth/oidc/login", http.MethodGet},
+		{"/v1/auth/oidc/callback", http.MethodGet},
+		{"/v1/polls/{pollID}/voters", http.MethodPost},
+		{"/v1/polls/{pollID}/voters", http.MethodGet},
+		{"/v1/polls/{pollID}/voters/{voterID}", http.MethodDelete},
+		{"/v1/orgs", http.MethodPost},
+		{"/v1/orgs", http.MethodGet},
+		{"/v1/orgs/{orgID}/polls", http.MethodGet},
+		{"/v1/orgs/{orgID}/members", http.MethodGet},
+		{"/v1/orgs/{orgID}/members", http.MethodPut},
+		{"/v1/orgs/{orgID}/members/{userID}", http.MethodDelete},
+		{"/v1/polls/{pollID}/revisions", http.MethodGet},
+		{"/v1/polls/{pollID}/revisions/{revisionID}/restore", http.MethodPost},
+		{"/v1/healthcheck/live", http.MethodGet},
+		{"/v1/healthcheck/ready", http.MethodGet},
+		{"/v1/openapi.json", http.MethodGet},
 	}
-	testMux := app.routes()
+	testMux := testRoutes()
 	chiRoutes := testMux.(chi.Routes)
 	for _, test := range tests {
 		if !routeExists(test.route, test.method, chiRoutes) {
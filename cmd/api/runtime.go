@@ -0,0 +1,50 @@
+package main
+
+import "sync/atomic"
+
+const (
+	featureRegistration = "registration"
+	featurePollListing  = "poll_listing"
+	featureWriteIns     = "write_ins"
+)
+
+// limiterSettings is the per-IP rate limit. The admin API swaps it while
+// serving, so the rate limiter loads it on every request.
+type limiterSettings struct {
+	RPS     float64 `json:"rps"`
+	Burst   int     `json:"burst"`
+	Enabled bool    `json:"enabled"`
+}
+
+// initRuntime copies the settings the admin API can change out of the
+// config. They are read from the app, not the config, from then on.
+func (app *application) initRuntime() {
+	app.limiter.Store(&limiterSettings{
+		RPS:     app.config.limiter.rps,
+		Burst:   app.config.limiter.burst,
+		Enabled: app.config.limiter.enabled,
+	})
+
+	app.features = map[string]*atomic.Bool{}
+	for name, enabled := range map[string]bool{
+		featureRegistration: app.config.features.registration,
+		featurePollListing:  app.config.features.pollListing,
+		featureWriteIns:     app.config.features.writeIns,
+	} {
+		app.features[name] = &atomic.Bool{}
+		app.features[name].Store(enabled)
+	}
+}
+
+func (app *application) featureEnabled(name string) bool {
+	feature, ok := app.features[name]
+	return ok && feature.Load()
+}
+
+func (app *application) featureStates() map[string]bool {
+	states := make(map[string]bool, len(app.features))
+	for name, feature := range app.features {
+		states[name] = feature.Load()
+	}
+	return states
+}
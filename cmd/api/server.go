@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// serve runs the server, and the admin server unless it's nil, until
+// SIGINT or SIGTERM. It then stops taking new connections, waits for
+// requests in flight and background jobs to finish, and returns.
+func (app *application) serve(srv *http.Server, admin *http.Server) error {
+	// listen before anything is served, so a taken admin port stops startup
+	var adminListener net.Listener
+	if admin != nil {
+		var err error
+		adminListener, err = net.Listen("tcp", admin.Addr)
+		if err != nil {
+			return fmt.Errorf("admin server: %w", err)
+		}
+	}
+
+	shutdownErr := make(chan error)
+
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		s := <-quit
+
+		app.logger.Info("shutting down server", "signal", s.String())
+
+		ctx, cancel := context.WithTimeout(context.Background(), app.config.http.shutdownTimeout)
+		defer cancel()
+
+		close(app.done)
+		err := srv.Shutdown(ctx)
+		if admin != nil {
+			err = errors.Join(err, admin.Shutdown(ctx))
+		}
+
+		app.logger.Info("waiting for background jobs")
+		app.wg.Wait()
+
+		shutdownErr <- err
+	}()
+
+	if admin != nil {
+		app.background(func() {
+			app.logger.Info("starting admin server", "addr", admin.Addr, "tls", admin.TLSConfig != nil)
+			var err error
+			if admin.TLSConfig != nil {
+				err = admin.ServeTLS(adminListener, "", "")
+			} else {
+				err = admin.Serve(adminListener)
+			}
+			if !errors.Is(err, http.ErrServerClosed) {
+				app.logger.Error("admin server stopped", "error", err)
+			}
+		})
+	}
+
+	app.logger.Info("starting server", "env", app.config.env, "addr", srv.Addr)
+
+	err := srv.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	if err := <-shutdownErr; err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+
+	app.logger.Info("stopped server", "addr", srv.Addr)
+	return nil
+}
+
+// background runs fn in a goroutine that shutdown waits for. fn should
+// return once app.done is closed.
+func (app *application) background(fn func()) {
+	app.wg.Add(1)
+	go func() {
+		defer app.wg.Done()
+		fn()
+	}()
+}
+
+func (app *application) shuttingDown() bool {
+	select {
+	case <-app.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (app *application) every(interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-app.done:
+			return
+		case <-ticker.C:
+			fn()
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_app_backgroundStopsOnShutdown(t *testing.T) {
+	a := &application{logger: app.logger, done: make(chan struct{})}
+
+	var runs atomic.Int32
+	a.background(func() {
+		a.every(time.Millisecond, func() { runs.Add(1) })
+	})
+
+	time.Sleep(20 * time.Millisecond)
+	close(a.done)
+
+	stopped := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("expected background job to stop after shutdown")
+	}
+
+	if runs.Load() == 0 {
+		t.Error("expected background job to run before shutdown")
+	}
+}
@@ -1,15 +1,84 @@
 package main
 
 import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/ivcp/polls/internal/auth"
+	"github.com/ivcp/polls/internal/auth/oidctest"
 	"github.com/ivcp/polls/internal/data"
 )
 
 var app application
 
+// testRoutes builds the router once, the metrics middleware can only
+// publish its expvars once per process.
+var testRoutes = sync.OnceValue(func() http.Handler {
+	return app.routes()
+})
+
 func TestMain(m *testing.M) {
 	app.models = data.NewMockModels()
+	app.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	app.config.deletion.gracePeriod = 7 * 24 * time.Hour
+	app.config.features.registration = true
+	app.config.features.pollListing = true
+	app.config.features.writeIns = true
+	app.initRuntime()
 	os.Exit(m.Run())
 }
+
+// withJWTKeys enables signed owner tokens for the duration of a test.
+func withJWTKeys(t *testing.T) *auth.KeySet {
+	t.Helper()
+
+	seed, err := auth.GenerateSeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := auth.ParseKeySet("test=" + seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.jwtKeys = keys
+	app.config.jwt.ttl = time.Hour
+	t.Cleanup(func() {
+		app.jwtKeys = nil
+	})
+
+	return keys
+}
+
+// withOIDC enables sign in with a test issuer for the duration of a test.
+func withOIDC(t *testing.T, user oidctest.User) *oidctest.Issuer {
+	t.Helper()
+
+	issuer, err := oidctest.NewIssuer(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(issuer.Close)
+
+	app.oidc, err = auth.NewOIDC(
+		context.Background(),
+		issuer.URL,
+		oidctest.ClientID,
+		oidctest.ClientSecret,
+		"http://localhost/v1/auth/oidc/callback",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		app.oidc = nil
+	})
+
+	return issuer
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ivcp/polls/internal/auth"
+)
+
+func (c *cli) jwt(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing jwt command\n\n%s", usage)
+	}
+
+	switch args[0] {
+	case "genkey":
+		return c.genJWTKey(args[1:])
+	}
+
+	return fmt.Errorf("unknown jwt command %q\n\n%s", args[0], usage)
+}
+
+// genJWTKey prints a signing key in the form JWT_KEYS expects. Put it first
+// in JWT_KEYS to sign with it and keep the old keys after it until the
+// tokens they signed have expired.
+func (c *cli) genJWTKey(args []string) error {
+	fs := newFlagSet("genkey")
+	kid := fs.String("kid", "", "Key ID")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *kid == "" {
+		return errors.New("kid: must be provided")
+	}
+	if strings.ContainsAny(*kid, "=,") {
+		return errors.New("kid: must not contain '=' or ','")
+	}
+
+	seed, err := auth.GenerateSeed()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "%s=%s\n", *kid, seed)
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/auth"
+)
+
+func Test_cli_jwt(t *testing.T) {
+	var out bytes.Buffer
+	c := cli{out: &out}
+
+	if err := c.run([]string{"jwt", "genkey", "-kid", "2024-03"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := strings.TrimSpace(out.String())
+	if !strings.HasPrefix(entry, "2024-03=") {
+		t.Errorf("expected output to start with the kid, but got %q", entry)
+	}
+	if _, err := auth.ParseKeySet(entry); err != nil {
+		t.Errorf("expected a usable key, but got %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"jwt", "genkey"},
+		{"jwt", "genkey", "-kid", "a,b"},
+		{"jwt", "rotate"},
+	} {
+		if err := c.run(args); err == nil {
+			t.Errorf("expected %v to fail", args)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+func (c *cli) keys(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing keys command\n\n%s", usage)
+	}
+
+	switch args[0] {
+	case "create":
+		return c.createKey(args[1:])
+	case "update":
+		return c.updateKey(args[1:])
+	case "list":
+		return c.listKeys()
+	case "revoke":
+		return c.revokeKey(args[1:])
+	case "usage":
+		return c.keyUsage(args[1:])
+	}
+
+	return fmt.Errorf("unknown keys command %q\n\n%s", args[0], usage)
+}
+
+func parseKeyFlags(name string, args []string) (*data.APIKey, error) {
+	var key data.APIKey
+
+	fs := newFlagSet(name)
+	fs.StringVar(&key.Name, "name", "", "Name of the integration")
+	fs.Float64Var(&key.RPS, "rps", 10, "Maximum requests per second")
+	fs.IntVar(&key.Burst, "burst", 20, "Maximum burst")
+	fs.IntVar(&key.DailyQuota, "quota", 0, "Maximum requests per day, 0 for unlimited")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	key.Name = strings.TrimSpace(key.Name)
+
+	v := validator.New()
+	if data.ValidateAPIKey(v, &key); !v.Valid() {
+		return nil, validationError(v)
+	}
+
+	return &key, nil
+}
+
+func (c *cli) createKey(args []string) error {
+	key, err := parseKeyFlags("create", args)
+	if err != nil {
+		return err
+	}
+
+	token, err := data.GenerateAPIKey()
+	if err != nil {
+		return err
+	}
+
+	err = c.models.APIKeys.Insert(key, token.Hash)
+	if err != nil {
+		if errors.Is(err, data.ErrDuplicateAPIKeyName) {
+			return fmt.Errorf("an api key named %q already exists", key.Name)
+		}
+		return err
+	}
+
+	fmt.Fprintf(c.out, "created api key %q, it will not be shown again:\n%s\n", key.Name, token.Plaintext)
+	return nil
+}
+
+func (c *cli) updateKey(args []string) error {
+	key, err := parseKeyFlags("update", args)
+	if err != nil {
+		return err
+	}
+
+	err = c.models.APIKeys.UpdateLimits(key)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return fmt.Errorf("no api key named %q", key.Name)
+		}
+		return err
+	}
+
+	fmt.Fprintf(c.out, "updated api key %q\n", key.Name)
+	return nil
+}
+
+func (c *cli) listKeys() error {
+	keys, err := c.models.APIKeys.GetAll()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tRPS\tBURST\tDAILY QUOTA\tLAST USED\tSTATUS")
+	for _, key := range keys {
+		quota := "unlimited"
+		if key.DailyQuota > 0 {
+			quota = fmt.Sprint(key.DailyQuota)
+		}
+		status := "active"
+		if key.RevokedAt != nil {
+			status = "revoked"
+		}
+		fmt.Fprintf(tw, "%s\t%g\t%d\t%s\t%s\t%s\n",
+			key.Name, key.RPS, key.Burst, quota, formatTime(key.LastUsedAt), status)
+	}
+	return tw.Flush()
+}
+
+func (c *cli) revokeKey(args []string) error {
+	var name string
+	fs := newFlagSet("revoke")
+	fs.StringVar(&name, "name", "", "Name of the integration")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	err := c.models.APIKeys.Revoke(name)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return fmt.Errorf("no active api key named %q", name)
+		}
+		return err
+	}
+
+	fmt.Fprintf(c.out, "revoked api key %q\n", name)
+	return nil
+}
+
+func (c *cli) keyUsage(args []string) error {
+	var name string
+	var days int
+	fs := newFlagSet("usage")
+	fs.StringVar(&name, "name", "", "Name of the integration")
+	fs.IntVar(&days, "days", 7, "Number of days to show")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if days < 1 {
+		return errors.New("days must be greater than zero")
+	}
+
+	usage, err := c.models.APIKeys.GetUsage(name, days)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DAY\tREQUESTS")
+	for _, u := range usage {
+		fmt.Fprintf(tw, "%s\t%d\n", u.Day.Format(time.DateOnly), u.Requests)
+	}
+	return tw.Flush()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func validationError(v *validator.Validator) error {
+	var msgs []string
+	for key, msg := range v.Errors {
+		msgs = append(msgs, key+": "+msg)
+	}
+	sort.Strings(msgs)
+	return errors.New(strings.Join(msgs, ", "))
+}
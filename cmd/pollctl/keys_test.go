@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_cli_keys(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		expectedOutput string
+		expectedErr    string
+	}{
+		{
+			name:           "create key",
+			args:           []string{"keys", "create", "-name", "backend", "-rps", "50", "-quota", "1000"},
+			expectedOutput: "pk_",
+		},
+		{
+			name:        "create key without name",
+			args:        []string{"keys", "create"},
+			expectedErr: "name: must be provided",
+		},
+		{
+			name:        "create key with invalid limits",
+			args:        []string{"keys", "create", "-name", "backend", "-rps", "0", "-burst", "0"},
+			expectedErr: "burst: must be greater than zero, rps: must be greater than zero",
+		},
+		{
+			name:           "list keys",
+			args:           []string{"keys", "list"},
+			expectedOutput: "backend",
+		},
+		{
+			name:           "revoke key",
+			args:           []string{"keys", "revoke", "-name", "backend"},
+			expectedOutput: `revoked api key "backend"`,
+		},
+		{
+			name:        "usage with invalid days",
+			args:        []string{"keys", "usage", "-name", "backend", "-days", "0"},
+			expectedErr: "days must be greater than zero",
+		},
+		{
+			name:        "unknown command",
+			args:        []string{"votes"},
+			expectedErr: `unknown command "votes"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			c := cli{models: data.NewMockModels(), out: &out}
+
+			err := c.run(test.args)
+			if test.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+					t.Errorf("expected error %q, but got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !strings.Contains(out.String(), test.expectedOutput) {
+				t.Errorf("expected output to contain %q, but got %q", test.expectedOutput, out.String())
+			}
+		})
+	}
+}
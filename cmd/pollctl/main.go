@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ivcp/polls/internal/data"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const usage = `pollctl manages the polls service.
+
+Usage:
+  pollctl polls list [-search TEXT] [-page N] [-page-size N] [-sort FIELD]
+  pollctl polls results -id ID
+  pollctl polls close -id ID
+  pollctl polls delete -id ID
+  pollctl polls rotate-token -id ID
+  pollctl polls purge-ips -id ID
+  pollctl stats
+  pollctl keys create -name NAME [-rps N] [-burst N] [-quota N]
+  pollctl keys update -name NAME [-rps N] [-burst N] [-quota N]
+  pollctl keys list
+  pollctl keys revoke -name NAME
+  pollctl keys usage -name NAME [-days N]
+  pollctl jwt genkey -kid KID
+
+The database is read from the DB_DSN environment variable.
+`
+
+type cli struct {
+	models data.Models
+	out    io.Writer
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// generating keys needs no database
+	if os.Args[1] == "jwt" {
+		c := cli{out: os.Stdout}
+		if err := c.run(os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	dsn := os.Getenv("DB_DSN")
+	if dsn == "" {
+		fmt.Fprintln(os.Stderr, "DB_DSN not set")
+		os.Exit(1)
+	}
+
+	db, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error connecting to the DB: %s\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	c := cli{models: data.NewModels(db), out: os.Stdout}
+	if err := c.run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		db.Close()
+		os.Exit(1)
+	}
+}
+
+func (c *cli) run(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command\n\n%s", usage)
+	}
+
+	switch args[0] {
+	case "polls":
+		return c.polls(args[1:])
+	case "stats":
+		return c.stats()
+	case "keys":
+		return c.keys(args[1:])
+	case "jwt":
+		return c.jwt(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(c.out, usage)
+		return nil
+	}
+
+	return fmt.Errorf("unknown command %q\n\n%s", args[0], usage)
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/data"
+	"github.com/ivcp/polls/internal/validator"
+)
+
+// actor is who pollctl changes are put down to in the revision log.
+const actor = "pollctl"
+
+func (c *cli) polls(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing polls command\n\n%s", usage)
+	}
+
+	switch args[0] {
+	case "list":
+		return c.listPolls(args[1:])
+	case "results":
+		return c.showResults(args[1:])
+	case "close":
+		return c.closePoll(args[1:])
+	case "delete":
+		return c.deletePoll(args[1:])
+	case "rotate-token":
+		return c.rotateToken(args[1:])
+	case "purge-ips":
+		return c.purgeIPs(args[1:])
+	}
+
+	return fmt.Errorf("unknown polls command %q\n\n%s", args[0], usage)
+}
+
+func (c *cli) listPolls(args []string) error {
+	var search string
+	filters := data.Filters{
+		SortSafelist: []string{"created_at", "question", "-created_at", "-question"},
+	}
+
+	fs := newFlagSet("list")
+	fs.StringVar(&search, "search", "", "Words in the question")
+	fs.IntVar(&filters.Page, "page", 1, "Page")
+	fs.IntVar(&filters.PageSize, "page-size", 20, "Polls per page")
+	fs.StringVar(&filters.Sort, "sort", "-created_at", "Sort by created_at or question, - for descending")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	v := validator.New()
+	if data.ValidateFilters(v, filters); !v.Valid() {
+		return validationError(v)
+	}
+
+	polls, metadata, err := c.models.Polls.GetAllIncludingPrivate(context.Background(), search, filters)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tQUESTION\tTYPE\tVISIBILITY\tCREATED\tEXPIRES")
+	for _, poll := range polls {
+		visibility := "public"
+		if poll.IsPrivate {
+			visibility = "private"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			poll.ID, poll.Question, poll.PollType, visibility,
+			poll.CreatedAt.Format(time.RFC3339), formatExpiry(poll.ExpiresAt))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if metadata.TotalRecords > 0 {
+		fmt.Fprintf(c.out, "\npage %d of %d, %d polls\n", metadata.CurrentPage, metadata.LastPage, metadata.TotalRecords)
+	}
+	return nil
+}
+
+func (c *cli) showResults(args []string) error {
+	poll, err := c.getPoll("results", args)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	results, err := c.models.PollOptions.GetResults(ctx, poll.ID)
+	if err != nil {
+		return err
+	}
+	turnout, err := c.models.Polls.GetTurnout(ctx, poll.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "%s\n", poll.Question)
+	if poll.DeletedAt != nil {
+		fmt.Fprintf(c.out, "deleted %s\n", formatTime(poll.DeletedAt))
+	}
+	if poll.HiddenAt != nil {
+		fmt.Fprintf(c.out, "hidden %s\n", formatTime(poll.HiddenAt))
+	}
+	fmt.Fprintln(c.out)
+	tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OPTION\tVOTES")
+	for _, option := range results {
+		fmt.Fprintf(tw, "%s\t%d\n", option.Value, option.VoteCount)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "\n%d ballots from %d IPs, last vote %s\n",
+		turnout.Ballots, turnout.DistinctIPs, formatTime(turnout.LastVoteAt))
+	return nil
+}
+
+// closePoll makes the poll expire now, so voting stops and results are
+// shown as for any other closed poll.
+func (c *cli) closePoll(args []string) error {
+	poll, err := c.getPoll("close", args)
+	if err != nil {
+		return err
+	}
+
+	if poll.DeletedAt != nil {
+		return fmt.Errorf("poll %s is deleted", poll.ID)
+	}
+	if !poll.ExpiresAt.Time.IsZero() && poll.ExpiresAt.Time.Before(time.Now()) {
+		return fmt.Errorf("poll %s is already closed", poll.ID)
+	}
+
+	ctx := context.Background()
+	before := data.NewPollSnapshot(poll)
+	poll.ExpiresAt = data.ExpiresAt{Time: time.Now().Truncate(time.Second)}
+
+	revision := data.NewRevision(data.RevisionUpdatePoll, actor, before)
+	if err := c.models.Polls.Update(ctx, poll, revision); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "closed poll %s\n", poll.ID)
+	return nil
+}
+
+func (c *cli) deletePoll(args []string) error {
+	poll, err := c.getPoll("delete", args)
+	if err != nil {
+		return err
+	}
+	if poll.DeletedAt != nil {
+		return fmt.Errorf("poll %s is already deleted", poll.ID)
+	}
+
+	err = c.models.Polls.Delete(context.Background(), poll.ID, data.NewRevision(data.RevisionDelete, actor, nil))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return fmt.Errorf("no poll with id %q", poll.ID)
+		}
+		return err
+	}
+
+	fmt.Fprintf(c.out, "deleted poll %s, it can be restored until it's purged\n", poll.ID)
+	return nil
+}
+
+// rotateToken replaces the owner token of a poll, e.g. when it has leaked
+// or the owner lost it. Signed tokens stay valid until they are revoked.
+func (c *cli) rotateToken(args []string) error {
+	poll, err := c.getPoll("rotate-token", args)
+	if err != nil {
+		return err
+	}
+
+	token, err := data.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	pollToken := &data.PollToken{PollID: poll.ID}
+	revoked, err := c.models.Tokens.RotateOwner(pollToken, token.Hash)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "revoked %d owner tokens of poll %s, the new token will not be shown again:\n%s\n",
+		revoked, poll.ID, token.Plaintext)
+	return nil
+}
+
+func (c *cli) purgeIPs(args []string) error {
+	poll, err := c.getPoll("purge-ips", args)
+	if err != nil {
+		return err
+	}
+
+	purged, err := c.models.Polls.PurgeIPs(context.Background(), poll.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "purged %d IPs of poll %s\n", purged, poll.ID)
+	return nil
+}
+
+func (c *cli) stats() error {
+	stats, err := c.models.Polls.GetStats(context.Background())
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "polls\t%d\n", stats.Polls)
+	fmt.Fprintf(tw, "private polls\t%d\n", stats.PrivatePolls)
+	fmt.Fprintf(tw, "open polls\t%d\n", stats.OpenPolls)
+	fmt.Fprintf(tw, "deleted polls\t%d\n", stats.DeletedPolls)
+	fmt.Fprintf(tw, "votes\t%d\n", stats.Votes)
+	fmt.Fprintf(tw, "votes in the last day\t%d\n", stats.VotesLastDay)
+	return tw.Flush()
+}
+
+func parsePollID(name string, args []string) (string, error) {
+	var id string
+	fs := newFlagSet(name)
+	fs.StringVar(&id, "id", "", "Poll ID")
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if id == "" {
+		return "", errors.New("id: must be provided")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return "", errors.New("id: must be a valid UUID")
+	}
+	return id, nil
+}
+
+func (c *cli) getPoll(name string, args []string) (*data.Poll, error) {
+	id, err := parsePollID(name, args)
+	if err != nil {
+		return nil, err
+	}
+
+	poll, err := c.models.Polls.GetAny(context.Background(), id)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return nil, fmt.Errorf("no poll with id %q", id)
+		}
+		return nil, err
+	}
+	return poll, nil
+}
+
+func formatExpiry(e data.ExpiresAt) string {
+	if e.Time.IsZero() {
+		return "never"
+	}
+	return e.Time.Format(time.RFC3339)
+}
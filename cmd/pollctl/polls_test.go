@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ivcp/polls/internal/data"
+)
+
+func Test_cli_polls(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		expectedOutput string
+		expectedErr    string
+	}{
+		{
+			name:           "list includes private polls",
+			args:           []string{"polls", "list", "-search", "test"},
+			expectedOutput: data.ExamplePollIDOrgPrivate,
+		},
+		{
+			name:        "list with invalid sort",
+			args:        []string{"polls", "list", "-sort", "votes"},
+			expectedErr: "sort: invalid sort value",
+		},
+		{
+			name:           "results",
+			args:           []string{"polls", "results", "-id", data.ExamplePollIDValid},
+			expectedOutput: "3 ballots from 2 IPs",
+		},
+		{
+			name:        "results without id",
+			args:        []string{"polls", "results"},
+			expectedErr: "id: must be provided",
+		},
+		{
+			name:        "results of a missing poll",
+			args:        []string{"polls", "results", "-id", "00000000-0000-4000-8000-000000000000"},
+			expectedErr: "no poll with id",
+		},
+		{
+			name:           "results of a hidden poll",
+			args:           []string{"polls", "results", "-id", data.ExamplePollIDHidden},
+			expectedOutput: "hidden ",
+		},
+		{
+			name:           "results of a deleted poll",
+			args:           []string{"polls", "results", "-id", data.ExamplePollIDDeleted},
+			expectedOutput: "deleted ",
+		},
+		{
+			name:           "close",
+			args:           []string{"polls", "close", "-id", data.ExamplePollIDValid},
+			expectedOutput: "closed poll " + data.ExamplePollIDValid,
+		},
+		{
+			name:        "close a closed poll",
+			args:        []string{"polls", "close", "-id", data.ExamplePollIDExpiredPoll},
+			expectedErr: "already closed",
+		},
+		{
+			name:        "close a deleted poll",
+			args:        []string{"polls", "close", "-id", data.ExamplePollIDDeleted},
+			expectedErr: "is deleted",
+		},
+		{
+			name:           "delete",
+			args:           []string{"polls", "delete", "-id", data.ExamplePollIDValid},
+			expectedOutput: "deleted poll " + data.ExamplePollIDValid,
+		},
+		{
+			name:        "delete a deleted poll",
+			args:        []string{"polls", "delete", "-id", data.ExamplePollIDDeleted},
+			expectedErr: "already deleted",
+		},
+		{
+			name:        "delete with invalid id",
+			args:        []string{"polls", "delete", "-id", "1"},
+			expectedErr: "id: must be a valid UUID",
+		},
+		{
+			name:           "rotate token",
+			args:           []string{"polls", "rotate-token", "-id", data.ExamplePollIDValid},
+			expectedOutput: "revoked 1 owner tokens",
+		},
+		{
+			name:           "purge ips",
+			args:           []string{"polls", "purge-ips", "-id", data.ExamplePollIDValid},
+			expectedOutput: "purged 5 IPs",
+		},
+		{
+			name:           "stats",
+			args:           []string{"stats"},
+			expectedOutput: "votes in the last day  35",
+		},
+		{
+			name:        "unknown polls command",
+			args:        []string{"polls", "archive"},
+			expectedErr: `unknown polls command "archive"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			c := cli{models: data.NewMockModels(), out: &out}
+
+			err := c.run(test.args)
+			if test.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+					t.Errorf("expected error %q, but got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !strings.Contains(out.String(), test.expectedOutput) {
+				t.Errorf("expected output to contain %q, but got %q", test.expectedOutput, out.String())
+			}
+		})
+	}
+}
+
+func Test_cli_rotateTokenPrintsPlaintext(t *testing.T) {
+	var out bytes.Buffer
+	c := cli{models: data.NewMockModels(), out: &out}
+
+	if err := c.run([]string{"polls", "rotate-token", "-id", data.ExamplePollIDValid}); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if token := lines[len(lines)-1]; len(token) != 26 {
+		t.Errorf("expected a 26 byte token on the last line, but got %q", token)
+	}
+}
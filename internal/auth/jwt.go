@@ -0,0 +1,164 @@
+// Package auth issues and verifies the signed owner tokens handed out in
+// place of database tokens.
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const issuer = "polls"
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type PollClaims struct {
+	Scopes []string `json:"scopes"`
+	jwt.RegisteredClaims
+}
+
+// KeySet holds the signing keys by key ID. The first key signs new tokens,
+// the rest are kept so tokens signed before a rotation still verify.
+type KeySet struct {
+	kids []string
+	keys map[string]ed25519.PrivateKey
+}
+
+// ParseKeySet reads keys in the form "kid=seed,kid=seed", where each seed
+// is a base64url encoded 32 byte Ed25519 seed.
+func ParseKeySet(s string) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]ed25519.PrivateKey)}
+
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, encoded, ok := strings.Cut(entry, "=")
+		if !ok || kid == "" {
+			return nil, fmt.Errorf("jwt keys: entry must be in the form kid=seed")
+		}
+		if _, exists := ks.keys[kid]; exists {
+			return nil, fmt.Errorf("jwt keys: duplicate kid %q", kid)
+		}
+		seed, err := base64.RawURLEncoding.DecodeString(encoded)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("jwt keys: seed of %q must be %d base64url encoded bytes", kid, ed25519.SeedSize)
+		}
+		ks.kids = append(ks.kids, kid)
+		ks.keys[kid] = ed25519.NewKeyFromSeed(seed)
+	}
+
+	if len(ks.kids) == 0 {
+		return nil, errors.New("jwt keys: no keys provided")
+	}
+
+	return ks, nil
+}
+
+// GenerateSeed returns a new base64url encoded seed for ParseKeySet.
+func GenerateSeed() (string, error) {
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(seed), nil
+}
+
+// Sign issues a token for a poll. The returned claims hold the generated
+// token ID.
+func (ks *KeySet) Sign(pollID string, scopes []string, ttl time.Duration) (string, *PollClaims, error) {
+	now := time.Now()
+	claims := &PollClaims{
+		Scopes: scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   pollID,
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	kid := ks.kids[0]
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(ks.keys[kid])
+	if err != nil {
+		return "", nil, fmt.Errorf("sign token: %w", err)
+	}
+
+	return signed, claims, nil
+}
+
+// Verify checks the signature, issuer and expiry of a token.
+func (ks *KeySet) Verify(tokenString string) (*PollClaims, error) {
+	var claims PollClaims
+
+	_, err := jwt.ParseWithClaims(
+		tokenString,
+		&claims,
+		func(token *jwt.Token) (any, error) {
+			kid, _ := token.Header["kid"].(string)
+			key, ok := ks.keys[kid]
+			if !ok {
+				return nil, fmt.Errorf("unknown kid %q", kid)
+			}
+			return key.Public(), nil
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+
+	if _, err := uuid.Parse(claims.Subject); err != nil {
+		return nil, fmt.Errorf("%w: invalid subject", ErrInvalidToken)
+	}
+	if _, err := uuid.Parse(claims.ID); err != nil {
+		return nil, fmt.Errorf("%w: invalid jti", ErrInvalidToken)
+	}
+
+	return &claims, nil
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
+// JWKS returns the public keys in JSON Web Key Set form.
+func (ks *KeySet) JWKS() []JWK {
+	keys := make([]JWK, 0, len(ks.kids))
+	for _, kid := range ks.kids {
+		public := ks.keys[kid].Public().(ed25519.PublicKey)
+		keys = append(keys, JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(public),
+			KeyID:     kid,
+			Algorithm: jwt.SigningMethodEdDSA.Alg(),
+			Use:       "sig",
+		})
+	}
+	return keys
+}
+
+// LooksLikeJWT tells signed tokens apart from database tokens.
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestKeySet(t *testing.T, kids ...string) *KeySet {
+	t.Helper()
+	var entries []string
+	for _, kid := range kids {
+		seed, err := GenerateSeed()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, kid+"="+seed)
+	}
+	ks, err := ParseKeySet(strings.Join(entries, ","))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+func TestParseKeySet(t *testing.T) {
+	seed, _ := GenerateSeed()
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"single key", "k1=" + seed, false},
+		{"two keys", "k2=" + seed + ", k1=" + seed, false},
+		{"empty", "", true},
+		{"missing kid", "=" + seed, true},
+		{"missing seed", "k1", true},
+		{"short seed", "k1=AAAA", true},
+		{"duplicate kid", "k1=" + seed + ",k1=" + seed, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseKeySet(test.input)
+			if (err != nil) != test.wantErr {
+				t.Errorf("expected error %v, but got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestKeySetSignVerify(t *testing.T) {
+	ks := newTestKeySet(t, "k1")
+	pollID := uuid.NewString()
+
+	token, issued, err := ks.Sign(pollID, []string{"poll:edit"}, time.Hour)
+	if err != nil {
+		t.Fatalf("sign returned an error: %s", err)
+	}
+	if !LooksLikeJWT(token) {
+		t.Errorf("expected a JWT, but got %q", token)
+	}
+
+	claims, err := ks.Verify(token)
+	if err != nil {
+		t.Fatalf("verify returned an error: %s", err)
+	}
+	if claims.Subject != pollID || claims.ID != issued.ID || len(claims.Scopes) != 1 {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	expired, _, _ := ks.Sign(pollID, []string{"poll:edit"}, -time.Hour)
+	if _, err := ks.Verify(expired); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected expired token to be rejected, but got %v", err)
+	}
+
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + parts[1] + "x." + parts[2]
+	if _, err := ks.Verify(tampered); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected tampered token to be rejected, but got %v", err)
+	}
+
+	other := newTestKeySet(t, "k1")
+	if _, err := other.Verify(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected token signed with another key to be rejected, but got %v", err)
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	old := newTestKeySet(t, "k1")
+	token, _, _ := old.Sign(uuid.NewString(), []string{"poll:edit"}, time.Hour)
+
+	seed, _ := GenerateSeed()
+	rotated, err := ParseKeySet("k2=" + seed + "," + "k1=" + encodedSeed(old, "k1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rotated.Verify(token); err != nil {
+		t.Errorf("expected token signed before rotation to verify, but got %v", err)
+	}
+
+	newToken, _, _ := rotated.Sign(uuid.NewString(), []string{"poll:edit"}, time.Hour)
+	if _, err := old.Verify(newToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected token with unknown kid to be rejected, but got %v", err)
+	}
+
+	jwks := rotated.JWKS()
+	if len(jwks) != 2 || jwks[0].KeyID != "k2" || jwks[1].KeyID != "k1" {
+		t.Errorf("expected both keys in JWKS, but got %+v", jwks)
+	}
+}
+
+func encodedSeed(ks *KeySet, kid string) string {
+	return base64.RawURLEncoding.EncodeToString(ks.keys[kid].Seed())
+}
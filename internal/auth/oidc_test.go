@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ivcp/polls/internal/auth/oidctest"
+)
+
+const redirectURL = "http://polls.test/v1/auth/oidc/callback"
+
+// authorize follows the login URL to the issuer and returns the code and
+// state it redirects back with.
+func authorize(t *testing.T, o *OIDC, req *LoginRequest) (string, string) {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(o.AuthCodeURL(req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	location, err := resp.Location()
+	if err != nil {
+		t.Fatalf("expected a redirect, but got status %d", resp.StatusCode)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestOIDC(t *testing.T) {
+	verified, unverified := true, false
+	issuer, err := oidctest.NewIssuer(oidctest.User{
+		Subject: "alice", Email: "alice@example.com", EmailVerified: &verified, Name: "Alice",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer issuer.Close()
+
+	ctx := context.Background()
+	o, err := NewOIDC(ctx, issuer.URL, oidctest.ClientID, oidctest.ClientSecret, redirectURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := NewLoginRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, state := authorize(t, o, req)
+	if state != req.State {
+		t.Errorf("expected state %q, but got %q", req.State, state)
+	}
+
+	identity, err := o.Exchange(ctx, code, req)
+	if err != nil {
+		t.Fatalf("exchange returned an error: %s", err)
+	}
+	if identity.Issuer != issuer.URL || identity.Subject != "alice" || identity.Email != "alice@example.com" {
+		t.Errorf("unexpected identity %+v", identity)
+	}
+
+	t.Run("code can only be used once", func(t *testing.T) {
+		if _, err := o.Exchange(ctx, code, req); !errors.Is(err, ErrInvalidLogin) {
+			t.Errorf("expected ErrInvalidLogin, but got %v", err)
+		}
+	})
+
+	t.Run("wrong verifier", func(t *testing.T) {
+		other, _ := NewLoginRequest()
+		code, _ := authorize(t, o, req)
+		other.Nonce = req.Nonce
+		if _, err := o.Exchange(ctx, code, other); !errors.Is(err, ErrInvalidLogin) {
+			t.Errorf("expected ErrInvalidLogin, but got %v", err)
+		}
+	})
+
+	t.Run("wrong nonce", func(t *testing.T) {
+		code, _ := authorize(t, o, req)
+		replayed := *req
+		replayed.Nonce = "other"
+		if _, err := o.Exchange(ctx, code, &replayed); !errors.Is(err, ErrInvalidLogin) {
+			t.Errorf("expected ErrInvalidLogin, but got %v", err)
+		}
+	})
+
+	t.Run("unverified email", func(t *testing.T) {
+		issuer.SetUser(oidctest.User{Subject: "bob", Email: "bob@example.com", EmailVerified: &unverified})
+		defer issuer.SetUser(oidctest.User{Subject: "alice", Email: "alice@example.com", EmailVerified: &verified})
+
+		code, _ := authorize(t, o, req)
+		if _, err := o.Exchange(ctx, code, req); !errors.Is(err, ErrInvalidLogin) {
+			t.Errorf("expected ErrInvalidLogin, but got %v", err)
+		}
+	})
+
+	// the email decides which domain restricted polls the user can vote
+	// on, so it's not taken on trust
+	t.Run("email_verified left out", func(t *testing.T) {
+		issuer.SetUser(oidctest.User{Subject: "mallory", Email: "mallory@example.com"})
+		defer issuer.SetUser(oidctest.User{Subject: "alice", Email: "alice@example.com", EmailVerified: &verified})
+
+		code, _ := authorize(t, o, req)
+		if _, err := o.Exchange(ctx, code, req); !errors.Is(err, ErrInvalidLogin) {
+			t.Errorf("expected ErrInvalidLogin, but got %v", err)
+		}
+	})
+}
@@ -0,0 +1,294 @@
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrDuplicateAPIKeyName = errors.New("duplicate api key name")
+
+const (
+	apiKeyPrefix        = "pk_"
+	maxAPIKeyNameLength = 100
+)
+
+// APIKey identifies a server-to-server integration. Keys have their own
+// rate limits instead of the per-IP limiter and a DailyQuota of 0 means
+// unlimited.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	RPS        float64    `json:"rps"`
+	Burst      int        `json:"burst"`
+	DailyQuota int        `json:"daily_quota"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type APIKeyUsage struct {
+	Day      time.Time `json:"day"`
+	Requests int       `json:"requests"`
+}
+
+func GenerateAPIKey() (*Token, error) {
+	randomBytes := make([]byte, 20)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := apiKeyPrefix + base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+	hash := sha256.Sum256([]byte(plaintext))
+
+	return &Token{Plaintext: plaintext, Hash: hash[:]}, nil
+}
+
+func ValidateAPIKey(v *validator.Validator, key *APIKey) {
+	v.Check(key.Name != "", "name", "must be provided")
+	v.Check(
+		len(key.Name) <= maxAPIKeyNameLength,
+		"name",
+		fmt.Sprintf("must not be more than %d bytes long", maxAPIKeyNameLength),
+	)
+	v.Check(key.RPS > 0, "rps", "must be greater than zero")
+	v.Check(key.Burst > 0, "burst", "must be greater than zero")
+	v.Check(key.DailyQuota >= 0, "daily_quota", "must not be negative")
+}
+
+type APIKeyModel struct {
+	DB *pgxpool.Pool
+}
+
+func (a APIKeyModel) Insert(key *APIKey, hash []byte) error {
+	query := `
+		INSERT INTO api_keys (name, hash, rps, burst, daily_quota)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at;
+	`
+
+	args := []any{key.Name, hash, key.RPS, key.Burst, key.DailyQuota}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	err := a.DB.QueryRow(ctx, query, args...).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDuplicateAPIKeyName
+		}
+		return fmt.Errorf("insert api key: %w", err)
+	}
+
+	return nil
+}
+
+// GetByPlaintext returns the key unless it was revoked.
+func (a APIKeyModel) GetByPlaintext(plaintext string) (*APIKey, error) {
+	hash := sha256.Sum256([]byte(plaintext))
+
+	query := `
+		SELECT id, name, rps, burst, daily_quota, created_at, last_used_at, revoked_at
+		FROM api_keys
+		WHERE hash = $1 AND revoked_at IS NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	key, err := scanAPIKey(a.DB.QueryRow(ctx, query, hash[:]))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (a APIKeyModel) GetAll() ([]*APIKey, error) {
+	query := `
+		SELECT id, name, rps, burst, daily_quota, created_at, last_used_at, revoked_at
+		FROM api_keys
+		ORDER BY name ASC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := a.DB.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*APIKey{}
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("get api keys - scan: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("get api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (a APIKeyModel) UpdateLimits(key *APIKey) error {
+	query := `
+		UPDATE api_keys
+		SET rps = $1, burst = $2, daily_quota = $3
+		WHERE name = $4;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := a.DB.Exec(ctx, query, key.RPS, key.Burst, key.DailyQuota, key.Name)
+	if err != nil {
+		return fmt.Errorf("update api key: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (a APIKeyModel) Revoke(name string) error {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = NOW()
+		WHERE name = $1 AND revoked_at IS NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := a.DB.Exec(ctx, query, name)
+	if err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// RecordUsage counts a request against today's usage and returns the
+// number of requests made with the key today, this one included.
+func (a APIKeyModel) RecordUsage(keyID string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := a.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("record api key usage: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO api_key_usage (api_key_id, day, requests)
+		VALUES ($1, CURRENT_DATE, 1)
+		ON CONFLICT (api_key_id, day)
+		DO UPDATE SET requests = api_key_usage.requests + 1
+		RETURNING requests;
+	`
+
+	var requests int
+	err = tx.QueryRow(ctx, query, keyID).Scan(&requests)
+	if err != nil {
+		return 0, fmt.Errorf("record api key usage: %w", err)
+	}
+
+	queryKey := `
+		UPDATE api_keys
+		SET last_used_at = NOW()
+		WHERE id = $1;
+	`
+	_, err = tx.Exec(ctx, queryKey, keyID)
+	if err != nil {
+		return 0, fmt.Errorf("record api key usage - set last_used_at: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("record api key usage: %w", err)
+	}
+
+	return requests, nil
+}
+
+// GetUsage returns daily request counts for the last days, newest first.
+func (a APIKeyModel) GetUsage(name string, days int) ([]*APIKeyUsage, error) {
+	query := `
+		SELECT u.day, u.requests
+		FROM api_key_usage u
+		JOIN api_keys k ON k.id = u.api_key_id
+		WHERE k.name = $1 AND u.day > CURRENT_DATE - $2::integer
+		ORDER BY u.day DESC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := a.DB.Query(ctx, query, name, days)
+	if err != nil {
+		return nil, fmt.Errorf("get api key usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := []*APIKeyUsage{}
+
+	for rows.Next() {
+		var u APIKeyUsage
+		if err := rows.Scan(&u.Day, &u.Requests); err != nil {
+			return nil, fmt.Errorf("get api key usage - scan: %w", err)
+		}
+		usage = append(usage, &u)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("get api key usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+func scanAPIKey(row pgx.Row) (*APIKey, error) {
+	var key APIKey
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.RPS,
+		&key.Burst,
+		&key.DailyQuota,
+		&key.CreatedAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
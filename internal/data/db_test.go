@@ -9,10 +9,12 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ivcp/polls/migrations"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/ory/dockertest/v3"
@@ -90,13 +92,14 @@ func TestMain(m *testing.M) {
 }
 
 func runMigrations() error {
+	goose.SetBaseFS(migrations.FS)
 	if err := goose.SetDialect("postgres"); err != nil {
 		return fmt.Errorf("createTables: %w", err)
 	}
 
 	db := stdlib.OpenDBFromPool(testDB)
 
-	if err := goose.Up(db, "../../migrations"); err != nil {
+	if err := goose.Up(db, "."); err != nil {
 		return fmt.Errorf("createTables: %w", err)
 	}
 
@@ -132,7 +135,7 @@ func createPollAndGenerateToken(t *testing.T) (*Poll, *Token) {
 func TestPollsInsert(t *testing.T) {
 	poll, token := createPollAndGenerateToken(t)
 
-	if err := testModels.Polls.Insert(poll, token.Hash); err != nil {
+	if err := testModels.Polls.Insert(context.Background(), poll, token.Hash, nil); err != nil {
 		t.Errorf("insert poll returned an error: %s", err)
 	}
 
@@ -150,7 +153,7 @@ func TestPollsInsert(t *testing.T) {
 		}
 	}
 
-	_, err := testModels.Polls.CheckToken(token.Plaintext)
+	_, err := testModels.Polls.CheckToken(context.Background(), token.Plaintext)
 	if err != nil {
 		if errors.Is(err, ErrRecordNotFound) {
 			t.Errorf("token hash not inserted")
@@ -159,18 +162,18 @@ func TestPollsInsert(t *testing.T) {
 		}
 	}
 
-	if err = testModels.Polls.Delete(poll.ID); err != nil {
+	if err = testModels.Polls.Delete(context.Background(), poll.ID, nil); err != nil {
 		t.Errorf("delete poll returned an error: %s", err)
 	}
 }
 
 func TestPollsGet(t *testing.T) {
 	poll, token := createPollAndGenerateToken(t)
-	if err := testModels.Polls.Insert(poll, token.Hash); err != nil {
+	if err := testModels.Polls.Insert(context.Background(), poll, token.Hash, nil); err != nil {
 		t.Errorf("insert poll returned an error: %s", err)
 	}
 
-	p, err := testModels.Polls.Get(poll.ID)
+	p, err := testModels.Polls.Get(context.Background(), poll.ID)
 	if err != nil {
 		t.Errorf("get poll returned an error: %s", err)
 	}
@@ -179,31 +182,31 @@ func TestPollsGet(t *testing.T) {
 		t.Errorf("get poll returned wrong question: expected 'Test?' but got %s", poll.Question)
 	}
 
-	_, err = testModels.Polls.Get("badID")
+	_, err = testModels.Polls.Get(context.Background(), "badID")
 	if err == nil {
 		t.Errorf("expected error on bad id")
 	}
 
-	_, err = testModels.Polls.Get("")
+	_, err = testModels.Polls.Get(context.Background(), "")
 	if err == nil {
 		t.Errorf("expected error on empty string id")
 	}
 
-	_, err = testModels.Polls.Get(uuid.New().String())
+	_, err = testModels.Polls.Get(context.Background(), uuid.New().String())
 	if !errors.Is(err, ErrRecordNotFound) {
 		t.Errorf("expected error on non-existent poll")
 	}
 
-	if err = testModels.Polls.Delete(poll.ID); err != nil {
+	if err = testModels.Polls.Delete(context.Background(), poll.ID, nil); err != nil {
 		t.Errorf("delete poll returned an error: %s", err)
 	}
 }
 
 func TestPollsUpdate(t *testing.T) {
 	poll, token := createPollAndGenerateToken(t)
-	_ = testModels.Polls.Insert(poll, token.Hash)
+	_ = testModels.Polls.Insert(context.Background(), poll, token.Hash, nil)
 
-	p, _ := testModels.Polls.Get(poll.ID)
+	p, _ := testModels.Polls.Get(context.Background(), poll.ID)
 
 	oldUpdatedAt := poll.UpdatedAt
 
@@ -217,11 +220,11 @@ func TestPollsUpdate(t *testing.T) {
 
 	// sleep so updated_at can be changed
 	time.Sleep(1 * time.Second)
-	if err := testModels.Polls.Update(p); err != nil {
+	if err := testModels.Polls.Update(context.Background(), p, nil); err != nil {
 		t.Errorf("update poll returned an error: %s", err)
 	}
 
-	updatedPoll, _ := testModels.Polls.Get(p.ID)
+	updatedPoll, _ := testModels.Polls.Get(context.Background(), p.ID)
 
 	if updatedPoll.Question != newQuestion {
 		t.Errorf("expected question to be %s, but got %s", newQuestion, updatedPoll.Question)
@@ -236,34 +239,181 @@ func TestPollsUpdate(t *testing.T) {
 	if updatedPoll.UpdatedAt.Equal(oldUpdatedAt) {
 		t.Errorf("expected updated at to be changed")
 	}
-	_ = testModels.Polls.Delete(updatedPoll.ID)
+	_ = testModels.Polls.Delete(context.Background(), updatedPoll.ID, nil)
 }
 
 func TestPollsDelete(t *testing.T) {
 	poll, token := createPollAndGenerateToken(t)
-	_ = testModels.Polls.Insert(poll, token.Hash)
-	p, _ := testModels.Polls.Get(poll.ID)
+	_ = testModels.Polls.Insert(context.Background(), poll, token.Hash, nil)
+	p, _ := testModels.Polls.Get(context.Background(), poll.ID)
 
-	if err := testModels.Polls.Delete(uuid.New().String()); !errors.Is(err, ErrRecordNotFound) {
+	if err := testModels.Polls.Delete(context.Background(), uuid.New().String(), nil); !errors.Is(err, ErrRecordNotFound) {
 		t.Errorf("expected error on non-existent poll")
 	}
-	if err := testModels.Polls.Delete(""); !errors.Is(err, ErrRecordNotFound) {
+	if err := testModels.Polls.Delete(context.Background(), "", nil); !errors.Is(err, ErrRecordNotFound) {
 		t.Errorf("expected error on bad poll id")
 	}
 
-	if err := testModels.Polls.Delete(p.ID); err != nil {
+	if err := testModels.Polls.Delete(context.Background(), p.ID, nil); err != nil {
 		t.Errorf("delete poll returned an error: %s", err)
 	}
-	_, err := testModels.Polls.Get(p.ID)
+	_, err := testModels.Polls.Get(context.Background(), p.ID)
 	if !errors.Is(err, ErrRecordNotFound) {
 		t.Errorf("expected error on getting deleted poll")
 	}
 }
 
+func TestPollsSoftDelete(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(context.Background(), poll, token.Hash, nil)
+
+	if _, err := testModels.Polls.GetDeleted(context.Background(), poll.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected live poll not to be found as deleted")
+	}
+
+	deletedAt := time.Now()
+	if err := testModels.Polls.Delete(context.Background(), poll.ID, nil); err != nil {
+		t.Fatalf("delete poll returned an error: %s", err)
+	}
+	if err := testModels.Polls.Delete(context.Background(), poll.ID, nil); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected error on deleting a deleted poll")
+	}
+
+	polls, _, err := testModels.Polls.GetAll(context.Background(), poll.Question, Filters{Page: 1, PageSize: 20, Sort: "created_at", SortSafelist: []string{"created_at"}})
+	if err != nil {
+		t.Fatalf("get all returned an error: %s", err)
+	}
+	for _, p := range polls {
+		if p.ID == poll.ID {
+			t.Errorf("expected deleted poll to be left out of listings")
+		}
+	}
+
+	deleted, err := testModels.Polls.GetDeleted(context.Background(), poll.ID)
+	if err != nil {
+		t.Fatalf("get deleted returned an error: %s", err)
+	}
+	if deleted.DeletedAt == nil {
+		t.Fatalf("expected deleted_at to be set")
+	}
+
+	if err := testModels.Polls.Undelete(context.Background(), poll.ID, deletedAt.Add(time.Minute), nil); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected error on undeleting outside the grace period")
+	}
+	if err := testModels.Polls.Undelete(context.Background(), poll.ID, deletedAt.Add(-time.Minute), nil); err != nil {
+		t.Fatalf("undelete returned an error: %s", err)
+	}
+	if _, err := testModels.Polls.Get(context.Background(), poll.ID); err != nil {
+		t.Errorf("expected restored poll to be found, got %s", err)
+	}
+
+	if err := testModels.Polls.Delete(context.Background(), poll.ID, nil); err != nil {
+		t.Fatalf("delete poll returned an error: %s", err)
+	}
+	if _, err := testModels.Polls.Purge(context.Background(), deletedAt.Add(-time.Minute)); err != nil {
+		t.Fatalf("purge returned an error: %s", err)
+	}
+	if _, err := testModels.Polls.GetDeleted(context.Background(), poll.ID); err != nil {
+		t.Errorf("expected poll inside the grace period to survive a purge")
+	}
+	purged, err := testModels.Polls.Purge(context.Background(), time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("purge returned an error: %s", err)
+	}
+	if purged < 1 {
+		t.Errorf("expected at least one poll to be purged, got %d", purged)
+	}
+	if _, err := testModels.Polls.GetDeleted(context.Background(), poll.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected purged poll to be gone")
+	}
+}
+
+func TestPollsHide(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(context.Background(), poll, token.Hash, nil)
+	filters := Filters{Page: 1, PageSize: 20, Sort: "created_at", SortSafelist: []string{"created_at"}}
+
+	if err := testModels.Polls.Unhide(context.Background(), poll.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected error on unhiding a poll that isn't hidden")
+	}
+	if err := testModels.Polls.Hide(context.Background(), uuid.NewString()); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected error on hiding a non-existent poll")
+	}
+
+	if err := testModels.Polls.Hide(context.Background(), poll.ID); err != nil {
+		t.Fatalf("hide poll returned an error: %s", err)
+	}
+	if _, err := testModels.Polls.Get(context.Background(), poll.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected hidden poll not to be found")
+	}
+	if p, err := testModels.Polls.GetAny(context.Background(), poll.ID); err != nil || p.HiddenAt == nil {
+		t.Errorf("expected operators to find the hidden poll with hidden_at set, got %v", err)
+	}
+	polls, _, err := testModels.Polls.GetAll(context.Background(), poll.Question, filters)
+	if err != nil {
+		t.Fatalf("get all returned an error: %s", err)
+	}
+	for _, p := range polls {
+		if p.ID == poll.ID {
+			t.Errorf("expected hidden poll to be left out of listings")
+		}
+	}
+	polls, _, err = testModels.Polls.GetAllIncludingPrivate(context.Background(), poll.Question, filters)
+	if err != nil {
+		t.Fatalf("get all including private returned an error: %s", err)
+	}
+	found := false
+	for _, p := range polls {
+		if p.ID == poll.ID {
+			found = p.HiddenAt != nil
+		}
+	}
+	if !found {
+		t.Errorf("expected hidden poll to be listed for operators with hidden_at set")
+	}
+
+	// the owner deleting and restoring it doesn't undo the hide, and it
+	// survives a purge
+	if err := testModels.Polls.Delete(context.Background(), poll.ID, nil); err != nil {
+		t.Fatalf("delete poll returned an error: %s", err)
+	}
+	if err := testModels.Polls.Undelete(context.Background(), poll.ID, time.Now().Add(-time.Minute), nil); err != nil {
+		t.Fatalf("undelete returned an error: %s", err)
+	}
+	if _, err := testModels.Polls.Get(context.Background(), poll.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected restored poll to stay hidden")
+	}
+	if err := testModels.Polls.Delete(context.Background(), poll.ID, nil); err != nil {
+		t.Fatalf("delete poll returned an error: %s", err)
+	}
+	if _, err := testModels.Polls.Purge(context.Background(), time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("purge returned an error: %s", err)
+	}
+	if _, err := testModels.Polls.GetDeleted(context.Background(), poll.ID); err != nil {
+		t.Errorf("expected hidden poll to survive a purge")
+	}
+	if p, err := testModels.Polls.GetAny(context.Background(), poll.ID); err != nil || p.DeletedAt == nil {
+		t.Errorf("expected operators to find the deleted poll with deleted_at set, got %v", err)
+	}
+
+	if err := testModels.Polls.Unhide(context.Background(), poll.ID); err != nil {
+		t.Fatalf("unhide poll returned an error: %s", err)
+	}
+	if _, err := testModels.Polls.Get(context.Background(), poll.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected unhidden poll to stay deleted")
+	}
+	if err := testModels.Polls.Undelete(context.Background(), poll.ID, time.Now().Add(-time.Minute), nil); err != nil {
+		t.Fatalf("undelete returned an error: %s", err)
+	}
+	if _, err := testModels.Polls.Get(context.Background(), poll.ID); err != nil {
+		t.Errorf("expected poll to be found, got %s", err)
+	}
+}
+
 func TestPollOptionsInsert(t *testing.T) {
 	poll, token := createPollAndGenerateToken(t)
-	_ = testModels.Polls.Insert(poll, token.Hash)
-	p, _ := testModels.Polls.Get(poll.ID)
+	_ = testModels.Polls.Insert(context.Background(), poll, token.Hash, nil)
+	p, _ := testModels.Polls.Get(context.Background(), poll.ID)
 
 	oldUpdatedAt := p.UpdatedAt
 
@@ -275,11 +425,11 @@ func TestPollOptionsInsert(t *testing.T) {
 	}
 
 	time.Sleep(1 * time.Second)
-	if err := testModels.PollOptions.Insert(&option, p.ID); err != nil {
+	if err := testModels.PollOptions.Insert(context.Background(), &option, p.ID, nil); err != nil {
 		t.Errorf("add option returned an error: %s", err)
 	}
 
-	updatedPoll, _ := testModels.Polls.Get(p.ID)
+	updatedPoll, _ := testModels.Polls.Get(context.Background(), p.ID)
 
 	if len(updatedPoll.Options) != 4 {
 		t.Errorf("expected 4 options in poll, but got %d", len(updatedPoll.Options))
@@ -298,13 +448,13 @@ func TestPollOptionsInsert(t *testing.T) {
 	if updatedPoll.UpdatedAt.Equal(oldUpdatedAt) {
 		t.Errorf("expected poll updated at to be changed")
 	}
-	_ = testModels.Polls.Delete(updatedPoll.ID)
+	_ = testModels.Polls.Delete(context.Background(), updatedPoll.ID, nil)
 }
 
 func TestPollOptionsUpdateValue(t *testing.T) {
 	poll, token := createPollAndGenerateToken(t)
-	_ = testModels.Polls.Insert(poll, token.Hash)
-	p, _ := testModels.Polls.Get(poll.ID)
+	_ = testModels.Polls.Insert(context.Background(), poll, token.Hash, nil)
+	p, _ := testModels.Polls.Get(context.Background(), poll.ID)
 
 	newValue := "Test change value"
 
@@ -313,11 +463,11 @@ func TestPollOptionsUpdateValue(t *testing.T) {
 		Value: newValue,
 	}
 
-	if err := testModels.PollOptions.UpdateValue(&option); err != nil {
+	if err := testModels.PollOptions.UpdateValue(context.Background(), &option, nil); err != nil {
 		t.Errorf("update option value returned an error: %s", err)
 	}
 
-	updatedPoll, _ := testModels.Polls.Get(p.ID)
+	updatedPoll, _ := testModels.Polls.Get(context.Background(), p.ID)
 
 	match := false
 	for _, opt := range updatedPoll.Options {
@@ -330,24 +480,24 @@ func TestPollOptionsUpdateValue(t *testing.T) {
 		t.Errorf("option value not updated")
 	}
 
-	_ = testModels.Polls.Delete(updatedPoll.ID)
+	_ = testModels.Polls.Delete(context.Background(), updatedPoll.ID, nil)
 }
 
 func TestPollOptionsUpdatePosition(t *testing.T) {
 	poll, token := createPollAndGenerateToken(t)
-	_ = testModels.Polls.Insert(poll, token.Hash)
-	p, _ := testModels.Polls.Get(poll.ID)
+	_ = testModels.Polls.Insert(context.Background(), poll, token.Hash, nil)
+	p, _ := testModels.Polls.Get(context.Background(), poll.ID)
 
 	options := []*PollOption{
 		{ID: p.Options[2].ID, Position: 1},
 		{ID: p.Options[1].ID, Position: 2},
 	}
 
-	if err := testModels.PollOptions.UpdatePosition(options); err != nil {
+	if err := testModels.PollOptions.UpdatePosition(context.Background(), options, nil); err != nil {
 		t.Errorf("update option value returned an error: %s", err)
 	}
 
-	updatedPoll, _ := testModels.Polls.Get(p.ID)
+	updatedPoll, _ := testModels.Polls.Get(context.Background(), p.ID)
 
 	for _, opt := range updatedPoll.Options {
 		if opt.Value == "Three" {
@@ -369,42 +519,42 @@ func TestPollOptionsUpdatePosition(t *testing.T) {
 			}
 		}
 	}
-	_ = testModels.Polls.Delete(updatedPoll.ID)
+	_ = testModels.Polls.Delete(context.Background(), updatedPoll.ID, nil)
 }
 
 func TestPollOptionsDelete(t *testing.T) {
 	poll, token := createPollAndGenerateToken(t)
-	_ = testModels.Polls.Insert(poll, token.Hash)
-	p, _ := testModels.Polls.Get(poll.ID)
+	_ = testModels.Polls.Insert(context.Background(), poll, token.Hash, nil)
+	p, _ := testModels.Polls.Get(context.Background(), poll.ID)
 
-	if err := testModels.PollOptions.Delete(p.Options[2].ID); err != nil {
+	if err := testModels.PollOptions.Delete(context.Background(), p.Options[2].ID, nil); err != nil {
 		t.Errorf("delete option value returned an error: %s", err)
 	}
 
-	updatedPoll, _ := testModels.Polls.Get(p.ID)
+	updatedPoll, _ := testModels.Polls.Get(context.Background(), p.ID)
 
 	if len(updatedPoll.Options) != 2 {
 		t.Errorf("expected len of options to be 2 but got %d", len(poll.Options))
 	}
 
-	if err := testModels.PollOptions.Delete(uuid.New().String()); !errors.Is(err, ErrRecordNotFound) {
+	if err := testModels.PollOptions.Delete(context.Background(), uuid.New().String(), nil); !errors.Is(err, ErrRecordNotFound) {
 		t.Errorf("expected error on non-existent option")
 	}
 
-	_ = testModels.Polls.Delete(updatedPoll.ID)
+	_ = testModels.Polls.Delete(context.Background(), updatedPoll.ID, nil)
 }
 
 func TestPollOptionsVote(t *testing.T) {
 	poll, token := createPollAndGenerateToken(t)
-	_ = testModels.Polls.Insert(poll, token.Hash)
-	p, _ := testModels.Polls.Get(poll.ID)
+	_ = testModels.Polls.Insert(context.Background(), poll, token.Hash, nil)
+	p, _ := testModels.Polls.Get(context.Background(), poll.ID)
 
-	err := testModels.PollOptions.Vote(p.Options[0].ID, p.ID, "0.0.0.0")
+	err := testModels.PollOptions.Vote(context.Background(), p.Options[0].ID, p.ID, "0.0.0.0")
 	if err != nil {
 		t.Errorf("vote option returned an error: %s", err)
 	}
 
-	options, err := testModels.PollOptions.GetResults(p.ID)
+	options, err := testModels.PollOptions.GetResults(context.Background(), p.ID)
 	if err != nil {
 		t.Errorf("getting votes returned an error: %s", err)
 	}
@@ -418,17 +568,17 @@ func TestPollOptionsVote(t *testing.T) {
 		}
 	}
 
-	_ = testModels.PollOptions.Vote(p.Options[0].ID, p.ID, "0.0.0.0")
-	_ = testModels.PollOptions.Vote(p.Options[0].ID, p.ID, "0.0.0.0")
+	_ = testModels.PollOptions.Vote(context.Background(), p.Options[0].ID, p.ID, "0.0.0.0")
+	_ = testModels.PollOptions.Vote(context.Background(), p.Options[0].ID, p.ID, "0.0.0.0")
 
-	options, _ = testModels.PollOptions.GetResults(p.ID)
+	options, _ = testModels.PollOptions.GetResults(context.Background(), p.ID)
 	for _, opt := range options {
 		if opt.ID == p.Options[0].ID && opt.VoteCount != 3 {
 			t.Errorf("expected vote count to be 3, but got %d", opt.VoteCount)
 		}
 	}
 
-	if err := testModels.PollOptions.Vote(
+	if err := testModels.PollOptions.Vote(context.Background(),
 		uuid.New().String(),
 		p.ID,
 		"0.0.0.0",
@@ -437,30 +587,30 @@ func TestPollOptionsVote(t *testing.T) {
 	}
 
 	poll2, token := createPollAndGenerateToken(t)
-	_ = testModels.Polls.Insert(poll2, token.Hash)
-	p2, _ := testModels.Polls.Get(poll2.ID)
+	_ = testModels.Polls.Insert(context.Background(), poll2, token.Hash, nil)
+	p2, _ := testModels.Polls.Get(context.Background(), poll2.ID)
 
-	if err = testModels.PollOptions.Vote(
+	if err = testModels.PollOptions.Vote(context.Background(),
 		p.Options[0].ID,
 		p2.ID,
 		"0.0.0.0",
 	); !errors.Is(err, ErrRecordNotFound) {
 		t.Errorf("expected error on post and option id mismatch")
 	}
-	_ = testModels.Polls.Delete(p.ID)
-	_ = testModels.Polls.Delete(p2.ID)
+	_ = testModels.Polls.Delete(context.Background(), p.ID, nil)
+	_ = testModels.Polls.Delete(context.Background(), p2.ID, nil)
 }
 
 func TestPollGetVotedIPs(t *testing.T) {
 	poll, token := createPollAndGenerateToken(t)
-	_ = testModels.Polls.Insert(poll, token.Hash)
-	p, _ := testModels.Polls.Get(poll.ID)
+	_ = testModels.Polls.Insert(context.Background(), poll, token.Hash, nil)
+	p, _ := testModels.Polls.Get(context.Background(), poll.ID)
 
-	_ = testModels.PollOptions.Vote(p.Options[0].ID, p.ID, "0.0.0.1")
-	_ = testModels.PollOptions.Vote(p.Options[0].ID, p.ID, "0.0.0.2")
-	_ = testModels.PollOptions.Vote(p.Options[1].ID, p.ID, "0.0.0.3")
+	_ = testModels.PollOptions.Vote(context.Background(), p.Options[0].ID, p.ID, "0.0.0.1")
+	_ = testModels.PollOptions.Vote(context.Background(), p.Options[0].ID, p.ID, "0.0.0.2")
+	_ = testModels.PollOptions.Vote(context.Background(), p.Options[1].ID, p.ID, "0.0.0.3")
 
-	ips, err := testModels.Polls.GetVotedIPs(p.ID)
+	ips, err := testModels.Polls.GetVotedIPs(context.Background(), p.ID)
 	if err != nil {
 		t.Errorf("get ips returned an error: %s", err)
 	}
@@ -469,7 +619,7 @@ func TestPollGetVotedIPs(t *testing.T) {
 		t.Errorf("expected 3 ips to be stored, but got %d", len(ips))
 	}
 
-	ips, err = testModels.Polls.GetVotedIPs(uuid.New().String())
+	ips, err = testModels.Polls.GetVotedIPs(context.Background(), uuid.New().String())
 	if err != nil {
 		t.Errorf("get ips returned an error: %s", err)
 	}
@@ -478,10 +628,10 @@ func TestPollGetVotedIPs(t *testing.T) {
 	}
 
 	poll, token = createPollAndGenerateToken(t)
-	_ = testModels.Polls.Insert(poll, token.Hash)
-	p2, _ := testModels.Polls.Get(poll.ID)
+	_ = testModels.Polls.Insert(context.Background(), poll, token.Hash, nil)
+	p2, _ := testModels.Polls.Get(context.Background(), poll.ID)
 
-	ips, err = testModels.Polls.GetVotedIPs(p2.ID)
+	ips, err = testModels.Polls.GetVotedIPs(context.Background(), p2.ID)
 	if err != nil {
 		t.Errorf("get ips returned an error: %s", err)
 	}
@@ -489,19 +639,19 @@ func TestPollGetVotedIPs(t *testing.T) {
 		t.Errorf("expected empty slice if poll without votes, but got %s", ips)
 	}
 
-	_ = testModels.Polls.Delete(p.ID)
-	_ = testModels.Polls.Delete(p2.ID)
+	_ = testModels.Polls.Delete(context.Background(), p.ID, nil)
+	_ = testModels.Polls.Delete(context.Background(), p2.ID, nil)
 }
 
 func TestGetResults(t *testing.T) {
 	poll, token := createPollAndGenerateToken(t)
-	_ = testModels.Polls.Insert(poll, token.Hash)
-	p, _ := testModels.Polls.Get(poll.ID)
-	_ = testModels.PollOptions.Vote(p.Options[0].ID, p.ID, "0.0.0.0")
-	_ = testModels.PollOptions.Vote(p.Options[1].ID, p.ID, "0.0.0.0")
-	_ = testModels.PollOptions.Vote(p.Options[1].ID, p.ID, "0.0.0.0")
+	_ = testModels.Polls.Insert(context.Background(), poll, token.Hash, nil)
+	p, _ := testModels.Polls.Get(context.Background(), poll.ID)
+	_ = testModels.PollOptions.Vote(context.Background(), p.Options[0].ID, p.ID, "0.0.0.0")
+	_ = testModels.PollOptions.Vote(context.Background(), p.Options[1].ID, p.ID, "0.0.0.0")
+	_ = testModels.PollOptions.Vote(context.Background(), p.Options[1].ID, p.ID, "0.0.0.0")
 
-	options, err := testModels.PollOptions.GetResults(p.ID)
+	options, err := testModels.PollOptions.GetResults(context.Background(), p.ID)
 	if err != nil {
 		t.Errorf("getting votes returned an error: %s", err)
 	}
@@ -515,7 +665,7 @@ func TestGetResults(t *testing.T) {
 		}
 	}
 
-	options, err = testModels.PollOptions.GetResults(uuid.New().String())
+	options, err = testModels.PollOptions.GetResults(context.Background(), uuid.New().String())
 	if err != nil {
 		t.Errorf("getting votes returned an error: %s", err)
 	}
@@ -523,7 +673,7 @@ func TestGetResults(t *testing.T) {
 		t.Errorf("expected len of options to be 0, but got %d", len(options))
 	}
 
-	_ = testModels.Polls.Delete(p.ID)
+	_ = testModels.Polls.Delete(context.Background(), p.ID, nil)
 }
 
 func TestPollGetAll(t *testing.T) {
@@ -541,7 +691,7 @@ func TestPollGetAll(t *testing.T) {
 			{Value: fmt.Sprintf("Option three, poll %c", 96+i), Position: 2},
 		}
 		token, _ := GenerateToken()
-		if err := testModels.Polls.Insert(&poll, token.Hash); err != nil {
+		if err := testModels.Polls.Insert(context.Background(), &poll, token.Hash, nil); err != nil {
 			t.Fatalf("get all polls - insert poll returned an error: %s", err)
 		}
 	}
@@ -556,7 +706,7 @@ func TestPollGetAll(t *testing.T) {
 		IsPrivate: true,
 	}
 	token, _ := GenerateToken()
-	if err := testModels.Polls.Insert(&pollPrivate, token.Hash); err != nil {
+	if err := testModels.Polls.Insert(context.Background(), &pollPrivate, token.Hash, nil); err != nil {
 		t.Fatalf("get all polls - insert poll returned an error: %s", err)
 	}
 
@@ -667,7 +817,7 @@ func TestPollGetAll(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			polls, metadata, err := testModels.Polls.GetAll(test.search, Filters{
+			polls, metadata, err := testModels.Polls.GetAll(context.Background(), test.search, Filters{
 				Page:         test.page,
 				PageSize:     test.pageSize,
 				Sort:         test.sort,
@@ -755,7 +905,7 @@ func TestPollGetAll(t *testing.T) {
 	}
 
 	t.Run("private poll available with Get", func(t *testing.T) {
-		poll, err := testModels.Polls.Get(pollPrivate.ID)
+		poll, err := testModels.Polls.Get(context.Background(), pollPrivate.ID)
 		if err != nil {
 			t.Errorf("get private poll returned an error: %s", err)
 		}
@@ -764,3 +914,1123 @@ func TestPollGetAll(t *testing.T) {
 		}
 	})
 }
+
+func TestPollResponses(t *testing.T) {
+	poll := Poll{Question: "Test?", PollType: PollTypeOpenText}
+	token, _ := GenerateToken()
+	if err := testModels.Polls.Insert(context.Background(), &poll, token.Hash, nil); err != nil {
+		t.Fatalf("insert open text poll returned an error: %s", err)
+	}
+
+	p, err := testModels.Polls.Get(context.Background(), poll.ID)
+	if err != nil {
+		t.Fatalf("get open text poll without options returned an error: %s", err)
+	}
+	if len(p.Options) != 0 {
+		t.Errorf("expected no options, but got %d", len(p.Options))
+	}
+
+	if voted, _ := testModels.Polls.HasVotes(context.Background(), p.ID); voted {
+		t.Errorf("expected no votes before any response was submitted")
+	}
+
+	first := PollResponse{Value: "Pizza"}
+	second := PollResponse{Value: "pizza"}
+	if err := testModels.PollResponses.Insert(&first, p.ID, "0.0.0.1"); err != nil {
+		t.Errorf("insert response returned an error: %s", err)
+	}
+	_ = testModels.PollResponses.Insert(&second, p.ID, "0.0.0.2")
+
+	if voted, _ := testModels.Polls.HasVotes(context.Background(), p.ID); !voted {
+		t.Errorf("expected responses to count as votes")
+	}
+
+	invalid := PollResponse{Value: "Pasta"}
+	if err := testModels.PollResponses.Insert(&invalid, p.ID, "not an ip"); err == nil {
+		t.Errorf("expected error on invalid ip")
+	}
+
+	if first.ID == "" || first.Status != ResponseStatusPending {
+		t.Errorf("expected inserted response to have id and pending status")
+	}
+
+	ips, _ := testModels.Polls.GetVotedIPs(context.Background(), p.ID)
+	if len(ips) != 2 {
+		t.Errorf("expected 2 ips to be stored, but got %d", len(ips))
+	}
+
+	other, otherToken := createPollAndGenerateToken(t)
+	_ = testModels.Polls.Insert(context.Background(), other, otherToken.Hash, nil)
+
+	first.Status = ResponseStatusApproved
+	if err := testModels.PollResponses.UpdateStatus(&first, other.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected error on moderating a response of another poll")
+	}
+	if err := testModels.PollResponses.UpdateStatus(&first, p.ID); err != nil {
+		t.Errorf("update response status returned an error: %s", err)
+	}
+
+	if err := testModels.PollResponses.Merge(second.ID, first.ID, other.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected error on merging responses of another poll")
+	}
+	if err := testModels.PollResponses.Merge(second.ID, first.ID, p.ID); err != nil {
+		t.Errorf("merge response returned an error: %s", err)
+	}
+
+	approved, err := testModels.PollResponses.GetAll(p.ID, ResponseStatusApproved)
+	if err != nil {
+		t.Errorf("get responses returned an error: %s", err)
+	}
+	if len(approved) != 1 || approved[0].Count != 2 {
+		t.Errorf("expected one approved response with count 2, but got %v", approved)
+	}
+
+	merged, _ := testModels.PollResponses.Get(second.ID, p.ID)
+	if merged.Status != ResponseStatusMerged || merged.MergedInto != first.ID {
+		t.Errorf("expected response to be merged into %s", first.ID)
+	}
+
+	option := PollOption{Value: first.Value, Position: 0, VoteCount: approved[0].Count}
+	if err := testModels.PollResponses.Promote(approved[0], &option, other.ID, nil); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected error on promoting a response of another poll")
+	}
+	if err := testModels.PollResponses.Promote(approved[0], &option, p.ID, nil); err != nil {
+		t.Errorf("promote response returned an error: %s", err)
+	}
+
+	results, _ := testModels.PollOptions.GetResults(context.Background(), p.ID)
+	if len(results) != 1 || results[0].VoteCount != 2 {
+		t.Errorf("expected promoted option with 2 votes, but got %v", results)
+	}
+
+	if _, err := testModels.PollResponses.Get(uuid.NewString(), p.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected error on non-existent response")
+	}
+
+	_ = testModels.Polls.Delete(context.Background(), p.ID, nil)
+	_ = testModels.Polls.Delete(context.Background(), other.ID, nil)
+}
+
+func TestPollOptionsWriteIn(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	poll.AllowWriteIn = true
+	_ = testModels.Polls.Insert(context.Background(), poll, token.Hash, nil)
+	p, _ := testModels.Polls.Get(context.Background(), poll.ID)
+
+	if !p.AllowWriteIn {
+		t.Errorf("expected allow_write_in to be stored")
+	}
+
+	option := PollOption{Value: "Four"}
+	created, err := testModels.PollOptions.WriteIn(context.Background(), &option, p.ID, "0.0.0.1")
+	if err != nil {
+		t.Errorf("write-in returned an error: %s", err)
+	}
+	if !created || option.ID == "" || option.Position != len(p.Options) {
+		t.Errorf("expected write-in to be added at position %d, but got %+v", len(p.Options), option)
+	}
+
+	same := PollOption{Value: " fOUR  "}
+	created, err = testModels.PollOptions.WriteIn(context.Background(), &same, p.ID, "0.0.0.2")
+	if err != nil {
+		t.Errorf("write-in returned an error: %s", err)
+	}
+	if created || same.ID != option.ID || same.Value != "Four" {
+		t.Errorf("expected write-in to be counted for %s, but got %+v", option.ID, same)
+	}
+
+	options, _ := testModels.PollOptions.GetResults(context.Background(), p.ID)
+	match := false
+	for _, opt := range options {
+		if opt.ID == option.ID && opt.VoteCount == 2 {
+			match = true
+		}
+	}
+	if !match {
+		t.Errorf("expected write-in option to be stored with two votes")
+	}
+
+	ips, _ := testModels.Polls.GetVotedIPs(context.Background(), p.ID)
+	if len(ips) != 2 {
+		t.Errorf("expected 2 ips to be stored, but got %d", len(ips))
+	}
+
+	// write-ins racing each other add one option between them
+	var wg sync.WaitGroup
+	results := make([]PollOption, 2)
+	errs := make([]error, 2)
+	for i, value := range []string{"Red", " red"} {
+		wg.Add(1)
+		go func(i int, value string) {
+			defer wg.Done()
+			results[i].Value = value
+			_, errs[i] = testModels.PollOptions.WriteIn(context.Background(), &results[i], p.ID, fmt.Sprintf("0.0.1.%d", i))
+		}(i, value)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Errorf("concurrent write-in returned an error: %s", err)
+		}
+	}
+	if results[0].ID != results[1].ID || results[0].Position != len(p.Options)+1 {
+		t.Errorf("expected both write-ins to end up on one option, but got %+v", results)
+	}
+
+	dup := PollOption{Value: "RED", Position: 10}
+	if err := testModels.PollOptions.Insert(context.Background(), &dup, p.ID, nil); !errors.Is(err, ErrDuplicateOption) {
+		t.Errorf("expected ErrDuplicateOption on adding a matching option, but got %v", err)
+	}
+
+	if _, err := testModels.PollOptions.WriteIn(context.Background(), &PollOption{Value: "Five"}, uuid.NewString(), "0.0.0.3"); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected error on non-existent poll")
+	}
+
+	_ = testModels.Polls.Delete(context.Background(), p.ID, nil)
+}
+
+func TestQuizAnswers(t *testing.T) {
+	newUser := func(email string) *User {
+		user := User{Email: email}
+		_ = user.Password.Set("pa55word")
+		if err := testModels.Users.Insert(&user); err != nil {
+			t.Fatalf("insert user returned an error: %s", err)
+		}
+		return &user
+	}
+	quizmaster := newUser("quizmaster@example.com")
+	rival := newUser("rival@example.com")
+
+	var ids []string
+	var seriesID string
+	for i, expires := range []time.Time{
+		time.Now().Add(-1 * time.Hour),
+		time.Now().Add(-1 * time.Hour),
+		time.Now().Add(1 * time.Hour),
+	} {
+		poll := Poll{
+			Question:  fmt.Sprintf("Quiz %d?", i),
+			ExpiresAt: ExpiresAt{expires},
+			PollType:  PollTypeQuiz,
+			Series:    "db-test-trivia",
+			OwnerID:   quizmaster.ID,
+			Options: []*PollOption{
+				{Value: "Right", Position: 0, IsCorrect: true},
+				{Value: "Wrong", Position: 1},
+			},
+		}
+		token, _ := GenerateToken()
+		if err := testModels.Polls.Insert(context.Background(), &poll, token.Hash, nil); err != nil {
+			t.Fatalf("insert quiz returned an error: %s", err)
+		}
+		ids = append(ids, poll.ID)
+		if seriesID == "" {
+			seriesID = poll.SeriesID
+		}
+		if poll.SeriesID == "" || poll.SeriesID != seriesID {
+			t.Errorf("expected quizzes of one owner to share a series, but got %q and %q", seriesID, poll.SeriesID)
+		}
+
+		p, _ := testModels.Polls.Get(context.Background(), poll.ID)
+		if !p.Options[0].IsCorrect || p.Options[1].IsCorrect {
+			t.Errorf("expected is_correct to be stored with options")
+		}
+
+		// alice is always right, bob only on the first quiz
+		err := testModels.QuizAnswers.Vote(context.Background(), &QuizAnswer{
+			PollID: p.ID, OptionID: p.Options[0].ID, Voter: "alice", Correct: true,
+		}, "0.0.0.1", nil)
+		if err != nil {
+			t.Errorf("quiz vote returned an error: %s", err)
+		}
+		bob := p.Options[1]
+		if i == 0 {
+			bob = p.Options[0]
+		}
+		_ = testModels.QuizAnswers.Vote(context.Background(), &QuizAnswer{
+			PollID: p.ID, OptionID: bob.ID, Voter: "bob", Correct: bob.IsCorrect,
+		}, "0.0.0.2", nil)
+
+		results, _ := testModels.PollOptions.GetResults(context.Background(), p.ID)
+		if results[0].VoteCount+results[1].VoteCount != 2 {
+			t.Errorf("expected quiz answers to be counted as votes, but got %+v", results)
+		}
+
+		// postgres rejects NUL in text, so the answer fails after the vote was counted
+		err = testModels.QuizAnswers.Vote(context.Background(), &QuizAnswer{
+			PollID: p.ID, OptionID: p.Options[0].ID, Voter: "nul\x00", Correct: true,
+		}, "0.0.0.3", nil)
+		ips, _ := testModels.Polls.GetVotedIPs(context.Background(), p.ID)
+		if err == nil || len(ips) != 2 {
+			t.Errorf("expected a failed answer to roll back the vote, but got %v and %d ips", err, len(ips))
+		}
+	}
+
+	// someone else's quiz with the same series name doesn't count
+	other := Poll{
+		Question:  "Rigged?",
+		ExpiresAt: ExpiresAt{time.Now().Add(-1 * time.Hour)},
+		PollType:  PollTypeQuiz,
+		Series:    "db-test-trivia",
+		OwnerID:   rival.ID,
+		Options: []*PollOption{
+			{Value: "Right", Position: 0, IsCorrect: true},
+			{Value: "Wrong", Position: 1},
+		},
+	}
+	token, _ := GenerateToken()
+	if err := testModels.Polls.Insert(context.Background(), &other, token.Hash, nil); err != nil {
+		t.Fatalf("insert quiz returned an error: %s", err)
+	}
+	if other.SeriesID == seriesID {
+		t.Errorf("expected a series of another owner")
+	}
+	_ = testModels.QuizAnswers.Vote(context.Background(), &QuizAnswer{
+		PollID: other.ID, OptionID: other.Options[0].ID, Voter: "mallory", Correct: true,
+	}, "0.0.0.4", nil)
+
+	anonymous := Poll{Question: "Anonymous?", PollType: PollTypeQuiz, Series: "db-test-trivia"}
+	if err := testModels.Polls.Insert(context.Background(), &anonymous, token.Hash, nil); !errors.Is(err, ErrSeriesWithoutOwner) {
+		t.Errorf("expected ErrSeriesWithoutOwner, but got %v", err)
+	}
+
+	if _, _, err := testModels.QuizAnswers.Leaderboard(uuid.NewString()); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected error on non-existent series")
+	}
+
+	series, leaderboard, err := testModels.QuizAnswers.Leaderboard(seriesID)
+	if err != nil {
+		t.Errorf("get leaderboard returned an error: %s", err)
+	}
+	if series == nil || series.Name != "db-test-trivia" {
+		t.Errorf("expected series db-test-trivia, but got %+v", series)
+	}
+
+	if len(leaderboard) != 2 {
+		t.Fatalf("expected 2 voters on leaderboard, but got %d", len(leaderboard))
+	}
+	if leaderboard[0].Voter != "alice" || leaderboard[0].Score != 2 || leaderboard[0].Answered != 2 {
+		t.Errorf("expected alice to lead with 2 of 2 on closed quizzes, but got %+v", leaderboard[0])
+	}
+	if leaderboard[1].Voter != "bob" || leaderboard[1].Score != 1 {
+		t.Errorf("expected bob to have a score of 1, but got %+v", leaderboard[1])
+	}
+
+	p, _ := testModels.Polls.Get(context.Background(), ids[2])
+	if err := testModels.PollOptions.SetCorrect(context.Background(), p.ID, []string{p.Options[1].ID}, nil); err != nil {
+		t.Errorf("set correct returned an error: %s", err)
+	}
+	p, _ = testModels.Polls.Get(context.Background(), ids[2])
+	for _, opt := range p.Options {
+		if opt.IsCorrect != (opt.Value == "Wrong") {
+			t.Errorf("expected only the option marked by SetCorrect to be correct, got %+v", opt)
+		}
+	}
+
+	for _, id := range append(ids, other.ID) {
+		_ = testModels.Polls.Delete(context.Background(), id, nil)
+	}
+}
+
+func TestAvailability(t *testing.T) {
+	day := time.Date(2030, time.March, 4, 9, 0, 0, 0, time.UTC)
+	poll := Poll{
+		Question: "Meeting?",
+		PollType: PollTypeSchedule,
+		Options: []*PollOption{
+			{Value: "a", Position: 0, Slot: &TimeSlot{Start: day, End: day.Add(time.Hour), TimeZone: "Europe/Berlin"}},
+			{Value: "b", Position: 1, Slot: &TimeSlot{Start: day.Add(time.Hour), End: day.Add(2 * time.Hour), TimeZone: "Europe/Berlin"}},
+		},
+	}
+	token, _ := GenerateToken()
+	if err := testModels.Polls.Insert(context.Background(), &poll, token.Hash, nil); err != nil {
+		t.Fatalf("insert schedule poll returned an error: %s", err)
+	}
+
+	p, _ := testModels.Polls.Get(context.Background(), poll.ID)
+	if p.Options[0].Slot == nil || !p.Options[0].Slot.Start.Equal(day) {
+		t.Fatalf("expected time slot payload to be stored, but got %+v", p.Options[0].Slot)
+	}
+
+	ballots := []AvailabilityBallot{
+		{Voter: "alice", Answers: []AvailabilityAnswer{
+			{OptionID: p.Options[0].ID, Answer: AnswerNo},
+			{OptionID: p.Options[1].ID, Answer: AnswerYes},
+		}},
+		{Voter: "bob", Answers: []AvailabilityAnswer{
+			{OptionID: p.Options[0].ID, Answer: AnswerYes},
+			{OptionID: p.Options[1].ID, Answer: AnswerYes},
+		}},
+	}
+	for i, ballot := range ballots {
+		if err := testModels.Availability.Insert(&ballot, p.ID, fmt.Sprintf("0.0.0.%d", i)); err != nil {
+			t.Errorf("insert availability returned an error: %s", err)
+		}
+	}
+
+	tallies, err := testModels.Availability.GetResults(p.ID)
+	if err != nil {
+		t.Errorf("get availability returned an error: %s", err)
+	}
+	if len(tallies) != 2 || tallies[0].OptionID != p.Options[1].ID || tallies[0].Yes != 2 {
+		t.Errorf("expected second slot to rank first with 2 yes answers, but got %+v", tallies[0])
+	}
+	if tallies[1].Yes != 1 || tallies[1].No != 1 {
+		t.Errorf("expected first slot to have 1 yes and 1 no, but got %+v", tallies[1])
+	}
+
+	options, _ := testModels.PollOptions.GetResults(context.Background(), p.ID)
+	for _, opt := range options {
+		if opt.VoteCount != 2 {
+			t.Errorf("expected every answered slot to count 2 ballots, but got %d", opt.VoteCount)
+		}
+	}
+
+	_ = testModels.Polls.Delete(context.Background(), p.ID, nil)
+}
+
+func TestRatings(t *testing.T) {
+	poll := Poll{
+		Question: "Office?",
+		PollType: PollTypeMatrix,
+		Scale:    []string{"Disagree", "Neutral", "Agree"},
+		Options: []*PollOption{
+			{Value: "coffee", Position: 0},
+			{Value: "chairs", Position: 1},
+		},
+	}
+	token, _ := GenerateToken()
+	if err := testModels.Polls.Insert(context.Background(), &poll, token.Hash, nil); err != nil {
+		t.Fatalf("insert matrix poll returned an error: %s", err)
+	}
+
+	p, _ := testModels.Polls.Get(context.Background(), poll.ID)
+	if len(p.Scale) != 3 || p.Scale[2] != "Agree" {
+		t.Fatalf("expected scale to be stored, but got %v", p.Scale)
+	}
+
+	ballots := []RatingBallot{
+		{Ratings: []Rating{{OptionID: p.Options[0].ID, Value: 3}, {OptionID: p.Options[1].ID, Value: 1}}},
+		{Ratings: []Rating{{OptionID: p.Options[0].ID, Value: 3}, {OptionID: p.Options[1].ID, Value: 2}}},
+	}
+	for i, ballot := range ballots {
+		if err := testModels.Ratings.Insert(&ballot, p.ID, fmt.Sprintf("0.0.0.%d", i)); err != nil {
+			t.Errorf("insert ratings returned an error: %s", err)
+		}
+	}
+
+	rows, err := testModels.Ratings.GetDistributions(p.ID, len(p.Scale))
+	if err != nil {
+		t.Errorf("get distributions returned an error: %s", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, but got %d", len(rows))
+	}
+	if rows[0].OptionID != p.Options[0].ID || rows[0].Counts[2] != 2 {
+		t.Errorf("expected first row to have 2 ratings of 3, but got %+v", rows[0])
+	}
+	if rows[1].Counts[0] != 1 || rows[1].Counts[1] != 1 {
+		t.Errorf("expected second row to have one rating of 1 and 2, but got %+v", rows[1])
+	}
+
+	_ = testModels.Polls.Delete(context.Background(), p.ID, nil)
+}
+
+func TestUsers(t *testing.T) {
+	user := User{Email: "owner@example.com", Name: "Owner"}
+	if err := user.Password.Set("pa55word"); err != nil {
+		t.Fatalf("set password returned an error: %s", err)
+	}
+	if err := testModels.Users.Insert(&user); err != nil {
+		t.Fatalf("insert user returned an error: %s", err)
+	}
+
+	duplicate := User{Email: "owner@example.com", Password: user.Password}
+	if err := testModels.Users.Insert(&duplicate); !errors.Is(err, ErrDuplicateEmail) {
+		t.Errorf("expected ErrDuplicateEmail, but got %v", err)
+	}
+
+	u, err := testModels.Users.GetByEmail(user.Email)
+	if err != nil {
+		t.Fatalf("get user by email returned an error: %s", err)
+	}
+	if match, _ := u.Password.Matches("pa55word"); !match {
+		t.Errorf("expected stored password to match")
+	}
+
+	session, err := testModels.Users.NewSession(user.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("new session returned an error: %s", err)
+	}
+	if u, err := testModels.Users.GetForSession(session.Plaintext); err != nil || u.ID != user.ID {
+		t.Errorf("expected session to belong to user %s, but got %v, %v", user.ID, u, err)
+	}
+
+	expired, _ := testModels.Users.NewSession(user.ID, -time.Hour)
+	if _, err := testModels.Users.GetForSession(expired.Plaintext); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected expired session to be rejected, but got %v", err)
+	}
+
+	owned := Poll{
+		Question:  "Owned?",
+		OwnerID:   user.ID,
+		IsPrivate: true,
+		Options: []*PollOption{
+			{Value: "a", Position: 0},
+			{Value: "b", Position: 1},
+		},
+	}
+	anonymous := Poll{
+		Question: "Anonymous?",
+		Options: []*PollOption{
+			{Value: "a", Position: 0},
+			{Value: "b", Position: 1},
+		},
+	}
+	for _, poll := range []*Poll{&owned, &anonymous} {
+		token, _ := GenerateToken()
+		if err := testModels.Polls.Insert(context.Background(), poll, token.Hash, nil); err != nil {
+			t.Fatalf("insert poll returned an error: %s", err)
+		}
+	}
+
+	p, _ := testModels.Polls.Get(context.Background(), owned.ID)
+	if p.OwnerID != user.ID {
+		t.Errorf("expected owner %s, but got %q", user.ID, p.OwnerID)
+	}
+	p, _ = testModels.Polls.Get(context.Background(), anonymous.ID)
+	if p.OwnerID != "" {
+		t.Errorf("expected anonymous poll to have no owner, but got %q", p.OwnerID)
+	}
+
+	filters := Filters{Page: 1, PageSize: 20, Sort: "-created_at", SortSafelist: []string{"-created_at"}}
+	polls, metadata, err := testModels.Polls.GetAllForOwner(context.Background(), user.ID, filters)
+	if err != nil {
+		t.Errorf("get polls for owner returned an error: %s", err)
+	}
+	if len(polls) != 1 || polls[0].ID != owned.ID || !polls[0].IsPrivate || metadata.TotalRecords != 1 {
+		t.Errorf("expected only the private owned poll, but got %d polls", len(polls))
+	}
+
+	if err := testModels.Users.DeleteSession(session.Plaintext); err != nil {
+		t.Errorf("delete session returned an error: %s", err)
+	}
+	if _, err := testModels.Users.GetForSession(session.Plaintext); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected deleted session to be rejected, but got %v", err)
+	}
+
+	_ = testModels.Polls.Delete(context.Background(), owned.ID, nil)
+	_ = testModels.Polls.Delete(context.Background(), anonymous.ID, nil)
+}
+
+func TestUsersOIDC(t *testing.T) {
+	issuer := "https://sso.example.com"
+	user := User{Email: "staff@example.com", Name: "Staff", Issuer: issuer, Subject: "staff-1"}
+	if err := testModels.Users.UpsertOIDC(&user); err != nil {
+		t.Fatalf("upsert oidc user returned an error: %s", err)
+	}
+
+	squatter := User{Email: "staff@corp.example.com"}
+	if err := squatter.Password.Set("pa55word"); err != nil {
+		t.Fatalf("set password returned an error: %s", err)
+	}
+	if err := testModels.Users.Insert(&squatter); err != nil {
+		t.Fatalf("insert user returned an error: %s", err)
+	}
+
+	again := User{Email: "staff@corp.example.com", Name: "Renamed", Issuer: issuer, Subject: "staff-1"}
+	if err := testModels.Users.UpsertOIDC(&again); err != nil {
+		t.Fatalf("upsert oidc user returned an error: %s", err)
+	}
+	if again.ID != user.ID {
+		t.Errorf("expected the same user on a second login, but got %s and %s", user.ID, again.ID)
+	}
+
+	other := User{Email: "staff@corp.example.com", Issuer: issuer, Subject: "staff-2"}
+	if err := testModels.Users.UpsertOIDC(&other); !errors.Is(err, ErrDuplicateEmail) {
+		t.Errorf("expected ErrDuplicateEmail, but got %v", err)
+	}
+
+	if u, err := testModels.Users.GetByEmail("staff@corp.example.com"); err != nil || u.ID != squatter.ID {
+		t.Errorf("expected password sign in to find only the password account, but got %v, %v", u, err)
+	}
+
+	u, err := testModels.Users.GetByVerifiedEmail("staff@corp.example.com")
+	if err != nil {
+		t.Fatalf("get user by verified email returned an error: %s", err)
+	}
+	if u.ID != user.ID || u.Name != "Renamed" || u.Issuer != issuer {
+		t.Errorf("expected name and issuer to follow the provider, but got %q, %q", u.Name, u.Issuer)
+	}
+	if match, err := u.Password.Matches(""); match || err != nil {
+		t.Errorf("expected users without a password not to match, but got %v, %v", match, err)
+	}
+
+	session, _ := testModels.Users.NewSession(user.ID, time.Hour)
+	if u, err := testModels.Users.GetForSession(session.Plaintext); err != nil || u.Issuer != issuer {
+		t.Errorf("expected session user from %s, but got %v, %v", issuer, u, err)
+	}
+
+	poll := Poll{
+		Question:         "Staff only?",
+		VoterIssuer:      issuer,
+		VoterEmailDomain: "example.com",
+		Options: []*PollOption{
+			{Value: "a", Position: 0},
+			{Value: "b", Position: 1},
+		},
+	}
+	if err := testModels.Polls.Insert(context.Background(), &poll, nil, nil); err != nil {
+		t.Fatalf("insert poll returned an error: %s", err)
+	}
+	p, _ := testModels.Polls.Get(context.Background(), poll.ID)
+	if p.VoterIssuer != issuer || p.VoterEmailDomain != "example.com" {
+		t.Errorf("expected voter restrictions to be stored, but got %q, %q", p.VoterIssuer, p.VoterEmailDomain)
+	}
+
+	_ = testModels.Polls.Delete(context.Background(), poll.ID, nil)
+}
+
+func TestVoterAuth(t *testing.T) {
+	poll := Poll{
+		Question:      "Accountable?",
+		VoterAuth:     true,
+		PublicBallots: true,
+		Options: []*PollOption{
+			{Value: "a", Position: 0},
+			{Value: "b", Position: 1},
+		},
+	}
+	if err := testModels.Polls.Insert(context.Background(), &poll, nil, nil); err != nil {
+		t.Fatalf("insert poll returned an error: %s", err)
+	}
+	p, _ := testModels.Polls.Get(context.Background(), poll.ID)
+	if !p.VoterAuth || !p.PublicBallots {
+		t.Errorf("expected voter_auth and public_ballots to be stored")
+	}
+
+	credential := VoterCredential{Label: "Erin"}
+	token, _ := GenerateToken()
+	if err := testModels.Voters.InsertCredential(&credential, poll.ID, token.Hash); err != nil {
+		t.Fatalf("insert credential returned an error: %s", err)
+	}
+	got, err := testModels.Voters.GetForCredential(poll.ID, token.Plaintext)
+	if err != nil || got.ID != credential.ID {
+		t.Fatalf("expected credential %s, but got %v, %v", credential.ID, got, err)
+	}
+	if _, err := testModels.Voters.GetForCredential(uuid.NewString(), token.Plaintext); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected credential to only work on its poll, but got %v", err)
+	}
+
+	voter := CredentialVoter(got)
+	optionID := poll.Options[0].ID
+	if err := testModels.PollOptions.VoteAs(context.Background(), optionID, poll.ID, "10.0.0.1", voter); err != nil {
+		t.Fatalf("vote returned an error: %s", err)
+	}
+	// a different IP doesn't make it a different voter
+	if err := testModels.PollOptions.VoteAs(context.Background(), optionID, poll.ID, "10.0.0.2", voter); !errors.Is(err, ErrAlreadyVoted) {
+		t.Errorf("expected ErrAlreadyVoted, but got %v", err)
+	}
+	// the same IP doesn't either
+	other := &Voter{ID: "user:" + uuid.NewString(), Name: "Grace"}
+	if err := testModels.PollOptions.VoteAs(context.Background(), poll.Options[1].ID, poll.ID, "10.0.0.1", other); err != nil {
+		t.Errorf("vote returned an error: %s", err)
+	}
+
+	results, _ := testModels.PollOptions.GetResults(context.Background(), poll.ID)
+	for _, opt := range results {
+		if opt.VoteCount != 1 {
+			t.Errorf("expected one vote on %q, but got %d", opt.Value, opt.VoteCount)
+		}
+	}
+
+	ballots, err := testModels.Voters.GetBallots(poll.ID)
+	if err != nil {
+		t.Fatalf("get ballots returned an error: %s", err)
+	}
+	if len(ballots) != 2 || ballots[0].Voter != "Erin" || ballots[0].OptionID != optionID {
+		t.Errorf("expected Erin's ballot first, but got %+v", ballots)
+	}
+
+	if err := testModels.Voters.DeleteCredential(credential.ID, poll.ID); err != nil {
+		t.Errorf("delete credential returned an error: %s", err)
+	}
+	if credentials, _ := testModels.Voters.GetCredentials(poll.ID); len(credentials) != 0 {
+		t.Errorf("expected no credentials, but got %d", len(credentials))
+	}
+	if ballots, _ := testModels.Voters.GetBallots(poll.ID); len(ballots) != 2 {
+		t.Errorf("expected ballots to outlive their credential")
+	}
+
+	_ = testModels.Polls.Delete(context.Background(), poll.ID, nil)
+}
+
+func TestOrganizations(t *testing.T) {
+	newUser := func(email string) *User {
+		user := User{Email: email}
+		_ = user.Password.Set("pa55word")
+		if err := testModels.Users.Insert(&user); err != nil {
+			t.Fatalf("insert user returned an error: %s", err)
+		}
+		return &user
+	}
+	owner := newUser("org-owner@example.com")
+	viewer := newUser("org-viewer@example.com")
+
+	org := Organization{Name: "Marketing"}
+	if err := testModels.Organizations.Insert(&org, owner.ID); err != nil {
+		t.Fatalf("insert organization returned an error: %s", err)
+	}
+	if role, err := testModels.Organizations.GetRole(org.ID, owner.ID); err != nil || role != OrgRoleOwner {
+		t.Errorf("expected creator to be owner, but got %q, %v", role, err)
+	}
+
+	if err := testModels.Organizations.SetMember(org.ID, viewer.ID, OrgRoleViewer); err != nil {
+		t.Fatalf("set member returned an error: %s", err)
+	}
+	if orgs, _ := testModels.Organizations.GetAllForUser(viewer.ID); len(orgs) != 1 || orgs[0].Role != OrgRoleViewer {
+		t.Errorf("expected viewer to see the organization, but got %+v", orgs)
+	}
+	if members, _ := testModels.Organizations.GetMembers(org.ID); len(members) != 2 {
+		t.Errorf("expected 2 members, but got %d", len(members))
+	}
+
+	if err := testModels.Organizations.SetMember(org.ID, owner.ID, OrgRoleEditor); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("expected ErrLastOwner, but got %v", err)
+	}
+	if err := testModels.Organizations.RemoveMember(org.ID, owner.ID); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("expected ErrLastOwner, but got %v", err)
+	}
+	if role, _ := testModels.Organizations.GetRole(org.ID, owner.ID); role != OrgRoleOwner {
+		t.Errorf("expected owner to keep their role, but got %q", role)
+	}
+
+	poll := Poll{Question: "Team offsite?", IsPrivate: true, OrgID: org.ID, OwnerID: owner.ID}
+	if err := testModels.Polls.Insert(context.Background(), &poll, nil, nil); err != nil {
+		t.Fatalf("insert poll returned an error: %s", err)
+	}
+	if p, _ := testModels.Polls.Get(context.Background(), poll.ID); p.OrgID != org.ID {
+		t.Errorf("expected org_id %s, but got %q", org.ID, p.OrgID)
+	}
+
+	filters := Filters{Page: 1, PageSize: 20, Sort: "-created_at", SortSafelist: []string{"-created_at"}}
+	polls, _, err := testModels.Polls.GetAllForOrg(context.Background(), org.ID, "offsite", filters)
+	if err != nil {
+		t.Fatalf("get all for org returned an error: %s", err)
+	}
+	if len(polls) != 1 || polls[0].ID != poll.ID {
+		t.Errorf("expected the private poll in the org listing, but got %+v", polls)
+	}
+	if polls, _, _ := testModels.Polls.GetAllForOrg(context.Background(), org.ID, "budget", filters); len(polls) != 0 {
+		t.Errorf("expected search to filter org polls, but got %d", len(polls))
+	}
+	if polls, _, _ := testModels.Polls.GetAll(context.Background(), "offsite", filters); len(polls) != 0 {
+		t.Errorf("expected private org poll to be left out of the public listing")
+	}
+
+	if err := testModels.Organizations.RemoveMember(org.ID, viewer.ID); err != nil {
+		t.Errorf("remove member returned an error: %s", err)
+	}
+	if _, err := testModels.Organizations.GetRole(org.ID, viewer.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound, but got %v", err)
+	}
+
+	_ = testModels.Polls.Delete(context.Background(), poll.ID, nil)
+}
+
+func TestRevisions(t *testing.T) {
+	poll := Poll{
+		Question: "Lunch?",
+		Options: []*PollOption{
+			{Value: "Pizza", Position: 0},
+			{Value: "Sushi", Position: 1},
+			{Value: "Tacos", Position: 2},
+		},
+	}
+	first := NewRevision(RevisionCreate, "anonymous", nil)
+	if err := testModels.Polls.Insert(context.Background(), &poll, nil, first); err != nil {
+		t.Fatalf("insert poll returned an error: %s", err)
+	}
+	if first.ID == "" || len(first.Changes) != 4 {
+		t.Errorf("expected the create revision with question and options, but got %+v", first)
+	}
+
+	created := NewPollSnapshot(&poll)
+	removed := poll.Options[0].ID
+	poll.Question = "Dinner?"
+	second := NewRevision(RevisionUpdatePoll, "user:"+uuid.NewString(), created)
+	if err := testModels.Polls.Update(context.Background(), &poll, second); err != nil {
+		t.Fatalf("update poll returned an error: %s", err)
+	}
+	third := NewRevision(RevisionDeleteOption, "anonymous", second.Snapshot)
+	if err := testModels.PollOptions.Delete(context.Background(), removed, third); err != nil {
+		t.Fatalf("delete option returned an error: %s", err)
+	}
+	if len(third.Changes) != 3 || third.Changes[2].Field != "options."+removed || third.Changes[2].After != nil {
+		t.Errorf("expected moved options and the removed option in the diff, but got %+v", third.Changes)
+	}
+
+	failed := NewRevision(RevisionAddOption, "anonymous", third.Snapshot)
+	err := testModels.PollOptions.Insert(context.Background(), &PollOption{Value: " sushi", Position: 2}, poll.ID, failed)
+	if !errors.Is(err, ErrDuplicateOption) {
+		t.Errorf("expected ErrDuplicateOption, but got %v", err)
+	}
+
+	revisions, err := testModels.Revisions.GetAllForPoll(poll.ID)
+	if err != nil {
+		t.Fatalf("get revisions returned an error: %s", err)
+	}
+	if len(revisions) != 3 || revisions[0].ID != third.ID || revisions[1].ID != second.ID {
+		t.Fatalf("expected newest revision first and none for the failed change, but got %+v", revisions)
+	}
+	if revisions[1].Changes[0].Before != "Lunch?" || revisions[1].Changes[0].After != "Dinner?" {
+		t.Errorf("expected question change to round trip, but got %+v", revisions[1].Changes[0])
+	}
+
+	revision, err := testModels.Revisions.Get(first.ID, poll.ID)
+	if err != nil {
+		t.Fatalf("get revision returned an error: %s", err)
+	}
+	if _, err := testModels.Revisions.Get(first.ID, uuid.NewString()); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected revision to only be found on its poll, but got %v", err)
+	}
+
+	edited, _ := testModels.Polls.Get(context.Background(), poll.ID)
+	revision.Snapshot.Apply(edited)
+	if err := testModels.Polls.Restore(context.Background(), edited, nil); err != nil {
+		t.Fatalf("restore returned an error: %s", err)
+	}
+	restored, _ := testModels.Polls.Get(context.Background(), poll.ID)
+	if restored.Question != "Lunch?" || len(restored.Options) != 3 {
+		t.Fatalf("expected the poll as created, but got %q with %d options", restored.Question, len(restored.Options))
+	}
+	if changes := DiffSnapshots(created, NewPollSnapshot(restored)); len(changes) != 0 {
+		t.Errorf("expected no differences after restore, but got %+v", changes)
+	}
+
+	deleted := NewRevision(RevisionDelete, "anonymous", nil)
+	if err := testModels.Polls.Delete(context.Background(), poll.ID, deleted); err != nil {
+		t.Fatalf("delete poll returned an error: %s", err)
+	}
+	undeleted := NewRevision(RevisionUndelete, "anonymous", nil)
+	if err := testModels.Polls.Undelete(context.Background(), poll.ID, time.Now().Add(-time.Hour), undeleted); err != nil {
+		t.Fatalf("undelete poll returned an error: %s", err)
+	}
+	if deleted.ID == "" || deleted.Changes[0].Field != "deleted" || undeleted.Changes[0].After != false {
+		t.Errorf("expected delete and undelete to be recorded, but got %+v and %+v", deleted, undeleted)
+	}
+	if len(deleted.Snapshot.Options) != 3 {
+		t.Errorf("expected the deleted poll in the snapshot, but got %+v", deleted.Snapshot)
+	}
+
+	_ = testModels.Polls.Delete(context.Background(), poll.ID, nil)
+}
+
+func TestTokens(t *testing.T) {
+	poll, ownerToken := createPollAndGenerateToken(t)
+	if err := testModels.Polls.Insert(context.Background(), poll, ownerToken.Hash, nil); err != nil {
+		t.Fatalf("insert poll returned an error: %s", err)
+	}
+
+	owner, err := testModels.Polls.CheckToken(context.Background(), ownerToken.Plaintext)
+	if err != nil {
+		t.Fatalf("check token returned an error: %s", err)
+	}
+	if owner.PollID != poll.ID || len(owner.Scopes) != len(AllScopes) {
+		t.Errorf("expected poll token to have every scope, but got %v", owner.Scopes)
+	}
+	if owner.LastUsedAt == nil {
+		t.Errorf("expected last_used_at to be set")
+	}
+
+	expiry := time.Now().Add(time.Hour)
+	scoped := PollToken{PollID: poll.ID, Label: "dashboard", Scopes: []string{ScopeResultsRead}, Expiry: &expiry}
+	scopedToken, _ := GenerateToken()
+	if err := testModels.Tokens.Insert(&scoped, scopedToken.Hash); err != nil {
+		t.Fatalf("insert token returned an error: %s", err)
+	}
+
+	expired := time.Now().Add(-time.Hour)
+	old := PollToken{PollID: poll.ID, Scopes: []string{ScopePollEdit}, Expiry: &expired}
+	oldToken, _ := GenerateToken()
+	if err := testModels.Tokens.Insert(&old, oldToken.Hash); err != nil {
+		t.Fatalf("insert token returned an error: %s", err)
+	}
+
+	checked, err := testModels.Polls.CheckToken(context.Background(), scopedToken.Plaintext)
+	if err != nil {
+		t.Fatalf("check token returned an error: %s", err)
+	}
+	if !checked.HasScope(ScopeResultsRead) || checked.HasScope(ScopePollEdit) {
+		t.Errorf("expected only results:read scope, but got %v", checked.Scopes)
+	}
+	if _, err := testModels.Polls.CheckToken(context.Background(), oldToken.Plaintext); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected expired token to be rejected, but got %v", err)
+	}
+
+	tokens, err := testModels.Tokens.GetAllForPoll(poll.ID)
+	if err != nil {
+		t.Errorf("get tokens returned an error: %s", err)
+	}
+	if len(tokens) != 2 {
+		t.Errorf("expected 2 unexpired tokens, but got %d", len(tokens))
+	}
+
+	if err := testModels.Tokens.Delete(scoped.ID, poll.ID); err != nil {
+		t.Errorf("delete token returned an error: %s", err)
+	}
+	if _, err := testModels.Polls.CheckToken(context.Background(), scopedToken.Plaintext); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected revoked token to be rejected, but got %v", err)
+	}
+	if err := testModels.Tokens.Delete(scoped.ID, poll.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound, but got %v", err)
+	}
+
+	_ = testModels.Polls.Delete(context.Background(), poll.ID, nil)
+}
+
+func TestPollsGetTurnout(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	if err := testModels.Polls.Insert(context.Background(), poll, token.Hash, nil); err != nil {
+		t.Fatalf("insert poll returned an error: %s", err)
+	}
+
+	turnout, err := testModels.Polls.GetTurnout(context.Background(), poll.ID)
+	if err != nil {
+		t.Fatalf("get turnout returned an error: %s", err)
+	}
+	if turnout.Ballots != 0 || turnout.LastVoteAt != nil {
+		t.Errorf("expected empty turnout, but got %+v", turnout)
+	}
+
+	for i, ip := range []string{"0.0.0.1", "0.0.0.2"} {
+		if err := testModels.PollOptions.Vote(context.Background(), poll.Options[i].ID, poll.ID, ip); err != nil {
+			t.Errorf("vote returned an error: %s", err)
+		}
+	}
+
+	turnout, err = testModels.Polls.GetTurnout(context.Background(), poll.ID)
+	if err != nil {
+		t.Fatalf("get turnout returned an error: %s", err)
+	}
+	if turnout.Ballots != 2 || turnout.DistinctIPs != 2 || turnout.LastVoteAt == nil {
+		t.Errorf("expected 2 ballots from 2 ips, but got %+v", turnout)
+	}
+
+	_ = testModels.Polls.Delete(context.Background(), poll.ID, nil)
+}
+
+func TestPollsErase(t *testing.T) {
+	for _, deleted := range []bool{false, true} {
+		poll, token := createPollAndGenerateToken(t)
+		_ = testModels.Polls.Insert(context.Background(), poll, token.Hash, nil)
+		if deleted {
+			_ = testModels.Polls.Delete(context.Background(), poll.ID, nil)
+		}
+
+		if err := testModels.Polls.Erase(context.Background(), poll.ID); err != nil {
+			t.Fatalf("erase returned an error: %s", err)
+		}
+		if _, err := testModels.Polls.GetDeleted(context.Background(), poll.ID); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("expected erased poll to be gone, but got %v", err)
+		}
+		if err := testModels.Polls.Erase(context.Background(), poll.ID); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("expected ErrRecordNotFound, but got %v", err)
+		}
+	}
+}
+
+func TestTokensRotateOwner(t *testing.T) {
+	poll, ownerToken := createPollAndGenerateToken(t)
+	if err := testModels.Polls.Insert(context.Background(), poll, ownerToken.Hash, nil); err != nil {
+		t.Fatalf("insert poll returned an error: %s", err)
+	}
+
+	scoped := PollToken{PollID: poll.ID, Label: "dashboard", Scopes: []string{ScopeResultsRead}}
+	scopedToken, _ := GenerateToken()
+	if err := testModels.Tokens.Insert(&scoped, scopedToken.Hash); err != nil {
+		t.Fatalf("insert token returned an error: %s", err)
+	}
+
+	rotated := PollToken{PollID: poll.ID}
+	newToken, _ := GenerateToken()
+	revoked, err := testModels.Tokens.RotateOwner(&rotated, newToken.Hash)
+	if err != nil {
+		t.Fatalf("rotate owner token returned an error: %s", err)
+	}
+	if revoked != 1 {
+		t.Errorf("expected 1 owner token to be revoked, but got %d", revoked)
+	}
+
+	if _, err := testModels.Polls.CheckToken(context.Background(), ownerToken.Plaintext); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected old owner token to be rejected, but got %v", err)
+	}
+	checked, err := testModels.Polls.CheckToken(context.Background(), newToken.Plaintext)
+	if err != nil {
+		t.Fatalf("check token returned an error: %s", err)
+	}
+	if checked.PollID != poll.ID || len(checked.Scopes) != len(AllScopes) {
+		t.Errorf("expected new owner token to have every scope, but got %v", checked.Scopes)
+	}
+	if _, err := testModels.Polls.CheckToken(context.Background(), scopedToken.Plaintext); err != nil {
+		t.Errorf("expected scoped token to be kept, but got %v", err)
+	}
+
+	_ = testModels.Polls.Delete(context.Background(), poll.ID, nil)
+}
+
+func TestPollsOperatorQueries(t *testing.T) {
+	poll, token := createPollAndGenerateToken(t)
+	poll.Question = "Operator private poll?"
+	poll.IsPrivate = true
+	if err := testModels.Polls.Insert(context.Background(), poll, token.Hash, nil); err != nil {
+		t.Fatalf("insert poll returned an error: %s", err)
+	}
+
+	filters := Filters{Page: 1, PageSize: 20, Sort: "created_at", SortSafelist: []string{"created_at"}}
+	polls, _, err := testModels.Polls.GetAllIncludingPrivate(context.Background(), "operator", filters)
+	if err != nil {
+		t.Fatalf("get all including private returned an error: %s", err)
+	}
+	if len(polls) != 1 || polls[0].ID != poll.ID {
+		t.Errorf("expected the private poll to be listed, but got %d polls", len(polls))
+	}
+
+	for i, ip := range []string{"0.0.0.1", "0.0.0.2"} {
+		if err := testModels.PollOptions.Vote(context.Background(), poll.Options[i].ID, poll.ID, ip); err != nil {
+			t.Errorf("vote returned an error: %s", err)
+		}
+	}
+
+	stats, err := testModels.Polls.GetStats(context.Background())
+	if err != nil {
+		t.Fatalf("get stats returned an error: %s", err)
+	}
+	if stats.Polls < 1 || stats.PrivatePolls < 1 || stats.OpenPolls < 1 || stats.VotesLastDay < 2 {
+		t.Errorf("expected stats to count the poll and its votes, but got %+v", stats)
+	}
+
+	purged, err := testModels.Polls.PurgeIPs(context.Background(), poll.ID)
+	if err != nil {
+		t.Fatalf("purge ips returned an error: %s", err)
+	}
+	if purged != 2 {
+		t.Errorf("expected 2 ips to be purged, but got %d", purged)
+	}
+	if err := testModels.PollOptions.Vote(context.Background(), poll.Options[0].ID, poll.ID, "0.0.0.1"); err != nil {
+		t.Errorf("expected purged ip to vote again, but got %s", err)
+	}
+
+	_ = testModels.Polls.Delete(context.Background(), poll.ID, nil)
+}
+
+func TestAPIKeys(t *testing.T) {
+	key := APIKey{Name: "backend", RPS: 10, Burst: 20, DailyQuota: 100}
+	plaintext, _ := GenerateAPIKey()
+	if err := testModels.APIKeys.Insert(&key, plaintext.Hash); err != nil {
+		t.Fatalf("insert api key returned an error: %s", err)
+	}
+
+	duplicate := APIKey{Name: "backend", RPS: 1, Burst: 1}
+	other, _ := GenerateAPIKey()
+	if err := testModels.APIKeys.Insert(&duplicate, other.Hash); !errors.Is(err, ErrDuplicateAPIKeyName) {
+		t.Errorf("expected ErrDuplicateAPIKeyName, but got %v", err)
+	}
+
+	k, err := testModels.APIKeys.GetByPlaintext(plaintext.Plaintext)
+	if err != nil {
+		t.Fatalf("get api key returned an error: %s", err)
+	}
+	if k.ID != key.ID || k.DailyQuota != 100 {
+		t.Errorf("expected stored key, but got %+v", k)
+	}
+
+	for i := 1; i <= 3; i++ {
+		requests, err := testModels.APIKeys.RecordUsage(key.ID)
+		if err != nil {
+			t.Fatalf("record usage returned an error: %s", err)
+		}
+		if requests != i {
+			t.Errorf("expected %d requests today, but got %d", i, requests)
+		}
+	}
+
+	usage, err := testModels.APIKeys.GetUsage(key.Name, 7)
+	if err != nil {
+		t.Errorf("get usage returned an error: %s", err)
+	}
+	if len(usage) != 1 || usage[0].Requests != 3 {
+		t.Errorf("expected 3 requests today, but got %+v", usage)
+	}
+
+	key.RPS = 50
+	if err := testModels.APIKeys.UpdateLimits(&key); err != nil {
+		t.Errorf("update api key returned an error: %s", err)
+	}
+
+	poll, token := createPollAndGenerateToken(t)
+	poll.APIKeyID = key.ID
+	if err := testModels.Polls.Insert(context.Background(), poll, token.Hash, nil); err != nil {
+		t.Fatalf("insert poll returned an error: %s", err)
+	}
+	p, _ := testModels.Polls.Get(context.Background(), poll.ID)
+	if p.APIKeyID != key.ID {
+		t.Errorf("expected poll to belong to api key %s, but got %q", key.ID, p.APIKeyID)
+	}
+
+	if err := testModels.APIKeys.Revoke(key.Name); err != nil {
+		t.Errorf("revoke api key returned an error: %s", err)
+	}
+	if _, err := testModels.APIKeys.GetByPlaintext(plaintext.Plaintext); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected revoked key to be rejected, but got %v", err)
+	}
+
+	_ = testModels.Polls.Delete(context.Background(), poll.ID, nil)
+}
+
+func TestRevokedTokens(t *testing.T) {
+	poll, _ := createPollAndGenerateToken(t)
+	if err := testModels.Polls.Insert(context.Background(), poll, nil, nil); err != nil {
+		t.Fatalf("insert poll without token returned an error: %s", err)
+	}
+
+	tokens, err := testModels.Tokens.GetAllForPoll(poll.ID)
+	if err != nil {
+		t.Errorf("get tokens returned an error: %s", err)
+	}
+	if len(tokens) != 0 {
+		t.Errorf("expected no stored tokens, but got %d", len(tokens))
+	}
+
+	jti := uuid.NewString()
+	denied, err := testModels.Tokens.IsDenied(jti)
+	if err != nil || denied {
+		t.Errorf("expected token not to be denied, but got %v, %v", denied, err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	if err := testModels.Tokens.Deny(jti, poll.ID, expiresAt); err != nil {
+		t.Fatalf("deny returned an error: %s", err)
+	}
+	if err := testModels.Tokens.Deny(jti, poll.ID, expiresAt); err != nil {
+		t.Errorf("denying a token twice returned an error: %s", err)
+	}
+	denied, err = testModels.Tokens.IsDenied(jti)
+	if err != nil || !denied {
+		t.Errorf("expected token to be denied, but got %v, %v", denied, err)
+	}
+
+	expiredJTI := uuid.NewString()
+	if err := testModels.Tokens.Deny(expiredJTI, poll.ID, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("deny returned an error: %s", err)
+	}
+	// expired entries are purged on the next revocation
+	if err := testModels.Tokens.Deny(uuid.NewString(), poll.ID, expiresAt); err != nil {
+		t.Fatalf("deny returned an error: %s", err)
+	}
+	if denied, _ := testModels.Tokens.IsDenied(expiredJTI); denied {
+		t.Errorf("expected expired entry to be purged")
+	}
+
+	_ = testModels.Polls.Delete(context.Background(), poll.ID, nil)
+}
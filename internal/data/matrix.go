@@ -0,0 +1,237 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	minScaleLength      = 2
+	maxScaleLength      = 11
+	maxScaleLabelLength = 100
+)
+
+// ValidateScale checks the column labels of a matrix poll. The poll's
+// options are the rows rated on this scale.
+func ValidateScale(v *validator.Validator, scale []string) {
+	v.Check(
+		len(scale) >= minScaleLength && len(scale) <= maxScaleLength,
+		"scale",
+		fmt.Sprintf("must contain between %d and %d labels", minScaleLength, maxScaleLength),
+	)
+	v.Check(validator.Unique(scale), "scale", "must not contain duplicate labels")
+	for _, label := range scale {
+		v.Check(label != "", "scale", "labels must not be empty")
+		v.Check(
+			len(label) <= maxScaleLabelLength,
+			"scale",
+			fmt.Sprintf("label must not be more than %d bytes long", maxScaleLabelLength),
+		)
+	}
+}
+
+// Rating is one answer on a matrix ballot. Value is the 1-based position
+// on the poll's scale.
+type Rating struct {
+	OptionID string `json:"option_id"`
+	Value    int    `json:"value"`
+}
+
+type RatingBallot struct {
+	Ratings []Rating `json:"ratings"`
+}
+
+// ValidateRatingBallot requires exactly one rating per row of the matrix.
+func ValidateRatingBallot(v *validator.Validator, ballot *RatingBallot, poll *Poll) {
+	rows := make(map[string]bool, len(poll.Options))
+	for _, opt := range poll.Options {
+		rows[opt.ID] = true
+	}
+
+	var rated []string
+	for _, rating := range ballot.Ratings {
+		v.Check(rows[rating.OptionID], "ratings", "must only contain rows of this poll")
+		v.Check(
+			rating.Value >= 1 && rating.Value <= len(poll.Scale),
+			"ratings",
+			fmt.Sprintf("value must be between 1 and %d", len(poll.Scale)),
+		)
+		rated = append(rated, rating.OptionID)
+	}
+	v.Check(validator.Unique(rated), "ratings", "must not rate the same row twice")
+	v.Check(len(ballot.Ratings) == len(poll.Options), "ratings", "must rate every row")
+}
+
+// RowDistribution holds the number of ratings for each point of the scale,
+// Counts[0] being the first label.
+type RowDistribution struct {
+	OptionID string
+	Counts   []int
+}
+
+type RowStatistics struct {
+	Responses int     `json:"responses"`
+	Mean      float64 `json:"mean"`
+	Median    float64 `json:"median"`
+	StdDev    float64 `json:"std_dev"`
+}
+
+// Statistics treats the scale as the values 1..n. StdDev is the population
+// standard deviation.
+func (d RowDistribution) Statistics() RowStatistics {
+	var stats RowStatistics
+	var sum float64
+	for i, count := range d.Counts {
+		stats.Responses += count
+		sum += float64((i + 1) * count)
+	}
+	if stats.Responses == 0 {
+		return stats
+	}
+
+	stats.Mean = sum / float64(stats.Responses)
+
+	var squares float64
+	for i, count := range d.Counts {
+		diff := float64(i+1) - stats.Mean
+		squares += diff * diff * float64(count)
+	}
+	stats.StdDev = math.Sqrt(squares / float64(stats.Responses))
+
+	stats.Median = (d.nth((stats.Responses-1)/2) + d.nth(stats.Responses/2)) / 2
+
+	return stats
+}
+
+// nth returns the scale value of the nth rating, counting from 0 in
+// ascending order.
+func (d RowDistribution) nth(n int) float64 {
+	seen := 0
+	for i, count := range d.Counts {
+		seen += count
+		if n < seen {
+			return float64(i + 1)
+		}
+	}
+	return 0
+}
+
+type RatingModel struct {
+	DB *pgxpool.Pool
+}
+
+// Insert stores every rating of a ballot under one ballot id, counts them
+// towards the vote count of their rows and records the voter's IP.
+func (r RatingModel) Insert(ballot *RatingBallot, pollID string, ip string) error {
+	var paramIP pgtype.Inet
+	err := paramIP.Set(ip)
+	if err != nil {
+		return fmt.Errorf("insert ratings - set ip: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("insert ratings: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	ballotID := uuid.NewString()
+
+	query := `
+		INSERT INTO ratings (poll_id, option_id, ballot_id, value)
+		VALUES ($1, $2, $3, $4);
+	`
+	queryCount := `
+		UPDATE poll_options
+		SET vote_count = vote_count + 1
+		WHERE id = $1 AND poll_id = $2;
+	`
+	for _, rating := range ballot.Ratings {
+		_, err = tx.Exec(ctx, query, pollID, rating.OptionID, ballotID, rating.Value)
+		if err != nil {
+			return fmt.Errorf("insert ratings: %w", err)
+		}
+		result, err := tx.Exec(ctx, queryCount, rating.OptionID, pollID)
+		if err != nil {
+			return fmt.Errorf("insert ratings - count: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return ErrRecordNotFound
+		}
+	}
+
+	queryIP := `
+		INSERT INTO ips (ip, poll_id)
+		VALUES ($1, $2);
+	`
+	_, err = tx.Exec(ctx, queryIP, paramIP, pollID)
+	if err != nil {
+		return fmt.Errorf("insert ratings - insert ip: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("insert ratings: %w", err)
+	}
+
+	return nil
+}
+
+// GetDistributions returns the distribution of ratings for every row of
+// the poll, in row order.
+func (r RatingModel) GetDistributions(pollID string, scaleLength int) ([]*RowDistribution, error) {
+	query := `
+		SELECT po.id, ra.value, count(ra.id)
+		FROM poll_options po
+		LEFT JOIN ratings ra ON ra.option_id = po.id
+		WHERE po.poll_id = $1
+		GROUP BY po.id, po.position, ra.value
+		ORDER BY po.position ASC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := r.DB.Query(ctx, query, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get ratings: %w", err)
+	}
+	defer rows.Close()
+
+	distributions := []*RowDistribution{}
+	byOption := make(map[string]*RowDistribution)
+
+	for rows.Next() {
+		var optionID string
+		var value *int
+		var count int
+		err := rows.Scan(&optionID, &value, &count)
+		if err != nil {
+			return nil, fmt.Errorf("get ratings - scan: %w", err)
+		}
+
+		d, ok := byOption[optionID]
+		if !ok {
+			d = &RowDistribution{OptionID: optionID, Counts: make([]int, scaleLength)}
+			byOption[optionID] = d
+			distributions = append(distributions, d)
+		}
+		if value != nil && *value >= 1 && *value <= scaleLength {
+			d.Counts[*value-1] = count
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("get ratings: %w", err)
+	}
+
+	return distributions, nil
+}
@@ -1,11 +1,14 @@
 package data
 
 import (
+	"context"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type MockPollModel struct {
@@ -13,31 +16,88 @@ type MockPollModel struct {
 }
 
 var (
-	ExamplePollIDValid         = "e9da0ad7-6065-40de-8398-2514ce9c566f"
-	ExamplePollIDExpiredPoll   = "7a818efb-b94d-49ea-af0e-5f1c8999c1b5"
-	ExamplePollIDExpiredNotSet = "e4dd6db9-fa83-45d2-81dd-1f93019a25a2"
-	ExamplePollIDAfterVote     = "6e3e617f-b5e6-4627-a2db-c72e29ec1729"
-	ExamplePollIDAfterDeadline = "0d5edfad-ba7f-4ddc-a455-4f25ca09bfdd"
-	ExamplePollIDVotingStarted = "0d5edfad-ba7f-4ddc-a455-4f25ca09bfss"
-	ExampleOptionID1           = "65d7c012-f3f9-43f5-a62c-12ab516c6124"
-	ExampleOptionID2           = "b85b14b5-7da6-47d0-8518-07033e199a50"
-	ExampleOptionID3           = "b8168cce-4044-4c23-9506-b41915784166"
+	ExamplePollIDValid            = "e9da0ad7-6065-40de-8398-2514ce9c566f"
+	ExamplePollIDExpiredPoll      = "7a818efb-b94d-49ea-af0e-5f1c8999c1b5"
+	ExamplePollIDExpiredNotSet    = "e4dd6db9-fa83-45d2-81dd-1f93019a25a2"
+	ExamplePollIDAfterVote        = "6e3e617f-b5e6-4627-a2db-c72e29ec1729"
+	ExamplePollIDAfterDeadline    = "0d5edfad-ba7f-4ddc-a455-4f25ca09bfdd"
+	ExamplePollIDVotingStarted    = "0d5edfad-ba7f-4ddc-a455-4f25ca09bfss"
+	ExampleOptionID1              = "65d7c012-f3f9-43f5-a62c-12ab516c6124"
+	ExampleOptionID2              = "b85b14b5-7da6-47d0-8518-07033e199a50"
+	ExampleOptionID3              = "b8168cce-4044-4c23-9506-b41915784166"
+	ExamplePollIDOpenText         = "3f1c2a9e-8d4b-4c6a-9e2f-7b5d1a0c3e84"
+	ExamplePollIDOpenTextAnswered = "7c9e1b3d-5f7a-4c9e-b1d3-5f7a9c1e3b5d"
+	ExampleResponseID1            = "a4b7c2d9-1e3f-4a5b-8c6d-9e0f1a2b3c4d"
+	ExampleResponseID2            = "c9d8e7f6-5a4b-4c3d-9e2f-1a0b9c8d7e6f"
+	ExampleResponseIDMerged       = "f1e2d3c4-b5a6-4978-8a9b-0c1d2e3f4a5b"
+	ExamplePollIDWriteIn          = "5b2e8c41-7d9a-4f3e-b6c0-2a1d8e9f7c35"
+	ExamplePollIDQuiz             = "8c4d2f7a-1b3e-4d5c-9a8b-6e7f0a1b2c3d"
+	ExamplePollIDQuizClosed       = "2e9b7a6c-5d4f-4e3a-8b2c-1d0e9f8a7b6c"
+	ExampleSeries                 = "team-trivia"
+	ExampleSeriesID               = "2d4f6b8a-0c2e-4d4f-a6b8-0c2e4f6a8b0c"
+	ExamplePollIDSchedule         = "9d3a5e7c-2b4f-4c8e-a1d6-3f5b7c9e1a2d"
+	ExamplePollIDMatrix           = "4a6c8e0b-3d5f-4b7a-9c1e-5f7a9b1d3e5c"
+	ExampleUserID                 = "6b1f3d5a-7c9e-4b2d-8f0a-1c3e5a7b9d2f"
+	ExampleUserEmail              = "alice@example.com"
+	ExampleUserPassword           = "pa55word"
+	ExampleSessionToken           = "ALICESESSIONTOKENAAAAAAAAA"
+	ExampleSessionTokenOther      = "BOBSESSIONTOKENAAAAAAAAAAA"
+	ExampleTokenID                = "8e0a2c4f-6b8d-4e1a-9c3f-5b7d9f1a3c5e"
+	ExampleTokenResultsOnly       = "RESULTSONLYTOKENAAAAAAAAAA"
+	ExampleAPIKeyID               = "1d3f5b7a-9c2e-4a6d-8b0f-2e4a6c8d0f1b"
+	ExampleAPIKey                 = "pk_EXAMPLEAPIKEYAAAAAAAAAAAAAAAAAAAAA"
+	ExampleAPIKeyOverQuota        = "pk_OVERQUOTAAPIKEYAAAAAAAAAAAAAAAAAAA"
+	ExampleIssuer                 = "https://sso.example.com"
+	ExampleSessionTokenSSO        = "CAROLSESSIONTOKENAAAAAAAAA"
+	ExampleSessionTokenSSOExt     = "DAVESESSIONTOKENAAAAAAAAAA"
+	ExamplePollIDRestricted       = "7f9b1d3e-5a7c-4e9b-b1d3-9e1f3a5c7e9b"
+	ExamplePollIDVoterAuth        = "2c4e6a8d-0b1f-4d3e-a5c7-e9b1d3f5a7c9"
+	ExampleVoterCredentialID      = "5e7a9c1b-3d5f-4a7c-9e1b-3d5f7a9c1e3b"
+	ExampleVoterCredential        = "VOTERCREDENTIALAAAAAAAAAAA"
+	ExampleVoterCredentialUsed    = "USEDCREDENTIALAAAAAAAAAAAA"
+	ExampleOrgID                  = "3b5d7f9a-1c3e-4f5a-8b7d-9f1a3c5e7b9d"
+	ExamplePollIDOrgPrivate       = "9a1c3e5b-7d9f-4b1c-8e3a-5c7e9b1d3f5a"
+	ExampleOrgViewerID            = "4c6e8a0d-2f4b-4d6c-a8e0-6b8d0f2a4c6e"
+	ExampleOrgViewerEmail         = "grace@example.com"
+	ExampleSessionTokenViewer     = "GRACESESSIONTOKENAAAAAAAAA"
+	ExampleRevisionID             = "6d8f0b2c-4e6a-4c8e-b0d2-8a0c2e4f6b8d"
+	ExampleRevisionIDExpired      = "1e3a5c7d-9f1b-4d3e-8c5a-7e9b1d3f5a7c"
+	ExamplePollIDDeleted          = "8e0a2c4f-6b8d-4a0e-b2c4-0f2a4c6e8b0d"
+	ExamplePollIDDeletedOld       = "5f7b9d1a-3c5e-4f7b-9d1a-7c9e1b3d5f7a"
+	ExamplePollIDHidden           = "0b2d4f6a-8c0e-4b2d-9f6a-4c6e8a0c2e4f"
+	ExampleOptionValueRaced       = "Raced"
+	ExamplePurgedPolls            = int64(2)
+	ExamplePurgedIPs              = int64(5)
 )
 
-func (p MockPollModel) Insert(poll *Poll, tokenHash []byte) error {
+func (p MockPollModel) Insert(ctx context.Context, poll *Poll, tokenHash []byte, revision *Revision) error {
+	if poll.Series != "" {
+		if poll.OrgID == "" && poll.OwnerID == "" && poll.APIKeyID == "" {
+			return ErrSeriesWithoutOwner
+		}
+		poll.SeriesID = uuid.NewString()
+	}
 	poll.ID = uuid.NewString()
+	poll.CreatedAt = time.Now()
+	poll.UpdatedAt = poll.CreatedAt
+	for _, opt := range poll.Options {
+		opt.ID = uuid.NewString()
+	}
 	return nil
 }
 
-func (p MockPollModel) Get(id string) (*Poll, error) {
+func (p MockPollModel) Get(ctx context.Context, id string) (*Poll, error) {
 	if id == ExamplePollIDValid {
 		poll := Poll{
 			ID:                ExamplePollIDValid,
+			OwnerID:           ExampleUserID,
+			APIKeyID:          ExampleAPIKeyID,
 			Question:          "Test?",
 			CreatedAt:         time.Now(),
 			UpdatedAt:         time.Now(),
 			ExpiresAt:         ExpiresAt{time.Now().Add(2 * time.Minute)},
 			ResultsVisibility: "always",
+			PollType:          PollTypeSingleChoice,
 			Options: []*PollOption{
 				{ID: ExampleOptionID1, Value: "One", Position: 0},
 				{ID: ExampleOptionID2, Value: "Two", Position: 1},
@@ -57,6 +117,51 @@ func (p MockPollModel) Get(id string) (*Poll, error) {
 	if id == ExamplePollIDExpiredNotSet {
 		return &Poll{}, nil
 	}
+	// one vote per account, ballots are public
+	if id == ExamplePollIDVoterAuth {
+		return &Poll{
+			ID:                ExamplePollIDVoterAuth,
+			Question:          "Test?",
+			ResultsVisibility: "always",
+			PollType:          PollTypeSingleChoice,
+			VoterAuth:         true,
+			PublicBallots:     true,
+			Options: []*PollOption{
+				{ID: ExampleOptionID1, Value: "One", Position: 0},
+				{ID: ExampleOptionID2, Value: "Two", Position: 1},
+			},
+		}, nil
+	}
+	// only members of the organization can see it
+	if id == ExamplePollIDOrgPrivate {
+		return &Poll{
+			ID:                ExamplePollIDOrgPrivate,
+			Question:          "Test?",
+			ResultsVisibility: "always",
+			IsPrivate:         true,
+			PollType:          PollTypeSingleChoice,
+			OrgID:             ExampleOrgID,
+			Options: []*PollOption{
+				{ID: ExampleOptionID1, Value: "One", Position: 0},
+				{ID: ExampleOptionID2, Value: "Two", Position: 1},
+			},
+		}, nil
+	}
+	// only staff signed in with the identity provider can vote
+	if id == ExamplePollIDRestricted {
+		return &Poll{
+			ID:                ExamplePollIDRestricted,
+			Question:          "Test?",
+			ResultsVisibility: "always",
+			PollType:          PollTypeSingleChoice,
+			VoterIssuer:       ExampleIssuer,
+			VoterEmailDomain:  "example.com",
+			Options: []*PollOption{
+				{ID: ExampleOptionID1, Value: "One", Position: 0},
+				{ID: ExampleOptionID2, Value: "Two", Position: 1},
+			},
+		}, nil
+	}
 	// results after vote
 	if id == ExamplePollIDAfterVote {
 		return &Poll{
@@ -67,40 +172,261 @@ func (p MockPollModel) Get(id string) (*Poll, error) {
 	// results after deadline
 	if id == ExamplePollIDAfterDeadline {
 		return &Poll{
+			ID:                ExamplePollIDAfterDeadline,
+			OwnerID:           ExampleUserID,
 			ExpiresAt:         ExpiresAt{time.Now().Add(1 * time.Minute)},
 			ResultsVisibility: "after_deadline",
 		}, nil
 	}
+	// open text poll, without and with responses
+	if id == ExamplePollIDOpenText || id == ExamplePollIDOpenTextAnswered {
+		return &Poll{
+			ID:                id,
+			Question:          "Test?",
+			ExpiresAt:         ExpiresAt{time.Now().Add(2 * time.Minute)},
+			ResultsVisibility: "always",
+			PollType:          PollTypeOpenText,
+			Options:           []*PollOption{},
+		}, nil
+	}
+	// write-in poll
+	if id == ExamplePollIDWriteIn {
+		return &Poll{
+			ID:                ExamplePollIDWriteIn,
+			Question:          "Test?",
+			ExpiresAt:         ExpiresAt{time.Now().Add(2 * time.Minute)},
+			ResultsVisibility: "always",
+			PollType:          PollTypeSingleChoice,
+			AllowWriteIn:      true,
+			Options: []*PollOption{
+				{ID: ExampleOptionID1, Value: "One", Position: 0},
+				{ID: ExampleOptionID2, Value: "Two", Position: 1},
+			},
+		}, nil
+	}
+	// quiz, open and closed
+	if id == ExamplePollIDQuiz || id == ExamplePollIDQuizClosed {
+		expiresAt := time.Now().Add(2 * time.Minute)
+		if id == ExamplePollIDQuizClosed {
+			expiresAt = time.Now().Add(-1 * time.Minute)
+		}
+		return &Poll{
+			ID:                id,
+			Question:          "Test?",
+			ExpiresAt:         ExpiresAt{expiresAt},
+			ResultsVisibility: "always",
+			PollType:          PollTypeQuiz,
+			Series:            ExampleSeries,
+			SeriesID:          ExampleSeriesID,
+			Options: []*PollOption{
+				{ID: ExampleOptionID1, Value: "One", Position: 0, IsCorrect: true},
+				{ID: ExampleOptionID2, Value: "Two", Position: 1},
+				{ID: ExampleOptionID3, Value: "Three", Position: 2},
+			},
+		}, nil
+	}
+	// schedule poll
+	if id == ExamplePollIDSchedule {
+		day := time.Date(2030, time.March, 4, 9, 0, 0, 0, time.UTC)
+		return &Poll{
+			ID:                ExamplePollIDSchedule,
+			Question:          "Team meeting?",
+			ExpiresAt:         ExpiresAt{time.Now().Add(2 * time.Minute)},
+			ResultsVisibility: "always",
+			PollType:          PollTypeSchedule,
+			Options: []*PollOption{
+				{
+					ID: ExampleOptionID1, Value: "Monday morning", Position: 0,
+					Slot: &TimeSlot{Start: day, End: day.Add(time.Hour), TimeZone: "UTC"},
+				},
+				{
+					ID: ExampleOptionID2, Value: "Monday afternoon", Position: 1,
+					Slot: &TimeSlot{Start: day.Add(5 * time.Hour), End: day.Add(6 * time.Hour), TimeZone: "UTC"},
+				},
+			},
+		}, nil
+	}
+	// matrix poll
+	if id == ExamplePollIDMatrix {
+		return &Poll{
+			ID:                ExamplePollIDMatrix,
+			Question:          "How do you feel about the office?",
+			ExpiresAt:         ExpiresAt{time.Now().Add(2 * time.Minute)},
+			ResultsVisibility: "always",
+			PollType:          PollTypeMatrix,
+			Scale:             []string{"Disagree", "Neutral", "Agree"},
+			Options: []*PollOption{
+				{ID: ExampleOptionID1, Value: "The coffee is good", Position: 0},
+				{ID: ExampleOptionID2, Value: "The chairs are comfortable", Position: 1},
+			},
+		}, nil
+	}
 	return nil, ErrRecordNotFound
 }
 
-func (p MockPollModel) Update(poll *Poll) error {
+func (p MockPollModel) Update(ctx context.Context, poll *Poll, revision *Revision) error {
 	if poll.ID == ExamplePollIDValid {
 		return nil
 	}
 	return ErrRecordNotFound
 }
 
-func (p MockPollModel) Delete(id string) error {
+func (p MockPollModel) Restore(ctx context.Context, poll *Poll, revision *Revision) error {
+	if poll.ID == ExamplePollIDValid {
+		poll.UpdatedAt = time.Now()
+		return nil
+	}
+	return ErrRecordNotFound
+}
+
+func (p MockPollModel) Delete(ctx context.Context, id string, revision *Revision) error {
 	if id == ExamplePollIDValid {
 		return nil
 	}
 	return ErrRecordNotFound
 }
 
-func (p MockPollModel) GetAll(search string, filters Filters) ([]*Poll, Metadata, error) {
-	return nil, Metadata{}, nil
+func (p MockPollModel) GetAny(ctx context.Context, id string) (*Poll, error) {
+	switch id {
+	case ExamplePollIDHidden:
+		hiddenAt := time.Now().Add(-time.Hour)
+		return &Poll{ID: id, Question: "Hidden poll?", Options: []*PollOption{}, HiddenAt: &hiddenAt}, nil
+	case ExamplePollIDDeleted, ExamplePollIDDeletedOld:
+		return p.GetDeleted(ctx, id)
+	}
+	return p.Get(ctx, id)
+}
+
+func (p MockPollModel) GetDeleted(ctx context.Context, id string) (*Poll, error) {
+	var deletedAt time.Time
+	switch id {
+	case ExamplePollIDDeleted:
+		deletedAt = time.Now().Add(-time.Hour)
+	case ExamplePollIDDeletedOld:
+		deletedAt = time.Now().Add(-365 * 24 * time.Hour)
+	default:
+		return nil, ErrRecordNotFound
+	}
+	return &Poll{
+		ID:        id,
+		Question:  "Deleted poll?",
+		OwnerID:   ExampleUserID,
+		DeletedAt: &deletedAt,
+	}, nil
+}
+
+func (p MockPollModel) Undelete(ctx context.Context, id string, deletedAfter time.Time, revision *Revision) error {
+	poll, err := p.GetDeleted(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !poll.DeletedAt.After(deletedAfter) {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (p MockPollModel) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return ExamplePurgedPolls, nil
+}
+
+func (p MockPollModel) Erase(ctx context.Context, id string) error {
+	switch id {
+	case ExamplePollIDValid, ExamplePollIDDeleted, ExamplePollIDDeletedOld:
+		return nil
+	}
+	return ErrRecordNotFound
+}
+
+func (p MockPollModel) Hide(ctx context.Context, id string) error {
+	switch id {
+	case ExamplePollIDValid, ExamplePollIDDeleted, ExamplePollIDDeletedOld, ExamplePollIDHidden:
+		return nil
+	}
+	return ErrRecordNotFound
+}
+
+func (p MockPollModel) Unhide(ctx context.Context, id string) error {
+	if id == ExamplePollIDHidden {
+		return nil
+	}
+	return ErrRecordNotFound
+}
+
+func (p MockPollModel) GetAll(ctx context.Context, search string, filters Filters) ([]*Poll, Metadata, error) {
+	return []*Poll{}, Metadata{}, nil
+}
+
+func (p MockPollModel) GetAllIncludingPrivate(ctx context.Context, search string, filters Filters) ([]*Poll, Metadata, error) {
+	polls := []*Poll{}
+	for _, id := range []string{ExamplePollIDValid, ExamplePollIDOrgPrivate} {
+		poll, err := p.Get(ctx, id)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		polls = append(polls, poll)
+	}
+	hiddenAt := time.Now().Add(-time.Hour)
+	polls = append(polls, &Poll{ID: ExamplePollIDHidden, Question: "Hidden poll?", Options: []*PollOption{}, HiddenAt: &hiddenAt})
+	return polls, calculateMetadata(len(polls), filters.Page, filters.PageSize), nil
 }
 
-func (p MockPollModel) GetVotedIPs(pollID string) ([]*net.IP, error) {
+func (p MockPollModel) GetVotedIPs(ctx context.Context, pollID string) ([]*net.IP, error) {
 	var ips []*net.IP
 	i := net.IPv4(0, 0, 0, 1)
 	ips = append(ips, &i)
 	return ips, nil
 }
 
-func (p MockPollModel) CheckToken(tokenPlaintext string) (string, error) {
-	return ExamplePollIDValid, nil
+func (p MockPollModel) HasVotes(ctx context.Context, pollID string) (bool, error) {
+	return pollID == ExamplePollIDVotingStarted || pollID == ExamplePollIDOpenTextAnswered, nil
+}
+
+func (p MockPollModel) GetAllForOwner(ctx context.Context, ownerID string, filters Filters) ([]*Poll, Metadata, error) {
+	if ownerID == ExampleUserID {
+		poll, _ := p.Get(ctx, ExamplePollIDValid)
+		return []*Poll{poll}, calculateMetadata(1, filters.Page, filters.PageSize), nil
+	}
+	return []*Poll{}, Metadata{}, nil
+}
+
+func (p MockPollModel) GetAllForOrg(ctx context.Context, orgID string, search string, filters Filters) ([]*Poll, Metadata, error) {
+	if orgID == ExampleOrgID {
+		poll, _ := p.Get(ctx, ExamplePollIDOrgPrivate)
+		return []*Poll{poll}, calculateMetadata(1, filters.Page, filters.PageSize), nil
+	}
+	return []*Poll{}, Metadata{}, nil
+}
+
+func (p MockPollModel) GetTurnout(ctx context.Context, pollID string) (*Turnout, error) {
+	lastVote := time.Date(2024, time.February, 26, 17, 19, 44, 0, time.UTC)
+	return &Turnout{Ballots: 3, DistinctIPs: 2, LastVoteAt: &lastVote}, nil
+}
+
+func (p MockPollModel) PurgeIPs(ctx context.Context, pollID string) (int64, error) {
+	if pollID == ExamplePollIDValid {
+		return ExamplePurgedIPs, nil
+	}
+	return 0, nil
+}
+
+func (p MockPollModel) GetStats(ctx context.Context) (*Stats, error) {
+	return &Stats{Polls: 12, PrivatePolls: 3, OpenPolls: 7, DeletedPolls: 1, Votes: 480, VotesLastDay: 35}, nil
+}
+
+func (p MockPollModel) CheckToken(ctx context.Context, tokenPlaintext string) (*PollToken, error) {
+	switch tokenPlaintext {
+	case ExampleSessionToken, ExampleSessionTokenOther, ExampleSessionTokenSSO, ExampleSessionTokenSSOExt, ExampleSessionTokenViewer,
+		ExampleVoterCredential, ExampleVoterCredentialUsed:
+		return nil, ErrRecordNotFound
+	case ExampleTokenResultsOnly:
+		return &PollToken{
+			ID:     uuid.NewString(),
+			PollID: ExamplePollIDValid,
+			Scopes: []string{ScopeResultsRead},
+		}, nil
+	}
+	return &PollToken{ID: ExampleTokenID, PollID: ExamplePollIDValid, Scopes: AllScopes}, nil
 }
 
 // PollOption
@@ -109,27 +435,61 @@ type MockPollOptionModel struct {
 	DB *pgxpool.Pool
 }
 
-func (p MockPollOptionModel) Insert(option *PollOption, pollID string) error {
+func (p MockPollOptionModel) Insert(ctx context.Context, option *PollOption, pollID string, revision *Revision) error {
+	// stands in for an option someone else added in the meantime
+	if option.Value == ExampleOptionValueRaced {
+		return ErrDuplicateOption
+	}
+	return nil
+}
+
+func (p MockPollOptionModel) UpdateValue(ctx context.Context, option *PollOption, revision *Revision) error {
 	return nil
 }
 
-func (p MockPollOptionModel) UpdateValue(option *PollOption) error {
+func (p MockPollOptionModel) UpdatePosition(ctx context.Context, options []*PollOption, revision *Revision) error {
 	return nil
 }
 
-func (p MockPollOptionModel) UpdatePosition(options []*PollOption) error {
+func (p MockPollOptionModel) Delete(ctx context.Context, optionID string, revision *Revision) error {
 	return nil
 }
 
-func (p MockPollOptionModel) Delete(optionID string) error {
+func (p MockPollOptionModel) Vote(ctx context.Context, optionID string, pollID string, ip string) error {
 	return nil
 }
 
-func (p MockPollOptionModel) Vote(optionID string, pollID string, ip string) error {
+// VoteAs treats Alice and the used credential as having voted already.
+func (p MockPollOptionModel) VoteAs(ctx context.Context, optionID string, pollID string, ip string, voter *Voter) error {
+	switch voter.ID {
+	case "user:" + ExampleUserID, "credential:" + ExampleVoterCredentialUsed:
+		return ErrAlreadyVoted
+	}
 	return nil
 }
 
-func (p MockPollOptionModel) GetResults(pollID string) ([]*PollOption, error) {
+func (p MockPollOptionModel) WriteIn(ctx context.Context, option *PollOption, pollID string, ip string) (bool, error) {
+	poll, err := MockPollModel{}.Get(ctx, pollID)
+	if err != nil {
+		return false, err
+	}
+	for _, opt := range poll.Options {
+		if NormalizeOptionValue(opt.Value) == NormalizeOptionValue(option.Value) {
+			*option = *opt
+			return false, nil
+		}
+	}
+	option.ID = uuid.NewString()
+	option.Position = len(poll.Options)
+	option.VoteCount = 1
+	return true, nil
+}
+
+func (p MockPollOptionModel) SetCorrect(ctx context.Context, pollID string, optionIDs []string, revision *Revision) error {
+	return nil
+}
+
+func (p MockPollOptionModel) GetResults(ctx context.Context, pollID string) ([]*PollOption, error) {
 	if pollID == ExamplePollIDVotingStarted {
 		return []*PollOption{
 			{ID: "1", Value: "One", Position: 0, VoteCount: 1},
@@ -138,3 +498,450 @@ func (p MockPollOptionModel) GetResults(pollID string) ([]*PollOption, error) {
 	}
 	return nil, nil
 }
+
+// PollResponse
+
+type MockPollResponseModel struct {
+	DB *pgxpool.Pool
+}
+
+func (p MockPollResponseModel) Insert(response *PollResponse, pollID string, ip string) error {
+	response.ID = uuid.NewString()
+	response.Status = ResponseStatusPending
+	response.Count = 1
+	return nil
+}
+
+func (p MockPollResponseModel) Get(id string, pollID string) (*PollResponse, error) {
+	switch id {
+	case ExampleResponseID1:
+		return &PollResponse{
+			ID: ExampleResponseID1, Value: "Pizza", Status: ResponseStatusApproved, Count: 2,
+		}, nil
+	case ExampleResponseID2:
+		return &PollResponse{
+			ID: ExampleResponseID2, Value: "pizza", Status: ResponseStatusPending, Count: 1,
+		}, nil
+	case ExampleResponseIDMerged:
+		return &PollResponse{
+			ID:         ExampleResponseIDMerged,
+			Value:      "PIZZA",
+			Status:     ResponseStatusMerged,
+			MergedInto: ExampleResponseID1,
+			Count:      1,
+		}, nil
+	}
+	return nil, ErrRecordNotFound
+}
+
+func (p MockPollResponseModel) GetAll(pollID string, status string) ([]*PollResponse, error) {
+	return []*PollResponse{
+		{ID: ExampleResponseID1, Value: "Pizza", Status: ResponseStatusApproved, Count: 2},
+	}, nil
+}
+
+func (p MockPollResponseModel) UpdateStatus(response *PollResponse, pollID string) error {
+	return nil
+}
+
+func (p MockPollResponseModel) Merge(id string, targetID string, pollID string) error {
+	return nil
+}
+
+func (p MockPollResponseModel) Promote(response *PollResponse, option *PollOption, pollID string, revision *Revision) error {
+	option.ID = uuid.NewString()
+	response.OptionID = option.ID
+	return nil
+}
+
+// QuizAnswer
+
+type MockQuizAnswerModel struct {
+	DB *pgxpool.Pool
+}
+
+func (q MockQuizAnswerModel) Vote(ctx context.Context, answer *QuizAnswer, ip string, voter *Voter) error {
+	if voter != nil {
+		if err := (MockPollOptionModel{}).VoteAs(ctx, answer.OptionID, answer.PollID, ip, voter); err != nil {
+			return err
+		}
+	}
+	answer.CreatedAt = time.Now()
+	return nil
+}
+
+func (q MockQuizAnswerModel) Leaderboard(seriesID string) (*QuizSeries, []*LeaderboardEntry, error) {
+	if seriesID == ExampleSeriesID {
+		return &QuizSeries{ID: ExampleSeriesID, Name: ExampleSeries}, []*LeaderboardEntry{
+			{Voter: "alice", Score: 2, Answered: 2},
+			{Voter: "bob", Score: 1, Answered: 2},
+		}, nil
+	}
+	return nil, nil, ErrRecordNotFound
+}
+
+// Availability
+
+type MockAvailabilityModel struct {
+	DB *pgxpool.Pool
+}
+
+func (a MockAvailabilityModel) Insert(ballot *AvailabilityBallot, pollID string, ip string) error {
+	return nil
+}
+
+func (a MockAvailabilityModel) GetResults(pollID string) ([]*SlotTally, error) {
+	tallies := []*SlotTally{
+		{OptionID: ExampleOptionID1, Yes: 1, IfNeedBe: 0, No: 2},
+		{OptionID: ExampleOptionID2, Yes: 2, IfNeedBe: 1, No: 0},
+	}
+	RankSlots(tallies)
+	return tallies, nil
+}
+
+// Rating
+
+type MockRatingModel struct {
+	DB *pgxpool.Pool
+}
+
+func (r MockRatingModel) Insert(ballot *RatingBallot, pollID string, ip string) error {
+	return nil
+}
+
+func (r MockRatingModel) GetDistributions(pollID string, scaleLength int) ([]*RowDistribution, error) {
+	return []*RowDistribution{
+		{OptionID: ExampleOptionID1, Counts: []int{1, 1, 2}},
+		{OptionID: ExampleOptionID2, Counts: []int{0, 0, 0}},
+	}, nil
+}
+
+// User
+
+type MockUserModel struct {
+	DB *pgxpool.Pool
+}
+
+func (u MockUserModel) Insert(user *User) error {
+	if user.Email == ExampleUserEmail {
+		return ErrDuplicateEmail
+	}
+	user.ID = uuid.NewString()
+	user.CreatedAt = time.Now()
+	return nil
+}
+
+func (u MockUserModel) UpsertOIDC(user *User) error {
+	if user.Email == ExampleOrgViewerEmail {
+		return ErrDuplicateEmail
+	}
+	user.ID = uuid.NewString()
+	user.CreatedAt = time.Now()
+	return nil
+}
+
+func (u MockUserModel) GetByEmail(email string) (*User, error) {
+	if email == ExampleUserEmail {
+		return exampleUser(), nil
+	}
+	return nil, ErrRecordNotFound
+}
+
+func (u MockUserModel) GetByVerifiedEmail(email string) (*User, error) {
+	if email == ExampleOrgViewerEmail {
+		return exampleOrgViewer(), nil
+	}
+	return nil, ErrRecordNotFound
+}
+
+func (u MockUserModel) Get(id string) (*User, error) {
+	switch id {
+	case ExampleUserID:
+		return exampleUser(), nil
+	case ExampleOrgViewerID:
+		return exampleOrgViewer(), nil
+	}
+	return nil, ErrRecordNotFound
+}
+
+func (u MockUserModel) NewSession(userID string, ttl time.Duration) (*Token, error) {
+	return GenerateToken()
+}
+
+func (u MockUserModel) GetForSession(tokenPlaintext string) (*User, error) {
+	switch tokenPlaintext {
+	case ExampleSessionToken:
+		return exampleUser(), nil
+	case ExampleSessionTokenOther:
+		return &User{ID: uuid.NewString(), Email: "bob@example.com"}, nil
+	case ExampleSessionTokenViewer:
+		return &User{ID: ExampleOrgViewerID, Email: ExampleOrgViewerEmail}, nil
+	case ExampleSessionTokenSSO:
+		return &User{ID: uuid.NewString(), Email: "carol@example.com", Issuer: ExampleIssuer}, nil
+	case ExampleSessionTokenSSOExt:
+		return &User{ID: uuid.NewString(), Email: "dave@contractor.com", Issuer: ExampleIssuer}, nil
+	}
+	return nil, ErrRecordNotFound
+}
+
+func (u MockUserModel) DeleteSession(tokenPlaintext string) error {
+	return nil
+}
+
+func exampleUser() *User {
+	hash, _ := bcrypt.GenerateFromPassword([]byte(ExampleUserPassword), bcrypt.MinCost)
+	return &User{
+		ID:       ExampleUserID,
+		Email:    ExampleUserEmail,
+		Name:     "Alice",
+		Password: userPassword{hash: hash},
+	}
+}
+
+func exampleOrgViewer() *User {
+	return &User{ID: ExampleOrgViewerID, Email: ExampleOrgViewerEmail, Issuer: ExampleIssuer}
+}
+
+// Token
+
+type MockTokenModel struct {
+	DB *pgxpool.Pool
+}
+
+func (t MockTokenModel) Insert(token *PollToken, tokenHash []byte) error {
+	token.ID = uuid.NewString()
+	token.CreatedAt = time.Now()
+	return nil
+}
+
+func (t MockTokenModel) RotateOwner(token *PollToken, tokenHash []byte) (int64, error) {
+	token.ID = uuid.NewString()
+	token.Scopes = AllScopes
+	token.CreatedAt = time.Now()
+	return 1, nil
+}
+
+func (t MockTokenModel) GetAllForPoll(pollID string) ([]*PollToken, error) {
+	return []*PollToken{
+		{ID: ExampleTokenID, PollID: pollID, Scopes: AllScopes, CreatedAt: time.Now()},
+	}, nil
+}
+
+func (t MockTokenModel) Delete(id string, pollID string) error {
+	if id == ExampleTokenID && pollID == ExamplePollIDValid {
+		return nil
+	}
+	return ErrRecordNotFound
+}
+
+// mockDeniedTokens lets tests revoke a signed token and see it rejected.
+var mockDeniedTokens sync.Map
+
+func (t MockTokenModel) Deny(jti string, pollID string, expiresAt time.Time) error {
+	mockDeniedTokens.Store(jti, true)
+	return nil
+}
+
+func (t MockTokenModel) IsDenied(jti string) (bool, error) {
+	_, denied := mockDeniedTokens.Load(jti)
+	return denied, nil
+}
+
+// Voter
+
+type MockVoterModel struct {
+	DB *pgxpool.Pool
+}
+
+func (vm MockVoterModel) InsertCredential(credential *VoterCredential, pollID string, hash []byte) error {
+	credential.ID = uuid.NewString()
+	credential.CreatedAt = time.Now()
+	return nil
+}
+
+func (vm MockVoterModel) GetCredentials(pollID string) ([]*VoterCredential, error) {
+	return []*VoterCredential{
+		{ID: ExampleVoterCredentialID, Label: "Erin", CreatedAt: time.Now()},
+	}, nil
+}
+
+func (vm MockVoterModel) GetForCredential(pollID string, plaintext string) (*VoterCredential, error) {
+	if pollID != ExamplePollIDVoterAuth {
+		return nil, ErrRecordNotFound
+	}
+	switch plaintext {
+	case ExampleVoterCredential:
+		return &VoterCredential{ID: ExampleVoterCredentialID, Label: "Erin"}, nil
+	case ExampleVoterCredentialUsed:
+		// the ID matches what VoteAs treats as having voted
+		return &VoterCredential{ID: ExampleVoterCredentialUsed, Label: "Frank"}, nil
+	}
+	return nil, ErrRecordNotFound
+}
+
+func (vm MockVoterModel) DeleteCredential(id string, pollID string) error {
+	if id != ExampleVoterCredentialID {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (vm MockVoterModel) GetBallots(pollID string) ([]*Ballot, error) {
+	return []*Ballot{
+		{Voter: "Erin", OptionID: ExampleOptionID1, VotedAt: time.Date(2024, 2, 26, 17, 19, 44, 0, time.UTC)},
+	}, nil
+}
+
+// Organization
+
+type MockOrganizationModel struct {
+	DB *pgxpool.Pool
+}
+
+func (o MockOrganizationModel) Insert(org *Organization, ownerID string) error {
+	org.ID = uuid.NewString()
+	org.CreatedAt = time.Now()
+	org.Role = OrgRoleOwner
+	return nil
+}
+
+func (o MockOrganizationModel) GetAllForUser(userID string) ([]*Organization, error) {
+	role, err := o.GetRole(ExampleOrgID, userID)
+	if err != nil {
+		return []*Organization{}, nil
+	}
+	return []*Organization{
+		{ID: ExampleOrgID, Name: "Marketing", CreatedAt: time.Now(), Role: role},
+	}, nil
+}
+
+// GetRole makes Alice the owner and Grace a viewer of the example
+// organization.
+func (o MockOrganizationModel) GetRole(orgID string, userID string) (string, error) {
+	if orgID == ExampleOrgID {
+		switch userID {
+		case ExampleUserID:
+			return OrgRoleOwner, nil
+		case ExampleOrgViewerID:
+			return OrgRoleViewer, nil
+		}
+	}
+	return "", ErrRecordNotFound
+}
+
+func (o MockOrganizationModel) GetMembers(orgID string) ([]*OrgMember, error) {
+	return []*OrgMember{
+		{UserID: ExampleUserID, Email: ExampleUserEmail, Name: "Alice", Role: OrgRoleOwner, JoinedAt: time.Now()},
+		{UserID: ExampleOrgViewerID, Email: ExampleOrgViewerEmail, Role: OrgRoleViewer, JoinedAt: time.Now()},
+	}, nil
+}
+
+func (o MockOrganizationModel) SetMember(orgID string, userID string, role string) error {
+	if orgID != ExampleOrgID {
+		return ErrRecordNotFound
+	}
+	if userID == ExampleUserID && role != OrgRoleOwner {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+func (o MockOrganizationModel) RemoveMember(orgID string, userID string) error {
+	switch {
+	case orgID != ExampleOrgID:
+		return ErrRecordNotFound
+	case userID == ExampleUserID:
+		return ErrLastOwner
+	case userID == ExampleOrgViewerID:
+		return nil
+	}
+	return ErrRecordNotFound
+}
+
+// Revision
+
+type MockRevisionModel struct {
+	DB *pgxpool.Pool
+}
+
+func (rm MockRevisionModel) GetAllForPoll(pollID string) ([]*Revision, error) {
+	revision, _ := rm.Get(ExampleRevisionID, pollID)
+	return []*Revision{revision}, nil
+}
+
+// Get returns a revision where the valid poll asked "Earlier?" and had its
+// third option deleted, and one that expired since.
+func (rm MockRevisionModel) Get(id string, pollID string) (*Revision, error) {
+	if pollID != ExamplePollIDValid {
+		return nil, ErrRecordNotFound
+	}
+	snapshot := &PollSnapshot{
+		Question:  "Earlier?",
+		ExpiresAt: time.Now().Add(time.Hour),
+		Options: []*OptionSnapshot{
+			{ID: ExampleOptionID1, Value: "One", Position: 0},
+			{ID: ExampleOptionID2, Value: "Two", Position: 1},
+		},
+	}
+	switch id {
+	case ExampleRevisionID:
+	case ExampleRevisionIDExpired:
+		snapshot.ExpiresAt = time.Now().Add(-time.Hour)
+	default:
+		return nil, ErrRecordNotFound
+	}
+	return &Revision{
+		ID:     id,
+		Action: RevisionUpdatePoll,
+		Actor:  "user:" + ExampleUserID,
+		Changes: []*RevisionChange{
+			{Field: "question", Before: "Test?", After: "Earlier?"},
+		},
+		CreatedAt: time.Date(2024, 2, 26, 17, 19, 44, 0, time.UTC),
+		Snapshot:  snapshot,
+	}, nil
+}
+
+// APIKey
+
+type MockAPIKeyModel struct {
+	DB *pgxpool.Pool
+}
+
+func (a MockAPIKeyModel) Insert(key *APIKey, hash []byte) error {
+	key.ID = uuid.NewString()
+	return nil
+}
+
+func (a MockAPIKeyModel) GetByPlaintext(plaintext string) (*APIKey, error) {
+	switch plaintext {
+	case ExampleAPIKey:
+		return &APIKey{ID: ExampleAPIKeyID, Name: "backend", RPS: 100, Burst: 100}, nil
+	case ExampleAPIKeyOverQuota:
+		return &APIKey{ID: uuid.NewString(), Name: "over-quota", RPS: 100, Burst: 100, DailyQuota: 10}, nil
+	}
+	return nil, ErrRecordNotFound
+}
+
+func (a MockAPIKeyModel) GetAll() ([]*APIKey, error) {
+	return []*APIKey{{ID: ExampleAPIKeyID, Name: "backend", RPS: 100, Burst: 100}}, nil
+}
+
+func (a MockAPIKeyModel) UpdateLimits(key *APIKey) error {
+	return nil
+}
+
+func (a MockAPIKeyModel) Revoke(name string) error {
+	return nil
+}
+
+func (a MockAPIKeyModel) RecordUsage(keyID string) (int, error) {
+	if keyID == ExampleAPIKeyID {
+		return 1, nil
+	}
+	return 11, nil
+}
+
+func (a MockAPIKeyModel) GetUsage(name string, days int) ([]*APIKeyUsage, error) {
+	return []*APIKeyUsage{}, nil
+}
@@ -1,6 +1,7 @@
 package data
 
 import (
+	"context"
 	"errors"
 	"net"
 	"time"
@@ -13,38 +14,152 @@ var ErrRecordNotFound = errors.New("record not found")
 const dbTimeout = time.Second * 3
 
 type Models struct {
-	Polls       Polls
-	PollOptions PollOptions
+	Polls         Polls
+	PollOptions   PollOptions
+	PollResponses PollResponses
+	QuizAnswers   QuizAnswers
+	Availability  Availability
+	Ratings       Ratings
+	Users         Users
+	Tokens        Tokens
+	APIKeys       APIKeys
+	Voters        Voters
+	Organizations Organizations
+	Revisions     Revisions
 }
 
 type Polls interface {
-	Insert(poll *Poll, tokenHash []byte) error
-	Get(id string) (*Poll, error)
-	Update(poll *Poll) error
-	Delete(id string) error
-	GetAll(search string, filters Filters) ([]*Poll, Metadata, error)
-	GetVotedIPs(pollID string) ([]*net.IP, error)
-	CheckToken(tokenPlaintext string) (string, error)
+	Insert(ctx context.Context, poll *Poll, tokenHash []byte, revision *Revision) error
+	Get(ctx context.Context, id string) (*Poll, error)
+	GetAny(ctx context.Context, id string) (*Poll, error)
+	Update(ctx context.Context, poll *Poll, revision *Revision) error
+	Restore(ctx context.Context, poll *Poll, revision *Revision) error
+	Delete(ctx context.Context, id string, revision *Revision) error
+	GetDeleted(ctx context.Context, id string) (*Poll, error)
+	Undelete(ctx context.Context, id string, deletedAfter time.Time, revision *Revision) error
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	Erase(ctx context.Context, id string) error
+	Hide(ctx context.Context, id string) error
+	Unhide(ctx context.Context, id string) error
+	GetAll(ctx context.Context, search string, filters Filters) ([]*Poll, Metadata, error)
+	GetAllIncludingPrivate(ctx context.Context, search string, filters Filters) ([]*Poll, Metadata, error)
+	GetAllForOwner(ctx context.Context, ownerID string, filters Filters) ([]*Poll, Metadata, error)
+	GetAllForOrg(ctx context.Context, orgID string, search string, filters Filters) ([]*Poll, Metadata, error)
+	GetVotedIPs(ctx context.Context, pollID string) ([]*net.IP, error)
+	HasVotes(ctx context.Context, pollID string) (bool, error)
+	GetTurnout(ctx context.Context, pollID string) (*Turnout, error)
+	PurgeIPs(ctx context.Context, pollID string) (int64, error)
+	GetStats(ctx context.Context) (*Stats, error)
+	CheckToken(ctx context.Context, tokenPlaintext string) (*PollToken, error)
 }
 type PollOptions interface {
-	Insert(option *PollOption, pollID string) error
-	UpdateValue(option *PollOption) error
-	UpdatePosition(options []*PollOption) error
-	Vote(optionID string, pollID string, ip string) error
-	Delete(optionID string) error
-	GetResults(pollID string) ([]*PollOption, error)
+	Insert(ctx context.Context, option *PollOption, pollID string, revision *Revision) error
+	UpdateValue(ctx context.Context, option *PollOption, revision *Revision) error
+	UpdatePosition(ctx context.Context, options []*PollOption, revision *Revision) error
+	Vote(ctx context.Context, optionID string, pollID string, ip string) error
+	VoteAs(ctx context.Context, optionID string, pollID string, ip string, voter *Voter) error
+	WriteIn(ctx context.Context, option *PollOption, pollID string, ip string) (bool, error)
+	SetCorrect(ctx context.Context, pollID string, optionIDs []string, revision *Revision) error
+	Delete(ctx context.Context, optionID string, revision *Revision) error
+	GetResults(ctx context.Context, pollID string) ([]*PollOption, error)
+}
+type PollResponses interface {
+	Insert(response *PollResponse, pollID string, ip string) error
+	Get(id string, pollID string) (*PollResponse, error)
+	GetAll(pollID string, status string) ([]*PollResponse, error)
+	UpdateStatus(response *PollResponse, pollID string) error
+	Merge(id string, targetID string, pollID string) error
+	Promote(response *PollResponse, option *PollOption, pollID string, revision *Revision) error
+}
+type QuizAnswers interface {
+	Vote(ctx context.Context, answer *QuizAnswer, ip string, voter *Voter) error
+	Leaderboard(seriesID string) (*QuizSeries, []*LeaderboardEntry, error)
+}
+type Availability interface {
+	Insert(ballot *AvailabilityBallot, pollID string, ip string) error
+	GetResults(pollID string) ([]*SlotTally, error)
+}
+type Ratings interface {
+	Insert(ballot *RatingBallot, pollID string, ip string) error
+	GetDistributions(pollID string, scaleLength int) ([]*RowDistribution, error)
+}
+type Tokens interface {
+	Insert(token *PollToken, tokenHash []byte) error
+	RotateOwner(token *PollToken, tokenHash []byte) (int64, error)
+	GetAllForPoll(pollID string) ([]*PollToken, error)
+	Delete(id string, pollID string) error
+	Deny(jti string, pollID string, expiresAt time.Time) error
+	IsDenied(jti string) (bool, error)
+}
+type Voters interface {
+	InsertCredential(credential *VoterCredential, pollID string, hash []byte) error
+	GetCredentials(pollID string) ([]*VoterCredential, error)
+	GetForCredential(pollID string, plaintext string) (*VoterCredential, error)
+	DeleteCredential(id string, pollID string) error
+	GetBallots(pollID string) ([]*Ballot, error)
+}
+type Revisions interface {
+	GetAllForPoll(pollID string) ([]*Revision, error)
+	Get(id string, pollID string) (*Revision, error)
+}
+type Organizations interface {
+	Insert(org *Organization, ownerID string) error
+	GetAllForUser(userID string) ([]*Organization, error)
+	GetRole(orgID string, userID string) (string, error)
+	GetMembers(orgID string) ([]*OrgMember, error)
+	SetMember(orgID string, userID string, role string) error
+	RemoveMember(orgID string, userID string) error
+}
+type APIKeys interface {
+	Insert(key *APIKey, hash []byte) error
+	GetByPlaintext(plaintext string) (*APIKey, error)
+	GetAll() ([]*APIKey, error)
+	UpdateLimits(key *APIKey) error
+	Revoke(name string) error
+	RecordUsage(keyID string) (int, error)
+	GetUsage(name string, days int) ([]*APIKeyUsage, error)
+}
+type Users interface {
+	Insert(user *User) error
+	UpsertOIDC(user *User) error
+	GetByEmail(email string) (*User, error)
+	GetByVerifiedEmail(email string) (*User, error)
+	Get(id string) (*User, error)
+	NewSession(userID string, ttl time.Duration) (*Token, error)
+	GetForSession(tokenPlaintext string) (*User, error)
+	DeleteSession(tokenPlaintext string) error
 }
 
 func NewModels(db *pgxpool.Pool) Models {
 	return Models{
-		Polls:       PollModel{DB: db},
-		PollOptions: PollOptionModel{DB: db},
+		Polls:         PollModel{DB: db},
+		PollOptions:   PollOptionModel{DB: db},
+		PollResponses: PollResponseModel{DB: db},
+		QuizAnswers:   QuizAnswerModel{DB: db},
+		Availability:  AvailabilityModel{DB: db},
+		Ratings:       RatingModel{DB: db},
+		Users:         UserModel{DB: db},
+		Tokens:        TokenModel{DB: db},
+		APIKeys:       APIKeyModel{DB: db},
+		Voters:        VoterModel{DB: db},
+		Organizations: OrganizationModel{DB: db},
+		Revisions:     RevisionModel{DB: db},
 	}
 }
 
 func NewMockModels() Models {
 	return Models{
-		Polls:       MockPollModel{},
-		PollOptions: MockPollOptionModel{},
+		Polls:         MockPollModel{},
+		PollOptions:   MockPollOptionModel{},
+		PollResponses: MockPollResponseModel{},
+		QuizAnswers:   MockQuizAnswerModel{},
+		Availability:  MockAvailabilityModel{},
+		Ratings:       MockRatingModel{},
+		Users:         MockUserModel{},
+		Tokens:        MockTokenModel{},
+		APIKeys:       MockAPIKeyModel{},
+		Voters:        MockVoterModel{},
+		Organizations: MockOrganizationModel{},
+		Revisions:     MockRevisionModel{},
 	}
 }
@@ -0,0 +1,268 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrLastOwner = errors.New("organization must keep an owner")
+
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleEditor = "editor"
+	OrgRoleViewer = "viewer"
+)
+
+var OrgRoles = []string{OrgRoleOwner, OrgRoleEditor, OrgRoleViewer}
+
+const maxOrgNameLength = 100
+
+type Organization struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	// Role is what the user listing their organizations can do in it
+	Role string `json:"role,omitempty"`
+}
+
+type OrgMember struct {
+	UserID   string    `json:"user_id"`
+	Email    string    `json:"email"`
+	Name     string    `json:"name"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// OrgRoleScopes is what a member can do with the organization's polls.
+// Owners can do everything, editors everything but delete polls and manage
+// their tokens, viewers only see results.
+func OrgRoleScopes(role string) []string {
+	switch role {
+	case OrgRoleOwner:
+		return AllScopes
+	case OrgRoleEditor:
+		return []string{ScopePollEdit, ScopeResultsRead, ScopeVotersManage}
+	case OrgRoleViewer:
+		return []string{ScopeResultsRead}
+	}
+	return nil
+}
+
+func ValidateOrganization(v *validator.Validator, org *Organization) {
+	v.Check(org.Name != "", "name", "must be provided")
+	v.Check(
+		len(org.Name) <= maxOrgNameLength,
+		"name",
+		fmt.Sprintf("must not be more than %d bytes long", maxOrgNameLength),
+	)
+}
+
+func ValidateOrgRole(v *validator.Validator, role string) {
+	v.Check(role != "", "role", "must be provided")
+	v.Check(validator.PermittedValue(role, OrgRoles...), "role", "invalid role value")
+}
+
+type OrganizationModel struct {
+	DB *pgxpool.Pool
+}
+
+// Insert creates the organization with the user as its first owner.
+func (o OrganizationModel) Insert(org *Organization, ownerID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := o.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("insert organization: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO organizations (name)
+		VALUES ($1)
+		RETURNING id, created_at;
+	`
+	err = tx.QueryRow(ctx, query, org.Name).Scan(&org.ID, &org.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert organization: %w", err)
+	}
+
+	queryMember := `
+		INSERT INTO organization_members (org_id, user_id, role)
+		VALUES ($1, $2, $3);
+	`
+	_, err = tx.Exec(ctx, queryMember, org.ID, ownerID, OrgRoleOwner)
+	if err != nil {
+		return fmt.Errorf("insert organization - owner: %w", err)
+	}
+
+	org.Role = OrgRoleOwner
+
+	return tx.Commit(ctx)
+}
+
+func (o OrganizationModel) GetAllForUser(userID string) ([]*Organization, error) {
+	query := `
+		SELECT o.id, o.name, o.created_at, m.role
+		FROM organizations o
+		INNER JOIN organization_members m ON m.org_id = o.id
+		WHERE m.user_id = $1
+		ORDER BY o.name ASC, o.id ASC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := o.DB.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get organizations: %w", err)
+	}
+	defer rows.Close()
+
+	orgs := []*Organization{}
+
+	for rows.Next() {
+		var org Organization
+		err := rows.Scan(&org.ID, &org.Name, &org.CreatedAt, &org.Role)
+		if err != nil {
+			return nil, fmt.Errorf("get organizations - scan: %w", err)
+		}
+		orgs = append(orgs, &org)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("get organizations: %w", err)
+	}
+
+	return orgs, nil
+}
+
+// GetRole returns ErrRecordNotFound when the user is not a member.
+func (o OrganizationModel) GetRole(orgID string, userID string) (string, error) {
+	query := `
+		SELECT role
+		FROM organization_members
+		WHERE org_id = $1 AND user_id = $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var role string
+	err := o.DB.QueryRow(ctx, query, orgID, userID).Scan(&role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrRecordNotFound
+		}
+		return "", fmt.Errorf("get organization role: %w", err)
+	}
+
+	return role, nil
+}
+
+func (o OrganizationModel) GetMembers(orgID string) ([]*OrgMember, error) {
+	query := `
+		SELECT u.id, u.email, u.name, m.role, m.created_at
+		FROM organization_members m
+		INNER JOIN users u ON u.id = m.user_id
+		WHERE m.org_id = $1
+		ORDER BY m.created_at ASC, u.id ASC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := o.DB.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("get organization members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []*OrgMember{}
+
+	for rows.Next() {
+		var member OrgMember
+		err := rows.Scan(&member.UserID, &member.Email, &member.Name, &member.Role, &member.JoinedAt)
+		if err != nil {
+			return nil, fmt.Errorf("get organization members - scan: %w", err)
+		}
+		members = append(members, &member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("get organization members: %w", err)
+	}
+
+	return members, nil
+}
+
+// SetMember adds the user to the organization or changes their role.
+func (o OrganizationModel) SetMember(orgID string, userID string, role string) error {
+	query := `
+		INSERT INTO organization_members (org_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (org_id, user_id) DO UPDATE SET role = EXCLUDED.role;
+	`
+	return o.changeMembers(orgID, query, orgID, userID, role)
+}
+
+func (o OrganizationModel) RemoveMember(orgID string, userID string) error {
+	query := `
+		DELETE FROM organization_members
+		WHERE org_id = $1 AND user_id = $2;
+	`
+	return o.changeMembers(orgID, query, orgID, userID)
+}
+
+// changeMembers runs a change to the members of an organization and rolls
+// it back if no owner would be left. The organization row is locked so
+// two owners can't demote each other at the same time.
+func (o OrganizationModel) changeMembers(orgID string, query string, args ...any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := o.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("change organization members: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var id string
+	err = tx.QueryRow(ctx, `SELECT id FROM organizations WHERE id = $1 FOR UPDATE;`, orgID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRecordNotFound
+		}
+		return fmt.Errorf("change organization members - lock: %w", err)
+	}
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("change organization members: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	queryOwners := `
+		SELECT count(*)
+		FROM organization_members
+		WHERE org_id = $1 AND role = $2;
+	`
+	var owners int
+	err = tx.QueryRow(ctx, queryOwners, orgID, OrgRoleOwner).Scan(&owners)
+	if err != nil {
+		return fmt.Errorf("change organization members - count owners: %w", err)
+	}
+	if owners == 0 {
+		return ErrLastOwner
+	}
+
+	return tx.Commit(ctx)
+}
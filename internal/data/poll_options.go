@@ -2,44 +2,78 @@ package data
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
 	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrDuplicateOption is returned when an option's value matches another
+// option of the poll once case and spacing are folded.
+var ErrDuplicateOption = errors.New("duplicate option")
+
 type PollOption struct {
 	ID    string `json:"id"`
 	Value string `json:"value"`
 	// Position of option in the list, starting at 0
 	Position  int `json:"position"`
 	VoteCount int `json:"-"`
+	// IsCorrect marks the right answers of a quiz, kept out of JSON so
+	// it can't leak before the quiz closes
+	IsCorrect bool `json:"-"`
+	// Slot is set on schedule poll options only
+	Slot *TimeSlot `json:"slot,omitempty"`
+}
+
+func (o *PollOption) payload() ([]byte, error) {
+	if o.Slot == nil {
+		return nil, nil
+	}
+	return json.Marshal(o.Slot)
 }
 
 type PollOptionModel struct {
 	DB *pgxpool.Pool
 }
 
-func (p PollOptionModel) Insert(option *PollOption, pollID string) error {
+func (p PollOptionModel) Insert(ctx context.Context, option *PollOption, pollID string, revision *Revision) error {
 	query := `
-		INSERT INTO poll_options (poll_id, value, position, vote_count)
-		VALUES ($1, $2, $3, $4);		
+		INSERT INTO poll_options (poll_id, value, position, vote_count, is_correct, payload)
+		VALUES ($1, $2, $3, $4, $5, $6);		
 	`
 
-	args := []any{pollID, option.Value, option.Position, option.VoteCount}
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	payload, err := option.payload()
+	if err != nil {
+		return fmt.Errorf("insert poll option - payload: %w", err)
+	}
+
+	args := []any{pollID, option.Value, option.Position, option.VoteCount, option.IsCorrect, payload}
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
-	_, err := p.DB.Exec(ctx, query, args...)
+
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("insert poll option: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, args...)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDuplicateOption
+		}
 		return fmt.Errorf("insert poll option: %w", err)
 	}
 
-	return p.setUpdatedAt(pollID)
+	return commitOptionChange(ctx, tx, pollID, revision)
 }
 
-func (p PollOptionModel) UpdateValue(option *PollOption) error {
+func (p PollOptionModel) UpdateValue(ctx context.Context, option *PollOption, revision *Revision) error {
 	query := `
 		UPDATE poll_options 
 		SET value = $1
@@ -48,19 +82,30 @@ func (p PollOptionModel) UpdateValue(option *PollOption) error {
 	`
 
 	var pollID string
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
-	err := p.DB.QueryRow(
+
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("update poll option: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(
 		ctx, query, option.Value, option.ID,
 	).Scan(&pollID)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDuplicateOption
+		}
 		return fmt.Errorf("update poll option: %w", err)
 	}
 
-	return p.setUpdatedAt(pollID)
+	return commitOptionChange(ctx, tx, pollID, revision)
 }
 
-func (p PollOptionModel) UpdatePosition(options []*PollOption) error {
+func (p PollOptionModel) UpdatePosition(ctx context.Context, options []*PollOption, revision *Revision) error {
 	query := `
 		UPDATE poll_options 
 		SET position = $1
@@ -70,10 +115,17 @@ func (p PollOptionModel) UpdatePosition(options []*PollOption) error {
 
 	var pollID string
 
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("update option position: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	for _, option := range options {
-		ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
-		defer cancel()
-		err := p.DB.QueryRow(
+		err := tx.QueryRow(
 			ctx, query, option.Position, option.ID,
 		).Scan(&pollID)
 		if err != nil {
@@ -81,10 +133,37 @@ func (p PollOptionModel) UpdatePosition(options []*PollOption) error {
 		}
 	}
 
-	return p.setUpdatedAt(pollID)
+	return commitOptionChange(ctx, tx, pollID, revision)
 }
 
-func (p PollOptionModel) Delete(optionID string) error {
+// SetCorrect marks the given options as the correct answers of a quiz and
+// clears the flag on every other option of the poll.
+func (p PollOptionModel) SetCorrect(ctx context.Context, pollID string, optionIDs []string, revision *Revision) error {
+	query := `
+		UPDATE poll_options
+		SET is_correct = (id = ANY($2::uuid[]))
+		WHERE poll_id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("set correct options: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, pollID, optionIDs)
+	if err != nil {
+		return fmt.Errorf("set correct options: %w", err)
+	}
+
+	return commitOptionChange(ctx, tx, pollID, revision)
+}
+
+// Delete removes the option and moves the options after it up one place.
+func (p PollOptionModel) Delete(ctx context.Context, optionID string, revision *Revision) error {
 	if optionID == "" {
 		return ErrRecordNotFound
 	}
@@ -92,13 +171,20 @@ func (p PollOptionModel) Delete(optionID string) error {
 	query := `
 		DELETE FROM poll_options
 		WHERE id = $1
-		RETURNING poll_id;	
+		RETURNING poll_id, position;	
 	`
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("delete option: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	var pollID string
-	err := p.DB.QueryRow(ctx, query, optionID).Scan(&pollID)
+	var position int
+	err = tx.QueryRow(ctx, query, optionID).Scan(&pollID, &position)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return ErrRecordNotFound
@@ -106,38 +192,89 @@ func (p PollOptionModel) Delete(optionID string) error {
 		return fmt.Errorf("delete option: %w", err)
 	}
 
-	return p.setUpdatedAt(pollID)
+	queryPositions := `
+		UPDATE poll_options
+		SET position = position - 1
+		WHERE poll_id = $1 AND position > $2;
+	`
+	_, err = tx.Exec(ctx, queryPositions, pollID, position)
+	if err != nil {
+		return fmt.Errorf("delete option - update positions: %w", err)
+	}
+
+	return commitOptionChange(ctx, tx, pollID, revision)
 }
 
-func (p PollOptionModel) Vote(optionID string, pollID string, ip string) error {
-	query := `
-		UPDATE poll_options 
-		SET vote_count = vote_count + 1
-		WHERE id = $1 AND poll_id = $2;
-	`
+func (p PollOptionModel) Vote(ctx context.Context, optionID string, pollID string, ip string) error {
+	return p.VoteAs(ctx, optionID, pollID, ip, nil)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+// VoteAs counts a ballot from an authenticated voter. Voters are told apart
+// by their identity rather than their IP, the IP is only kept for turnout.
+// Without a voter it counts an anonymous vote, told apart by its IP.
+func (p PollOptionModel) VoteAs(ctx context.Context, optionID string, pollID string, ip string, voter *Voter) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	result, err := p.DB.Exec(ctx, query, optionID, pollID)
+	tx, err := p.DB.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("vote option: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	if result.RowsAffected() == 0 {
-		return ErrRecordNotFound
+	if err = castVote(ctx, tx, optionID, pollID, ip, voter); err != nil {
+		return err
 	}
 
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("vote option: %w", err)
+	}
+
+	return nil
+}
+
+// castVote counts a vote for the option and records the voter's IP, and
+// their ballot when they are signed in.
+func castVote(ctx context.Context, tx pgx.Tx, optionID string, pollID string, ip string, voter *Voter) error {
 	var paramIP pgtype.Inet
-	err = paramIP.Set(ip)
+	err := paramIP.Set(ip)
 	if err != nil {
 		return fmt.Errorf("vote option - set ip: %w", err)
 	}
+
+	query := `
+		UPDATE poll_options
+		SET vote_count = vote_count + 1
+		WHERE id = $1 AND poll_id = $2;
+	`
+	result, err := tx.Exec(ctx, query, optionID, pollID)
+	if err != nil {
+		return fmt.Errorf("vote option: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	if voter != nil {
+		queryBallot := `
+			INSERT INTO ballots (poll_id, voter, voter_name, option_id)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (poll_id, voter) DO NOTHING;
+		`
+		result, err = tx.Exec(ctx, queryBallot, pollID, voter.ID, voter.Name, optionID)
+		if err != nil {
+			return fmt.Errorf("vote option - insert ballot: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return ErrAlreadyVoted
+		}
+	}
+
 	queryIP := `
 		INSERT INTO ips (ip, poll_id)
-		VALUES ($1, $2); 		
+		VALUES ($1, $2);
 	`
-	_, err = p.DB.Exec(ctx, queryIP, paramIP, pollID)
+	_, err = tx.Exec(ctx, queryIP, paramIP, pollID)
 	if err != nil {
 		return fmt.Errorf("vote option - insert ip: %w", err)
 	}
@@ -145,14 +282,92 @@ func (p PollOptionModel) Vote(optionID string, pollID string, ip string) error {
 	return nil
 }
 
-func (p PollOptionModel) GetResults(pollID string) ([]*PollOption, error) {
+// WriteIn counts a voter's write-in. A value matching an existing option
+// once case and spacing are folded is a vote for that option, anything
+// else is added as a new option at the end of the list. It reports whether
+// an option was added. The poll is locked while the options are compared,
+// so concurrent write-ins can't both add the same value.
+func (p PollOptionModel) WriteIn(ctx context.Context, option *PollOption, pollID string, ip string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("write-in: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	queryLock := `
+		SELECT id
+		FROM polls
+		WHERE id = $1
+		FOR UPDATE;
+	`
+	err = tx.QueryRow(ctx, queryLock, pollID).Scan(new(string))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, ErrRecordNotFound
+		}
+		return false, fmt.Errorf("write-in - lock poll: %w", err)
+	}
+
+	queryMatch := `
+		SELECT id, value, position
+		FROM poll_options
+		WHERE poll_id = $1 AND normalized_value = normalize_option_value($2);
+	`
+	err = tx.QueryRow(ctx, queryMatch, pollID, option.Value).Scan(&option.ID, &option.Value, &option.Position)
+	switch {
+	case err == nil:
+		if err = castVote(ctx, tx, option.ID, pollID, ip, nil); err != nil {
+			return false, err
+		}
+		if err = tx.Commit(ctx); err != nil {
+			return false, fmt.Errorf("write-in: %w", err)
+		}
+		return false, nil
+	case !errors.Is(err, pgx.ErrNoRows):
+		return false, fmt.Errorf("write-in - match option: %w", err)
+	}
+
+	queryInsert := `
+		INSERT INTO poll_options (poll_id, value, position, vote_count)
+		VALUES ($1, $2, (SELECT COALESCE(max(position) + 1, 0) FROM poll_options WHERE poll_id = $1), 0)
+		RETURNING id, position;
+	`
+	err = tx.QueryRow(ctx, queryInsert, pollID, option.Value).Scan(&option.ID, &option.Position)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return false, ErrDuplicateOption
+		}
+		return false, fmt.Errorf("write-in - insert option: %w", err)
+	}
+
+	if err = castVote(ctx, tx, option.ID, pollID, ip, nil); err != nil {
+		return false, err
+	}
+
+	if err = setUpdatedAt(ctx, tx, pollID); err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("write-in: %w", err)
+	}
+	option.VoteCount = 1
+
+	return true, nil
+}
+
+func (p PollOptionModel) GetResults(ctx context.Context, pollID string) ([]*PollOption, error) {
 	query := `
 		SELECT id, value, position, vote_count
 		FROM poll_options
 		WHERE poll_id = $1;
 	`
 
-	rows, err := p.DB.Query(context.Background(), query, pollID)
+	rows, err := p.DB.Query(ctx, query, pollID)
 	if err != nil {
 		return nil, fmt.Errorf("get votes for poll: %w", err)
 	}
@@ -181,15 +396,35 @@ func (p PollOptionModel) GetResults(pollID string) ([]*PollOption, error) {
 	return options, nil
 }
 
-func (p PollOptionModel) setUpdatedAt(pollID string) error {
+// commitOptionChange finishes a change to the options of a poll, bumping
+// the poll's updated_at and recording the revision along with it.
+func commitOptionChange(ctx context.Context, tx pgx.Tx, pollID string, revision *Revision) error {
+	if err := setUpdatedAt(ctx, tx, pollID); err != nil {
+		return err
+	}
+
+	if err := revision.record(ctx, tx, pollID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDuplicateOption
+		}
+		return fmt.Errorf("commit option change: %w", err)
+	}
+
+	return nil
+}
+
+func setUpdatedAt(ctx context.Context, tx pgx.Tx, pollID string) error {
 	query := `
 		UPDATE polls
 		SET updated_at = NOW()
 		WHERE id = $1;
 	`
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
-	defer cancel()
-	_, err := p.DB.Exec(ctx, query, pollID)
+	_, err := tx.Exec(ctx, query, pollID)
 	if err != nil {
 		return fmt.Errorf("set updated_at: %w", err)
 	}
@@ -0,0 +1,279 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	ResponseStatusPending  = "pending"
+	ResponseStatusApproved = "approved"
+	ResponseStatusHidden   = "hidden"
+	ResponseStatusMerged   = "merged"
+)
+
+var ResponseStatusSafelist = []string{
+	ResponseStatusPending,
+	ResponseStatusApproved,
+	ResponseStatusHidden,
+	ResponseStatusMerged,
+}
+
+type PollResponse struct {
+	ID         string `json:"id"`
+	Value      string `json:"value"`
+	Status     string `json:"status"`
+	MergedInto string `json:"merged_into,omitempty"`
+	OptionID   string `json:"option_id,omitempty"`
+	// Count is the response itself plus every response merged into it
+	Count     int       `json:"count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PollResponseModel struct {
+	DB *pgxpool.Pool
+}
+
+// Insert stores a response and records the voter's IP in one transaction,
+// so a voter whose IP wasn't recorded can't have a response counted.
+func (p PollResponseModel) Insert(response *PollResponse, pollID string, ip string) error {
+	var paramIP pgtype.Inet
+	err := paramIP.Set(ip)
+	if err != nil {
+		return fmt.Errorf("insert response - set ip: %w", err)
+	}
+
+	if response.Status == "" {
+		response.Status = ResponseStatusPending
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("insert response: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO poll_responses (poll_id, value, status)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at;
+	`
+	err = tx.QueryRow(
+		ctx, query, pollID, response.Value, response.Status,
+	).Scan(&response.ID, &response.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert response: %w", err)
+	}
+
+	queryIP := `
+		INSERT INTO ips (ip, poll_id)
+		VALUES ($1, $2);
+	`
+	_, err = tx.Exec(ctx, queryIP, paramIP, pollID)
+	if err != nil {
+		return fmt.Errorf("insert response - insert ip: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("insert response: %w", err)
+	}
+	response.Count = 1
+
+	return nil
+}
+
+func (p PollResponseModel) Get(id string, pollID string) (*PollResponse, error) {
+	if id == "" {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT r.id, r.value, r.status, r.merged_into, r.option_id, r.created_at,
+		1 + (SELECT count(*) FROM poll_responses m WHERE m.merged_into = r.id)
+		FROM poll_responses r
+		WHERE r.id = $1 AND r.poll_id = $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	response, err := scanResponse(p.DB.QueryRow(ctx, query, id, pollID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get response: %w", err)
+	}
+
+	return response, nil
+}
+
+// GetAll returns responses for a poll, optionally limited to one status.
+func (p PollResponseModel) GetAll(pollID string, status string) ([]*PollResponse, error) {
+	query := `
+		SELECT r.id, r.value, r.status, r.merged_into, r.option_id, r.created_at,
+		1 + (SELECT count(*) FROM poll_responses m WHERE m.merged_into = r.id)
+		FROM poll_responses r
+		WHERE r.poll_id = $1 AND (r.status = $2 OR $2 = '')
+		ORDER BY r.created_at ASC, r.id ASC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := p.DB.Query(ctx, query, pollID, status)
+	if err != nil {
+		return nil, fmt.Errorf("get responses: %w", err)
+	}
+	defer rows.Close()
+
+	responses := []*PollResponse{}
+
+	for rows.Next() {
+		response, err := scanResponse(rows)
+		if err != nil {
+			return nil, fmt.Errorf("get responses - scan: %w", err)
+		}
+		responses = append(responses, response)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("get responses: %w", err)
+	}
+
+	return responses, nil
+}
+
+func (p PollResponseModel) UpdateStatus(response *PollResponse, pollID string) error {
+	query := `
+		UPDATE poll_responses
+		SET status = $1
+		WHERE id = $2 AND poll_id = $3;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := p.DB.Exec(ctx, query, response.Status, response.ID, pollID)
+	if err != nil {
+		return fmt.Errorf("update response status: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Merge folds a response, and anything already merged into it, into the
+// target response. Both have to belong to the poll.
+func (p PollResponseModel) Merge(id string, targetID string, pollID string) error {
+	query := `
+		UPDATE poll_responses
+		SET merged_into = $2, status = $3
+		WHERE (id = $1 OR merged_into = $1) AND poll_id = $4
+		AND EXISTS (SELECT 1 FROM poll_responses WHERE id = $2 AND poll_id = $4);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := p.DB.Exec(ctx, query, id, targetID, ResponseStatusMerged, pollID)
+	if err != nil {
+		return fmt.Errorf("merge response: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Promote turns a response into a poll option. The new option starts with
+// the response count as its vote count.
+func (p PollResponseModel) Promote(response *PollResponse, option *PollOption, pollID string, revision *Revision) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("promote response: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	queryOption := `
+		INSERT INTO poll_options (poll_id, value, position, vote_count)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id;
+	`
+	err = tx.QueryRow(
+		ctx, queryOption, pollID, option.Value, option.Position, option.VoteCount,
+	).Scan(&option.ID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDuplicateOption
+		}
+		return fmt.Errorf("promote response - insert option: %w", err)
+	}
+
+	queryResponse := `
+		UPDATE poll_responses
+		SET option_id = $1
+		WHERE id = $2 AND poll_id = $3;
+	`
+	result, err := tx.Exec(ctx, queryResponse, option.ID, response.ID, pollID)
+	if err != nil {
+		return fmt.Errorf("promote response - update response: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	if err = commitOptionChange(ctx, tx, pollID, revision); err != nil {
+		return err
+	}
+
+	response.OptionID = option.ID
+
+	return nil
+}
+
+func scanResponse(row pgx.Row) (*PollResponse, error) {
+	var response PollResponse
+	var mergedInto, optionID *string
+
+	err := row.Scan(
+		&response.ID,
+		&response.Value,
+		&response.Status,
+		&mergedInto,
+		&optionID,
+		&response.CreatedAt,
+		&response.Count,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if mergedInto != nil {
+		response.MergedInto = *mergedInto
+	}
+	if optionID != nil {
+		response.OptionID = *optionID
+	}
+
+	return &response, nil
+}
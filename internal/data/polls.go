@@ -12,6 +12,7 @@ import (
 
 	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -25,6 +26,20 @@ type Poll struct {
 	ExpiresAt         ExpiresAt     `json:"expires_at"`
 	ResultsVisibility string        `json:"results_visibility"`
 	IsPrivate         bool          `json:"is_private"`
+	PollType          string        `json:"poll_type"`
+	AllowWriteIn      bool          `json:"allow_write_in"`
+	Series            string        `json:"series,omitempty"`
+	SeriesID          string        `json:"series_id,omitempty"`
+	Scale             []string      `json:"scale,omitempty"`
+	VoterIssuer       string        `json:"voter_issuer,omitempty"`
+	VoterEmailDomain  string        `json:"voter_email_domain,omitempty"`
+	VoterAuth         bool          `json:"voter_auth"`
+	PublicBallots     bool          `json:"public_ballots"`
+	OrgID             string        `json:"org_id,omitempty"`
+	DeletedAt         *time.Time    `json:"deleted_at,omitempty"`
+	HiddenAt          *time.Time    `json:"hidden_at,omitempty"`
+	OwnerID           string        `json:"-"`
+	APIKeyID          string        `json:"-"`
 	Token             string        `json:"token,omitempty"`
 }
 
@@ -32,36 +47,131 @@ type PollModel struct {
 	DB *pgxpool.Pool
 }
 
-func (p PollModel) Insert(poll *Poll, tokenHash []byte) error {
+func (p PollModel) Insert(ctx context.Context, poll *Poll, tokenHash []byte, revision *Revision) error {
 	query := `
-		INSERT INTO polls (question, description, expires_at, results_visibility, is_private)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO polls (question, description, expires_at, results_visibility, is_private, poll_type, allow_write_in, series, scale, owner_id, api_key_id, voter_issuer, voter_email_domain, voter_auth, public_ballots, org_id, series_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, '')::uuid, NULLIF($11, '')::uuid, $12, $13, $14, $15, NULLIF($16, '')::uuid, NULLIF($17, '')::uuid)
 		RETURNING id, created_at, updated_at;				
 		`
 
+	var scale []byte
+	var err error
+	if poll.Scale != nil {
+		scale, err = json.Marshal(poll.Scale)
+		if err != nil {
+			return fmt.Errorf("insert poll - scale: %w", err)
+		}
+	}
+
 	args := []any{
 		poll.Question,
 		poll.Description,
 		poll.ExpiresAt.Time,
 		poll.ResultsVisibility,
 		poll.IsPrivate,
+		poll.PollType,
+		poll.AllowWriteIn,
+		poll.Series,
+		scale,
+		poll.OwnerID,
+		poll.APIKeyID,
+		poll.VoterIssuer,
+		poll.VoterEmailDomain,
+		poll.VoterAuth,
+		poll.PublicBallots,
+		poll.OrgID,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	err := p.DB.QueryRow(
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("insert poll: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if poll.Series != "" {
+		if err := upsertSeries(ctx, tx, poll); err != nil {
+			return err
+		}
+	}
+	args = append(args, poll.SeriesID)
+
+	err = tx.QueryRow(
 		ctx, query, args...,
 	).Scan(&poll.ID, &poll.CreatedAt, &poll.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("insert poll: %w", err)
 	}
 
+	if len(poll.Options) > 0 {
+		if err := insertOptions(ctx, tx, poll); err != nil {
+			return err
+		}
+	}
+
+	// polls handed a signed token have nothing to store
+	if tokenHash != nil {
+		queryToken := `
+			INSERT INTO tokens (hash, poll_id)
+			VALUES ($1, $2);
+		`
+		_, err = tx.Exec(ctx, queryToken, tokenHash, poll.ID)
+		if err != nil {
+			return fmt.Errorf("insert poll - token: %w", err)
+		}
+	}
+
+	if err := revision.record(ctx, tx, poll.ID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("insert poll: %w", err)
+	}
+
+	return nil
+}
+
+// upsertSeries looks up the poll's quiz series by name among those of its
+// organization, or of its owner or API key when it has none, and creates
+// it if it's new. Series of different owners never mix on a leaderboard.
+func upsertSeries(ctx context.Context, tx pgx.Tx, poll *Poll) error {
+	query := `
+		INSERT INTO quiz_series (name, org_id, owner_id, api_key_id)
+		VALUES ($1, NULLIF($2, '')::uuid, NULLIF($3, '')::uuid, NULLIF($4, '')::uuid)
+		ON CONFLICT ((COALESCE(org_id, owner_id, api_key_id)), name) DO UPDATE
+		SET name = EXCLUDED.name
+		RETURNING id;
+	`
+
+	var orgID, ownerID, apiKeyID string
+	switch {
+	case poll.OrgID != "":
+		orgID = poll.OrgID
+	case poll.OwnerID != "":
+		ownerID = poll.OwnerID
+	case poll.APIKeyID != "":
+		apiKeyID = poll.APIKeyID
+	default:
+		return ErrSeriesWithoutOwner
+	}
+
+	err := tx.QueryRow(ctx, query, poll.Series, orgID, ownerID, apiKeyID).Scan(&poll.SeriesID)
+	if err != nil {
+		return fmt.Errorf("insert poll - series: %w", err)
+	}
+
+	return nil
+}
+
+func insertOptions(ctx context.Context, tx pgx.Tx, poll *Poll) error {
 	var queryOptionsString strings.Builder
 	queryOptionsString.WriteString(
-		"INSERT INTO poll_options (value, poll_id, position, vote_count) VALUES ",
+		"INSERT INTO poll_options (value, poll_id, position, vote_count, is_correct, payload) VALUES ",
 	)
-	values := make([]any, 0, len(poll.Options)*4)
+	values := make([]any, 0, len(poll.Options)*6)
 	count := 1
 
 	for i, opt := range poll.Options {
@@ -71,18 +181,20 @@ func (p PollModel) Insert(poll *Poll, tokenHash []byte) error {
 			comma = ""
 		}
 		str = fmt.Sprintf(
-			"($%d, $%d, $%d, $%d)%s ", count, count+1, count+2, count+3, comma,
+			"($%d, $%d, $%d, $%d, $%d, $%d)%s ",
+			count, count+1, count+2, count+3, count+4, count+5, comma,
 		)
 		queryOptionsString.WriteString(str)
-		values = append(values, opt.Value, poll.ID, opt.Position, opt.VoteCount)
-		count += 4
+		payload, err := opt.payload()
+		if err != nil {
+			return fmt.Errorf("insert poll options - payload: %w", err)
+		}
+		values = append(values, opt.Value, poll.ID, opt.Position, opt.VoteCount, opt.IsCorrect, payload)
+		count += 6
 	}
 	queryOptionsString.WriteString(" RETURNING id;")
 
-	ctx, cancel = context.WithTimeout(context.Background(), dbTimeout)
-	defer cancel()
-
-	rows, err := p.DB.Query(ctx, queryOptionsString.String(), values...)
+	rows, err := tx.Query(ctx, queryOptionsString.String(), values...)
 	if err != nil {
 		return fmt.Errorf("insert poll options: %w", err)
 	}
@@ -101,19 +213,20 @@ func (p PollModel) Insert(poll *Poll, tokenHash []byte) error {
 		return fmt.Errorf("insert poll options: %w", err)
 	}
 
-	queryToken := `
-		INSERT INTO tokens (hash, poll_id)
-		VALUES ($1, $2);
-	`
-	ctx, cancel = context.WithTimeout(context.Background(), dbTimeout)
-	defer cancel()
+	return nil
+}
 
-	_, err = p.DB.Exec(ctx, queryToken, tokenHash, poll.ID)
+func (p PollModel) Get(ctx context.Context, id string) (*Poll, error) {
+	return p.get(ctx, id, "p.deleted_at IS NULL AND p.hidden_at IS NULL")
+}
 
-	return err
+// GetAny looks up a poll whether or not it was deleted or hidden. It's for
+// operators, everyone else goes through Get.
+func (p PollModel) GetAny(ctx context.Context, id string) (*Poll, error) {
+	return p.get(ctx, id, "TRUE")
 }
 
-func (p PollModel) Get(id string) (*Poll, error) {
+func (p PollModel) get(ctx context.Context, id string, where string) (*Poll, error) {
 	if id == "" {
 		return nil, ErrRecordNotFound
 	}
@@ -121,13 +234,16 @@ func (p PollModel) Get(id string) (*Poll, error) {
 	query := `
 		SELECT p.id, p. question, p.description, p.created_at, 
 		p.updated_at, p.expires_at, p.results_visibility, p.is_private,
-		po.id, po.value, po.position
+		p.poll_type, p.allow_write_in, p.series, p.scale, p.owner_id, p.api_key_id,
+		p.voter_issuer, p.voter_email_domain, p.voter_auth, p.public_ballots, p.org_id,
+		COALESCE(p.series_id::text, ''), p.deleted_at, p.hidden_at,
+		po.id, po.value, po.position, po.is_correct, po.payload
 		FROM polls p
-		JOIN poll_options po ON po.poll_id = p.id 
-		WHERE p.id = $1;
+		LEFT JOIN poll_options po ON po.poll_id = p.id 
+		WHERE p.id = $1 AND ` + where + `;
 	`
 
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
 	rows, err := p.DB.Query(ctx, query, id)
@@ -137,12 +253,17 @@ func (p PollModel) Get(id string) (*Poll, error) {
 	defer rows.Close()
 
 	var poll Poll
-	var options []*PollOption
+	var scale []byte
+	var ownerID, apiKeyID, orgID *string
+	options := []*PollOption{}
 
 	first := true
 	for rows.Next() {
 
-		var option PollOption
+		var optionID, optionValue *string
+		var optionPosition *int
+		var optionIsCorrect *bool
+		var optionPayload []byte
 
 		switch {
 		case first:
@@ -155,9 +276,25 @@ func (p PollModel) Get(id string) (*Poll, error) {
 				&poll.ExpiresAt.Time,
 				&poll.ResultsVisibility,
 				&poll.IsPrivate,
-				&option.ID,
-				&option.Value,
-				&option.Position,
+				&poll.PollType,
+				&poll.AllowWriteIn,
+				&poll.Series,
+				&scale,
+				&ownerID,
+				&apiKeyID,
+				&poll.VoterIssuer,
+				&poll.VoterEmailDomain,
+				&poll.VoterAuth,
+				&poll.PublicBallots,
+				&orgID,
+				&poll.SeriesID,
+				&poll.DeletedAt,
+				&poll.HiddenAt,
+				&optionID,
+				&optionValue,
+				&optionPosition,
+				&optionIsCorrect,
+				&optionPayload,
 			)
 		default:
 			err = rows.Scan(
@@ -169,9 +306,25 @@ func (p PollModel) Get(id string) (*Poll, error) {
 				nil,
 				nil,
 				nil,
-				&option.ID,
-				&option.Value,
-				&option.Position,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				&optionID,
+				&optionValue,
+				&optionPosition,
+				&optionIsCorrect,
+				&optionPayload,
 			)
 		}
 
@@ -179,7 +332,20 @@ func (p PollModel) Get(id string) (*Poll, error) {
 			return nil, fmt.Errorf("get poll - scan: %w", err)
 		}
 
-		options = append(options, &option)
+		if optionID != nil {
+			option := &PollOption{
+				ID:        *optionID,
+				Value:     *optionValue,
+				Position:  *optionPosition,
+				IsCorrect: *optionIsCorrect,
+			}
+			if optionPayload != nil {
+				if err := json.Unmarshal(optionPayload, &option.Slot); err != nil {
+					return nil, fmt.Errorf("get poll - unmarshal payload: %w", err)
+				}
+			}
+			options = append(options, option)
+		}
 		first = false
 	}
 
@@ -187,21 +353,37 @@ func (p PollModel) Get(id string) (*Poll, error) {
 		return nil, fmt.Errorf("get poll: %w", err)
 	}
 
-	if len(options) == 0 {
+	if first {
 		return nil, ErrRecordNotFound
 	}
 
+	if scale != nil {
+		if err := json.Unmarshal(scale, &poll.Scale); err != nil {
+			return nil, fmt.Errorf("get poll - unmarshal scale: %w", err)
+		}
+	}
+
+	if ownerID != nil {
+		poll.OwnerID = *ownerID
+	}
+	if apiKeyID != nil {
+		poll.APIKeyID = *apiKeyID
+	}
+	if orgID != nil {
+		poll.OrgID = *orgID
+	}
+
 	poll.Options = options
 
 	return &poll, nil
 }
 
-func (p PollModel) Update(poll *Poll) error {
+func (p PollModel) Update(ctx context.Context, poll *Poll, revision *Revision) error {
 	queryPoll := `
 		UPDATE polls
 		SET question = $1, description = $2, 
-		expires_at = $3, updated_at = NOW()
-		WHERE id = $4
+		expires_at = $3, allow_write_in = $4, updated_at = NOW()
+		WHERE id = $5 AND deleted_at IS NULL
 		RETURNING updated_at;
 	`
 
@@ -209,31 +391,276 @@ func (p PollModel) Update(poll *Poll) error {
 		poll.Question,
 		poll.Description,
 		poll.ExpiresAt.Time,
+		poll.AllowWriteIn,
 		poll.ID,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("update poll: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, queryPoll, args...).Scan(&poll.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRecordNotFound
+		}
+		return fmt.Errorf("update poll: %w", err)
+	}
+
+	if err := revision.record(ctx, tx, poll.ID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("update poll: %w", err)
+	}
+
+	return nil
+}
+
+// Restore puts the poll back the way the snapshot has it. Options missing
+// from the snapshot are deleted and deleted ones come back with their old
+// IDs, so it's only safe before anyone has voted.
+func (p PollModel) Restore(ctx context.Context, poll *Poll, revision *Revision) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	return p.DB.QueryRow(ctx, queryPoll, args...).Scan(&poll.UpdatedAt)
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("restore poll: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	queryPoll := `
+		UPDATE polls
+		SET question = $1, description = $2,
+		expires_at = $3, allow_write_in = $4, updated_at = NOW()
+		WHERE id = $5 AND deleted_at IS NULL
+		RETURNING updated_at;
+	`
+	err = tx.QueryRow(
+		ctx, queryPoll, poll.Question, poll.Description, poll.ExpiresAt.Time, poll.AllowWriteIn, poll.ID,
+	).Scan(&poll.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRecordNotFound
+		}
+		return fmt.Errorf("restore poll: %w", err)
+	}
+
+	ids := make([]string, 0, len(poll.Options))
+	for _, opt := range poll.Options {
+		ids = append(ids, opt.ID)
+	}
+
+	// options may swap values on the way back
+	_, err = tx.Exec(ctx, "SET CONSTRAINTS poll_options_poll_id_normalized_value_key DEFERRED;")
+	if err != nil {
+		return fmt.Errorf("restore poll - defer constraints: %w", err)
+	}
+
+	queryDelete := `
+		DELETE FROM poll_options
+		WHERE poll_id = $1 AND NOT (id = ANY($2::uuid[]));
+	`
+	_, err = tx.Exec(ctx, queryDelete, poll.ID, ids)
+	if err != nil {
+		return fmt.Errorf("restore poll - delete options: %w", err)
+	}
+
+	queryOption := `
+		INSERT INTO poll_options (id, poll_id, value, position, vote_count, is_correct, payload)
+		VALUES ($1, $2, $3, $4, 0, $5, $6)
+		ON CONFLICT (id) DO UPDATE
+		SET value = EXCLUDED.value, position = EXCLUDED.position,
+		is_correct = EXCLUDED.is_correct, payload = EXCLUDED.payload;
+	`
+	for _, opt := range poll.Options {
+		payload, err := opt.payload()
+		if err != nil {
+			return fmt.Errorf("restore poll - payload: %w", err)
+		}
+		_, err = tx.Exec(ctx, queryOption, opt.ID, poll.ID, opt.Value, opt.Position, opt.IsCorrect, payload)
+		if err != nil {
+			return fmt.Errorf("restore poll - option: %w", err)
+		}
+	}
+
+	if err := revision.record(ctx, tx, poll.ID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDuplicateOption
+		}
+		return fmt.Errorf("restore poll: %w", err)
+	}
+
+	return nil
 }
 
-func (p PollModel) Delete(id string) error {
+// Delete hides the poll until it's restored or purged. Its options, votes
+// and tokens are kept until then.
+func (p PollModel) Delete(ctx context.Context, id string, revision *Revision) error {
 	if id == "" {
 		return ErrRecordNotFound
 	}
 
+	query := `
+		UPDATE polls
+		SET deleted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("delete poll: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("delete poll: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	err = revision.record(ctx, tx, id, &RevisionChange{Field: "deleted", Before: false, After: true})
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("delete poll: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeleted looks up a poll that was deleted but not purged yet. Only
+// what's needed to authorize a restore is returned.
+func (p PollModel) GetDeleted(ctx context.Context, id string) (*Poll, error) {
+	query := `
+		SELECT id, question, owner_id, api_key_id, org_id, deleted_at
+		FROM polls
+		WHERE id = $1 AND deleted_at IS NOT NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var poll Poll
+	var ownerID, apiKeyID, orgID *string
+	err := p.DB.QueryRow(ctx, query, id).Scan(
+		&poll.ID, &poll.Question, &ownerID, &apiKeyID, &orgID, &poll.DeletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get deleted poll: %w", err)
+	}
+
+	if ownerID != nil {
+		poll.OwnerID = *ownerID
+	}
+	if apiKeyID != nil {
+		poll.APIKeyID = *apiKeyID
+	}
+	if orgID != nil {
+		poll.OrgID = *orgID
+	}
+
+	return &poll, nil
+}
+
+// Undelete brings back a poll deleted after the given time. Polls deleted
+// earlier are left for Purge.
+func (p PollModel) Undelete(ctx context.Context, id string, deletedAfter time.Time, revision *Revision) error {
+	query := `
+		UPDATE polls
+		SET deleted_at = NULL
+		WHERE id = $1 AND deleted_at > $2;
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("undelete poll: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, query, id, deletedAfter)
+	if err != nil {
+		return fmt.Errorf("undelete poll: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	err = revision.record(ctx, tx, id, &RevisionChange{Field: "deleted", Before: true, After: false})
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("undelete poll: %w", err)
+	}
+
+	return nil
+}
+
+// Purge removes polls deleted before the given time for good, along with
+// everything that belongs to them. Hidden polls are kept for the operators
+// to decide on.
+func (p PollModel) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
 	query := `
 		DELETE FROM polls
+		WHERE deleted_at <= $1 AND hidden_at IS NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	result, err := p.DB.Exec(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("purge polls: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// Hide takes a poll down for everyone but the operators, whether or not
+// its owner deleted it. Restoring a deleted poll doesn't bring back a
+// hidden one, only Unhide does.
+func (p PollModel) Hide(ctx context.Context, id string) error {
+	query := `
+		UPDATE polls
+		SET hidden_at = COALESCE(hidden_at, NOW())
 		WHERE id = $1;
 	`
 
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
 	result, err := p.DB.Exec(ctx, query, id)
 	if err != nil {
-		return fmt.Errorf("delete poll: %w", err)
+		return fmt.Errorf("hide poll: %w", err)
 	}
 
 	if result.RowsAffected() == 0 {
@@ -243,26 +670,98 @@ func (p PollModel) Delete(id string) error {
 	return nil
 }
 
-func (p PollModel) GetAll(search string, filters Filters) ([]*Poll, Metadata, error) {
+func (p PollModel) Unhide(ctx context.Context, id string) error {
+	query := `
+		UPDATE polls
+		SET hidden_at = NULL
+		WHERE id = $1 AND hidden_at IS NOT NULL;
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	result, err := p.DB.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("unhide poll: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Erase removes a poll for good, whether it was deleted or not.
+func (p PollModel) Erase(ctx context.Context, id string) error {
+	query := `
+		DELETE FROM polls
+		WHERE id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	result, err := p.DB.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("erase poll: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (p PollModel) GetAll(ctx context.Context, search string, filters Filters) ([]*Poll, Metadata, error) {
+	where := `
+		(to_tsvector('simple', question) @@ plainto_tsquery('simple', $1) OR $1 = '') 
+		AND p.is_private = false AND p.hidden_at IS NULL`
+	return p.getAll(ctx, where, []any{search}, filters)
+}
+
+// GetAllIncludingPrivate lists every poll, private and hidden ones
+// included. It's meant for operators, not for the API.
+func (p PollModel) GetAllIncludingPrivate(ctx context.Context, search string, filters Filters) ([]*Poll, Metadata, error) {
+	where := `to_tsvector('simple', question) @@ plainto_tsquery('simple', $1) OR $1 = ''`
+	return p.getAll(ctx, where, []any{search}, filters)
+}
+
+// GetAllForOwner lists every poll owned by a user, private ones included.
+func (p PollModel) GetAllForOwner(ctx context.Context, ownerID string, filters Filters) ([]*Poll, Metadata, error) {
+	return p.getAll(ctx, "p.owner_id = $1 AND p.hidden_at IS NULL", []any{ownerID}, filters)
+}
+
+// GetAllForOrg lists every poll of an organization, private ones included.
+func (p PollModel) GetAllForOrg(ctx context.Context, orgID string, search string, filters Filters) ([]*Poll, Metadata, error) {
+	where := `
+		p.org_id = $1 AND p.hidden_at IS NULL
+		AND (to_tsvector('simple', question) @@ plainto_tsquery('simple', $2) OR $2 = '')`
+	return p.getAll(ctx, where, []any{orgID, search}, filters)
+}
+
+func (p PollModel) getAll(ctx context.Context, where string, args []any, filters Filters) ([]*Poll, Metadata, error) {
 	query := fmt.Sprintf(`
 		SELECT count(*) OVER(), p.id, p.question, p.description, 
-		p.created_at, p.updated_at, p.expires_at, p.results_visibility,
-	    jsonb_agg(jsonb_build_object(
-			'id', po.id, 'value', po.value, 'position', po.position
-			)) AS options
+		p.created_at, p.updated_at, p.expires_at, p.results_visibility, p.is_private,
+		p.poll_type, p.allow_write_in, p.series, COALESCE(p.series_id::text, ''), p.scale,
+		COALESCE(p.org_id::text, ''), p.hidden_at,
+	    COALESCE(jsonb_agg(jsonb_build_object(
+			'id', po.id, 'value', po.value, 'position', po.position, 'slot', po.payload
+			)) FILTER (WHERE po.id IS NOT NULL), '[]') AS options
 		FROM polls p
-		JOIN poll_options po ON po.poll_id = p.id 
-		WHERE (to_tsvector('simple', question) @@ plainto_tsquery('simple', $1) OR $1 = '') 
-		AND p.is_private = false
+		LEFT JOIN poll_options po ON po.poll_id = p.id 
+		WHERE p.deleted_at IS NULL AND (%s)
 		GROUP BY p.id
 		ORDER BY %s %s, id ASC
-		LIMIT $2 OFFSET $3;
-	`, filters.sortColumn(), filters.sortDirection())
+		LIMIT $%d OFFSET $%d;
+	`, where, filters.sortColumn(), filters.sortDirection(), len(args)+1, len(args)+2)
 
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := p.DB.Query(ctx, query, search, filters.limit(), filters.offset())
+	rows, err := p.DB.Query(ctx, query, append(args, filters.limit(), filters.offset())...)
 	if err != nil {
 		return nil, Metadata{}, fmt.Errorf("get all polls: %w", err)
 	}
@@ -274,6 +773,7 @@ func (p PollModel) GetAll(search string, filters Filters) ([]*Poll, Metadata, er
 	for rows.Next() {
 		var poll Poll
 		var optionsJson string
+		var scale []byte
 		err := rows.Scan(
 			&totalRecords,
 			&poll.ID,
@@ -283,6 +783,14 @@ func (p PollModel) GetAll(search string, filters Filters) ([]*Poll, Metadata, er
 			&poll.UpdatedAt,
 			&poll.ExpiresAt.Time,
 			&poll.ResultsVisibility,
+			&poll.IsPrivate,
+			&poll.PollType,
+			&poll.AllowWriteIn,
+			&poll.Series,
+			&poll.SeriesID,
+			&scale,
+			&poll.OrgID,
+			&poll.HiddenAt,
 			&optionsJson,
 		)
 		if err != nil {
@@ -292,6 +800,11 @@ func (p PollModel) GetAll(search string, filters Filters) ([]*Poll, Metadata, er
 		if err := json.Unmarshal([]byte(optionsJson), &poll.Options); err != nil {
 			return nil, Metadata{}, fmt.Errorf("get polls - unmarshal options: %w", err)
 		}
+		if scale != nil {
+			if err := json.Unmarshal(scale, &poll.Scale); err != nil {
+				return nil, Metadata{}, fmt.Errorf("get polls - unmarshal scale: %w", err)
+			}
+		}
 		polls = append(polls, &poll)
 	}
 
@@ -304,13 +817,13 @@ func (p PollModel) GetAll(search string, filters Filters) ([]*Poll, Metadata, er
 	return polls, metadata, nil
 }
 
-func (p PollModel) GetVotedIPs(pollID string) ([]*net.IP, error) {
+func (p PollModel) GetVotedIPs(ctx context.Context, pollID string) ([]*net.IP, error) {
 	query := `
 		SELECT ip
 		FROM ips
 		WHERE poll_id = $1;
 	`
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 	rows, err := p.DB.Query(ctx, query, pollID)
 	if err != nil {
@@ -337,26 +850,152 @@ func (p PollModel) GetVotedIPs(pollID string) ([]*net.IP, error) {
 	return ips, nil
 }
 
-func (p PollModel) CheckToken(tokenPlaintext string) (string, error) {
+// HasVotes reports whether anyone has voted on or answered the poll. Open
+// text responses don't count towards any option's vote count, so they and
+// the recorded IPs are checked as well.
+func (p PollModel) HasVotes(ctx context.Context, pollID string) (bool, error) {
+	query := `
+		SELECT
+			EXISTS (SELECT 1 FROM poll_options WHERE poll_id = $1 AND vote_count > 0)
+			OR EXISTS (SELECT 1 FROM poll_responses WHERE poll_id = $1)
+			OR EXISTS (SELECT 1 FROM ips WHERE poll_id = $1);
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var voted bool
+	err := p.DB.QueryRow(ctx, query, pollID).Scan(&voted)
+	if err != nil {
+		return false, fmt.Errorf("has votes: %w", err)
+	}
+
+	return voted, nil
+}
+
+// PurgeIPs forgets who voted on a poll, so the same IPs can vote again.
+// The votes themselves are kept.
+func (p PollModel) PurgeIPs(ctx context.Context, pollID string) (int64, error) {
+	query := `
+		DELETE FROM ips
+		WHERE poll_id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	result, err := p.DB.Exec(ctx, query, pollID)
+	if err != nil {
+		return 0, fmt.Errorf("purge ips: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// Stats are counted over the whole service. Deleted polls are only counted
+// in DeletedPolls until they are purged.
+type Stats struct {
+	Polls        int `json:"polls"`
+	PrivatePolls int `json:"private_polls"`
+	OpenPolls    int `json:"open_polls"`
+	DeletedPolls int `json:"deleted_polls"`
+	Votes        int `json:"votes"`
+	VotesLastDay int `json:"votes_last_day"`
+}
+
+func (p PollModel) GetStats(ctx context.Context) (*Stats, error) {
+	queryPolls := `
+		SELECT
+			count(*) FILTER (WHERE deleted_at IS NULL),
+			count(*) FILTER (WHERE deleted_at IS NULL AND is_private),
+			count(*) FILTER (WHERE deleted_at IS NULL AND (expires_at > NOW() OR expires_at = $1)),
+			count(*) FILTER (WHERE deleted_at IS NOT NULL)
+		FROM polls;
+	`
+	queryVotes := `
+		SELECT count(*), count(*) FILTER (WHERE created_at > NOW() - INTERVAL '1 day')
+		FROM ips;
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var stats Stats
+	// polls that never expire have a zero expires_at
+	err := p.DB.QueryRow(ctx, queryPolls, time.Time{}).Scan(
+		&stats.Polls, &stats.PrivatePolls, &stats.OpenPolls, &stats.DeletedPolls,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get stats - polls: %w", err)
+	}
+
+	err = p.DB.QueryRow(ctx, queryVotes).Scan(&stats.Votes, &stats.VotesLastDay)
+	if err != nil {
+		return nil, fmt.Errorf("get stats - votes: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// Turnout is only shown to owners. LastVoteAt is nil when no votes were
+// cast, or when they were cast before vote times were recorded.
+type Turnout struct {
+	Ballots     int        `json:"ballots"`
+	DistinctIPs int        `json:"distinct_ips"`
+	LastVoteAt  *time.Time `json:"last_vote_at"`
+}
+
+func (p PollModel) GetTurnout(ctx context.Context, pollID string) (*Turnout, error) {
+	query := `
+		SELECT count(*), count(DISTINCT ip), max(created_at)
+		FROM ips
+		WHERE poll_id = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var turnout Turnout
+	err := p.DB.QueryRow(ctx, query, pollID).Scan(
+		&turnout.Ballots, &turnout.DistinctIPs, &turnout.LastVoteAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get turnout: %w", err)
+	}
+
+	return &turnout, nil
+}
+
+// CheckToken looks up an unexpired token and records that it was used.
+func (p PollModel) CheckToken(ctx context.Context, tokenPlaintext string) (*PollToken, error) {
 	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
 
 	query := `
-			SELECT poll_id
-			FROM tokens
-			WHERE hash = $1;
+			UPDATE tokens
+			SET last_used_at = NOW()
+			WHERE hash = $1 AND (expiry IS NULL OR expiry > NOW())
+			RETURNING id, poll_id, label, scopes, expiry, last_used_at, created_at;
 		`
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 	row := p.DB.QueryRow(ctx, query, tokenHash[:])
 
-	var pollID string
-	err := row.Scan(&pollID)
+	var token PollToken
+	err := row.Scan(
+		&token.ID,
+		&token.PollID,
+		&token.Label,
+		&token.Scopes,
+		&token.Expiry,
+		&token.LastUsedAt,
+		&token.CreatedAt,
+	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return "", ErrRecordNotFound
+			return nil, ErrRecordNotFound
 		}
-		return "", fmt.Errorf("check token: %w", err)
+		return nil, fmt.Errorf("check token: %w", err)
 	}
 
-	return pollID, nil
+	return &token, nil
 }
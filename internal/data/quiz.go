@@ -0,0 +1,136 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type QuizAnswer struct {
+	PollID    string    `json:"poll_id"`
+	OptionID  string    `json:"option_id"`
+	Voter     string    `json:"voter"`
+	Correct   bool      `json:"correct"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ErrSeriesWithoutOwner is returned for a quiz series on a poll nobody
+// owns, which would let anyone add to the leaderboard.
+var ErrSeriesWithoutOwner = errors.New("series without owner")
+
+// QuizSeries groups the quizzes of one user, organization or API key that
+// share a leaderboard.
+type QuizSeries struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type LeaderboardEntry struct {
+	Voter    string `json:"voter"`
+	Score    int    `json:"score"`
+	Answered int    `json:"answered"`
+}
+
+type QuizAnswerModel struct {
+	DB *pgxpool.Pool
+}
+
+// Vote counts the voter's vote for the answer's option and stores the
+// answer for the leaderboard in one transaction, so a counted vote can't be
+// missing from the leaderboard. voter is nil for anonymous votes.
+func (q QuizAnswerModel) Vote(ctx context.Context, answer *QuizAnswer, ip string, voter *Voter) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tx, err := q.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("insert quiz answer: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err = castVote(ctx, tx, answer.OptionID, answer.PollID, ip, voter); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO quiz_answers (poll_id, option_id, voter, correct)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at;
+	`
+	args := []any{answer.PollID, answer.OptionID, answer.Voter, answer.Correct}
+	err = tx.QueryRow(ctx, query, args...).Scan(&answer.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert quiz answer: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("insert quiz answer: %w", err)
+	}
+
+	return nil
+}
+
+// Leaderboard ranks voters by their correct answers across every closed
+// quiz in a series. Open quizzes are left out so the scores don't give
+// away the answers.
+func (q QuizAnswerModel) Leaderboard(seriesID string) (*QuizSeries, []*LeaderboardEntry, error) {
+	querySeries := `
+		SELECT id, name
+		FROM quiz_series
+		WHERE id = $1;
+	`
+	query := `
+		SELECT qa.voter, count(*) FILTER (WHERE qa.correct), count(*)
+		FROM quiz_answers qa
+		JOIN polls p ON p.id = qa.poll_id
+		WHERE p.series_id = $1 AND p.poll_type = $2 AND p.expires_at < NOW()
+		AND p.deleted_at IS NULL AND p.hidden_at IS NULL
+		GROUP BY qa.voter
+		ORDER BY 2 DESC, 3 ASC, qa.voter ASC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var series QuizSeries
+	err := q.DB.QueryRow(ctx, querySeries, seriesID).Scan(&series.ID, &series.Name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrRecordNotFound
+		}
+		return nil, nil, fmt.Errorf("get leaderboard - series: %w", err)
+	}
+
+	rows, err := q.DB.Query(ctx, query, seriesID, PollTypeQuiz)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*LeaderboardEntry{}
+
+	for rows.Next() {
+		var entry LeaderboardEntry
+		err := rows.Scan(&entry.Voter, &entry.Score, &entry.Answered)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get leaderboard - scan: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("get leaderboard: %w", err)
+	}
+
+	return &series, entries, nil
+}
+
+func ValidateVoterName(v *validator.Validator, voter string) {
+	v.Check(voter != "", "voter", "must be provided")
+	v.Check(len(voter) <= 100, "voter", "must not be more than 100 bytes long")
+}
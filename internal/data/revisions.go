@@ -0,0 +1,343 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	RevisionCreate          = "create"
+	RevisionUpdatePoll      = "update_poll"
+	RevisionAddOption       = "add_option"
+	RevisionUpdateOption    = "update_option"
+	RevisionReorderOptions  = "reorder_options"
+	RevisionDeleteOption    = "delete_option"
+	RevisionSetAnswers      = "set_answers"
+	RevisionPromoteResponse = "promote_response"
+	RevisionRestore         = "restore"
+	RevisionDelete          = "delete"
+	RevisionUndelete        = "undelete"
+)
+
+// Revision records one change to a poll, who made it and what it changed.
+// The snapshot is the poll as it was after the change, which is what a
+// restore goes back to.
+type Revision struct {
+	ID        string            `json:"id"`
+	Action    string            `json:"action"`
+	Actor     string            `json:"actor"`
+	Changes   []*RevisionChange `json:"changes"`
+	CreatedAt time.Time         `json:"created_at"`
+	Snapshot  *PollSnapshot     `json:"-"`
+	before    *PollSnapshot
+}
+
+// NewRevision starts the revision of a change to a poll by actor. The model
+// making the change stores it in the same transaction, so there's never a
+// change without its revision. before is nil for a new poll.
+func NewRevision(action, actor string, before *PollSnapshot) *Revision {
+	return &Revision{Action: action, Actor: actor, before: before}
+}
+
+type RevisionChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// PollSnapshot holds the editable parts of a poll.
+type PollSnapshot struct {
+	Question     string            `json:"question"`
+	Description  string            `json:"description"`
+	ExpiresAt    time.Time         `json:"expires_at"`
+	AllowWriteIn bool              `json:"allow_write_in"`
+	Options      []*OptionSnapshot `json:"options"`
+}
+
+type OptionSnapshot struct {
+	ID        string    `json:"id"`
+	Value     string    `json:"value"`
+	Position  int       `json:"position"`
+	IsCorrect bool      `json:"is_correct"`
+	Slot      *TimeSlot `json:"slot,omitempty"`
+}
+
+func NewPollSnapshot(poll *Poll) *PollSnapshot {
+	snapshot := &PollSnapshot{
+		Question:     poll.Question,
+		Description:  poll.Description,
+		ExpiresAt:    poll.ExpiresAt.Time,
+		AllowWriteIn: poll.AllowWriteIn,
+		Options:      make([]*OptionSnapshot, 0, len(poll.Options)),
+	}
+	for _, opt := range poll.Options {
+		snapshot.Options = append(snapshot.Options, &OptionSnapshot{
+			ID:        opt.ID,
+			Value:     opt.Value,
+			Position:  opt.Position,
+			IsCorrect: opt.IsCorrect,
+			Slot:      opt.Slot,
+		})
+	}
+	return snapshot
+}
+
+// Apply sets the editable parts of the poll to the snapshot.
+func (s *PollSnapshot) Apply(poll *Poll) {
+	poll.Question = s.Question
+	poll.Description = s.Description
+	poll.ExpiresAt = ExpiresAt{s.ExpiresAt}
+	poll.AllowWriteIn = s.AllowWriteIn
+	poll.Options = make([]*PollOption, 0, len(s.Options))
+	for _, opt := range s.Options {
+		poll.Options = append(poll.Options, &PollOption{
+			ID:        opt.ID,
+			Value:     opt.Value,
+			Position:  opt.Position,
+			IsCorrect: opt.IsCorrect,
+			Slot:      opt.Slot,
+		})
+	}
+}
+
+// DiffSnapshots lists the fields that differ between two snapshots. Options
+// are matched by ID, so added and removed options have a nil side. A nil
+// before snapshot diffs against an empty poll.
+func DiffSnapshots(before, after *PollSnapshot) []*RevisionChange {
+	if before == nil {
+		before = &PollSnapshot{}
+	}
+
+	changes := []*RevisionChange{}
+	add := func(field string, b, a any) {
+		changes = append(changes, &RevisionChange{Field: field, Before: b, After: a})
+	}
+
+	if before.Question != after.Question {
+		add("question", before.Question, after.Question)
+	}
+	if before.Description != after.Description {
+		add("description", before.Description, after.Description)
+	}
+	if !before.ExpiresAt.Equal(after.ExpiresAt) {
+		add("expires_at", timeOrNil(before.ExpiresAt), timeOrNil(after.ExpiresAt))
+	}
+	if before.AllowWriteIn != after.AllowWriteIn {
+		add("allow_write_in", before.AllowWriteIn, after.AllowWriteIn)
+	}
+
+	old := make(map[string]*OptionSnapshot, len(before.Options))
+	for _, opt := range before.Options {
+		old[opt.ID] = opt
+	}
+	for _, opt := range after.Options {
+		field := "options." + opt.ID
+		prev, ok := old[opt.ID]
+		if !ok {
+			add(field, nil, opt)
+			continue
+		}
+		delete(old, opt.ID)
+		if prev.Value != opt.Value {
+			add(field+".value", prev.Value, opt.Value)
+		}
+		if prev.Position != opt.Position {
+			add(field+".position", prev.Position, opt.Position)
+		}
+		if prev.IsCorrect != opt.IsCorrect {
+			add(field+".is_correct", prev.IsCorrect, opt.IsCorrect)
+		}
+	}
+	for _, opt := range before.Options {
+		if _, removed := old[opt.ID]; removed {
+			add("options."+opt.ID, opt, nil)
+		}
+	}
+
+	return changes
+}
+
+func timeOrNil(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+type RevisionModel struct {
+	DB *pgxpool.Pool
+}
+
+// record stores the revision in tx. The poll is read back inside tx, so the
+// snapshot holds what the change stored. changes stand in for the diff
+// against the poll from before, for changes a snapshot doesn't show. A nil
+// revision records nothing.
+func (r *Revision) record(ctx context.Context, tx pgx.Tx, pollID string, changes ...*RevisionChange) error {
+	if r == nil {
+		return nil
+	}
+
+	after, err := snapshotPoll(ctx, tx, pollID)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		changes = DiffSnapshots(r.before, after)
+	}
+	r.Changes = changes
+	r.Snapshot = after
+
+	query := `
+		INSERT INTO poll_revisions (poll_id, action, actor, changes, snapshot)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at;
+	`
+
+	changesJSON, err := json.Marshal(r.Changes)
+	if err != nil {
+		return fmt.Errorf("insert revision - changes: %w", err)
+	}
+	snapshotJSON, err := json.Marshal(r.Snapshot)
+	if err != nil {
+		return fmt.Errorf("insert revision - snapshot: %w", err)
+	}
+
+	err = tx.QueryRow(
+		ctx, query, pollID, r.Action, r.Actor, changesJSON, snapshotJSON,
+	).Scan(&r.ID, &r.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert revision: %w", err)
+	}
+
+	return nil
+}
+
+func snapshotPoll(ctx context.Context, tx pgx.Tx, pollID string) (*PollSnapshot, error) {
+	query := `
+		SELECT question, description, expires_at, allow_write_in
+		FROM polls
+		WHERE id = $1;
+	`
+
+	var snapshot PollSnapshot
+	err := tx.QueryRow(ctx, query, pollID).Scan(
+		&snapshot.Question, &snapshot.Description, &snapshot.ExpiresAt, &snapshot.AllowWriteIn,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot poll: %w", err)
+	}
+
+	queryOptions := `
+		SELECT id, value, position, is_correct, payload
+		FROM poll_options
+		WHERE poll_id = $1
+		ORDER BY position;
+	`
+
+	rows, err := tx.Query(ctx, queryOptions, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot poll - options: %w", err)
+	}
+	defer rows.Close()
+
+	snapshot.Options = []*OptionSnapshot{}
+	for rows.Next() {
+		var opt OptionSnapshot
+		var payload []byte
+		if err := rows.Scan(&opt.ID, &opt.Value, &opt.Position, &opt.IsCorrect, &payload); err != nil {
+			return nil, fmt.Errorf("snapshot poll - scan: %w", err)
+		}
+		if payload != nil {
+			if err := json.Unmarshal(payload, &opt.Slot); err != nil {
+				return nil, fmt.Errorf("snapshot poll - unmarshal payload: %w", err)
+			}
+		}
+		snapshot.Options = append(snapshot.Options, &opt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("snapshot poll - options: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// GetAllForPoll lists the revisions of a poll, newest first.
+func (rm RevisionModel) GetAllForPoll(pollID string) ([]*Revision, error) {
+	query := `
+		SELECT id, action, actor, changes, snapshot, created_at
+		FROM poll_revisions
+		WHERE poll_id = $1
+		ORDER BY seq DESC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := rm.DB.Query(ctx, query, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []*Revision{}
+
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("get revisions - scan: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("get revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (rm RevisionModel) Get(id string, pollID string) (*Revision, error) {
+	query := `
+		SELECT id, action, actor, changes, snapshot, created_at
+		FROM poll_revisions
+		WHERE id = $1 AND poll_id = $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	revision, err := scanRevision(rm.DB.QueryRow(ctx, query, id, pollID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get revision: %w", err)
+	}
+
+	return revision, nil
+}
+
+func scanRevision(row pgx.Row) (*Revision, error) {
+	var revision Revision
+	var changes, snapshot []byte
+	err := row.Scan(
+		&revision.ID, &revision.Action, &revision.Actor, &changes, &snapshot, &revision.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(changes, &revision.Changes); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(snapshot, &revision.Snapshot); err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
@@ -0,0 +1,244 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	AnswerYes      = "yes"
+	AnswerIfNeedBe = "if_need_be"
+	AnswerNo       = "no"
+)
+
+var availabilityAnswerSafelist = []string{AnswerYes, AnswerIfNeedBe, AnswerNo}
+
+// TimeSlot is the payload of a schedule poll option. Start and End are
+// rendered in TimeZone.
+type TimeSlot struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	TimeZone string    `json:"time_zone"`
+}
+
+func (s TimeSlot) MarshalJSON() ([]byte, error) {
+	type slot TimeSlot
+	out := slot(s)
+	if loc, err := time.LoadLocation(s.TimeZone); err == nil {
+		out.Start = s.Start.In(loc)
+		out.End = s.End.In(loc)
+	}
+	return json.Marshal(out)
+}
+
+// In returns the slot rendered in another time zone.
+func (s TimeSlot) In(loc *time.Location) *TimeSlot {
+	return &TimeSlot{
+		Start:    s.Start.In(loc),
+		End:      s.End.In(loc),
+		TimeZone: loc.String(),
+	}
+}
+
+// Label is used as the option value of a slot when the owner doesn't
+// provide one.
+func (s TimeSlot) Label() string {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	start, end := s.Start.In(loc), s.End.In(loc)
+	endLayout := "15:04"
+	if start.YearDay() != end.YearDay() || start.Year() != end.Year() {
+		endLayout = "2006-01-02 15:04"
+	}
+	return fmt.Sprintf(
+		"%s - %s (%s)", start.Format("2006-01-02 15:04"), end.Format(endLayout), loc.String(),
+	)
+}
+
+func ValidateTimeSlots(v *validator.Validator, slots []*TimeSlot) {
+	for _, s := range slots {
+		v.Check(!s.Start.IsZero() && !s.End.IsZero(), "options", "time slots must have a start and end")
+		v.Check(s.End.After(s.Start), "options", "time slot end must be after its start")
+		_, err := time.LoadLocation(s.TimeZone)
+		v.Check(s.TimeZone != "" && err == nil, "options", "time slots must have a valid time_zone")
+	}
+
+	sorted := make([]*TimeSlot, len(slots))
+	copy(sorted, slots)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+	for i := 1; i < len(sorted); i++ {
+		prev, cur := sorted[i-1], sorted[i]
+		v.Check(
+			!(cur.Start.Equal(prev.Start) && cur.End.Equal(prev.End)),
+			"options",
+			"must not contain duplicate time slots",
+		)
+		v.Check(!cur.Start.Before(prev.End), "options", "time slots must not overlap")
+	}
+}
+
+type AvailabilityAnswer struct {
+	OptionID string `json:"option_id"`
+	Answer   string `json:"answer"`
+}
+
+type AvailabilityBallot struct {
+	Voter   string               `json:"voter"`
+	Answers []AvailabilityAnswer `json:"answers"`
+}
+
+func ValidateAvailabilityBallot(v *validator.Validator, ballot *AvailabilityBallot, poll *Poll) {
+	ValidateVoterName(v, ballot.Voter)
+	v.Check(len(ballot.Answers) > 0, "answers", "must be provided")
+
+	optionIDs := make(map[string]bool, len(poll.Options))
+	for _, opt := range poll.Options {
+		optionIDs[opt.ID] = true
+	}
+
+	var answered []string
+	for _, a := range ballot.Answers {
+		v.Check(optionIDs[a.OptionID], "answers", "must only contain options of this poll")
+		v.Check(
+			validator.PermittedValue(a.Answer, availabilityAnswerSafelist...),
+			"answers",
+			"answer must be one of yes, if_need_be or no",
+		)
+		answered = append(answered, a.OptionID)
+	}
+	v.Check(validator.Unique(answered), "answers", "must not answer the same option twice")
+}
+
+type SlotTally struct {
+	OptionID string `json:"option_id"`
+	Yes      int    `json:"yes"`
+	IfNeedBe int    `json:"if_need_be"`
+	No       int    `json:"no"`
+}
+
+// RankSlots orders tallies by availability: most yes answers first, then
+// most if_need_be answers.
+func RankSlots(tallies []*SlotTally) {
+	sort.SliceStable(tallies, func(i, j int) bool {
+		if tallies[i].Yes != tallies[j].Yes {
+			return tallies[i].Yes > tallies[j].Yes
+		}
+		return tallies[i].IfNeedBe > tallies[j].IfNeedBe
+	})
+}
+
+type AvailabilityModel struct {
+	DB *pgxpool.Pool
+}
+
+// Insert stores a ballot, counts it towards the vote count of every slot
+// it answers and records the voter's IP.
+func (a AvailabilityModel) Insert(ballot *AvailabilityBallot, pollID string, ip string) error {
+	var paramIP pgtype.Inet
+	err := paramIP.Set(ip)
+	if err != nil {
+		return fmt.Errorf("insert availability - set ip: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := a.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("insert availability: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO availability (poll_id, option_id, voter, answer)
+		VALUES ($1, $2, $3, $4);
+	`
+	queryCount := `
+		UPDATE poll_options
+		SET vote_count = vote_count + 1
+		WHERE id = $1 AND poll_id = $2;
+	`
+	for _, answer := range ballot.Answers {
+		_, err = tx.Exec(ctx, query, pollID, answer.OptionID, ballot.Voter, answer.Answer)
+		if err != nil {
+			return fmt.Errorf("insert availability: %w", err)
+		}
+		result, err := tx.Exec(ctx, queryCount, answer.OptionID, pollID)
+		if err != nil {
+			return fmt.Errorf("insert availability - count: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return ErrRecordNotFound
+		}
+	}
+
+	queryIP := `
+		INSERT INTO ips (ip, poll_id)
+		VALUES ($1, $2);
+	`
+	_, err = tx.Exec(ctx, queryIP, paramIP, pollID)
+	if err != nil {
+		return fmt.Errorf("insert availability - insert ip: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("insert availability: %w", err)
+	}
+
+	return nil
+}
+
+// GetResults returns a tally for every slot of the poll, ranked by
+// availability.
+func (a AvailabilityModel) GetResults(pollID string) ([]*SlotTally, error) {
+	query := `
+		SELECT po.id,
+		count(av.id) FILTER (WHERE av.answer = $2),
+		count(av.id) FILTER (WHERE av.answer = $3),
+		count(av.id) FILTER (WHERE av.answer = $4)
+		FROM poll_options po
+		LEFT JOIN availability av ON av.option_id = po.id
+		WHERE po.poll_id = $1
+		GROUP BY po.id, po.position
+		ORDER BY po.position ASC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := a.DB.Query(ctx, query, pollID, AnswerYes, AnswerIfNeedBe, AnswerNo)
+	if err != nil {
+		return nil, fmt.Errorf("get availability: %w", err)
+	}
+	defer rows.Close()
+
+	tallies := []*SlotTally{}
+
+	for rows.Next() {
+		var tally SlotTally
+		err := rows.Scan(&tally.OptionID, &tally.Yes, &tally.IfNeedBe, &tally.No)
+		if err != nil {
+			return nil, fmt.Errorf("get availability - scan: %w", err)
+		}
+		tallies = append(tallies, &tally)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("get availability: %w", err)
+	}
+
+	RankSlots(tallies)
+
+	return tallies, nil
+}
@@ -1,18 +1,57 @@
 package data
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base32"
+	"fmt"
+	"time"
 
 	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const (
+	ScopePollEdit     = "poll:edit"
+	ScopePollDelete   = "poll:delete"
+	ScopeResultsRead  = "results:read"
+	ScopeVotersManage = "voters:manage"
+)
+
+// AllScopes are granted to the token created with a poll and to the
+// account that owns it.
+var AllScopes = []string{
+	ScopePollEdit,
+	ScopePollDelete,
+	ScopeResultsRead,
+	ScopeVotersManage,
+}
+
+const maxTokenLabelLength = 100
+
 type Token struct {
 	Plaintext string
 	Hash      []byte
 }
 
+// PollToken describes a stored token. The plaintext is only set right after
+// the token is minted.
+type PollToken struct {
+	ID         string     `json:"id"`
+	PollID     string     `json:"-"`
+	Label      string     `json:"label"`
+	Scopes     []string   `json:"scopes"`
+	Expiry     *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Plaintext  string     `json:"token,omitempty"`
+}
+
+func (t *PollToken) HasScope(scope string) bool {
+	return validator.PermittedValue(scope, t.Scopes...)
+}
+
 func GenerateToken() (*Token, error) {
 	var token Token
 
@@ -34,3 +73,196 @@ func ValidateTokenPlaintext(v *validator.Validator, tokenPlaintext string) {
 	v.Check(tokenPlaintext != "", "token", "must be provided")
 	v.Check(len(tokenPlaintext) == 26, "token", "must be 26 bytes long")
 }
+
+func ValidatePollToken(v *validator.Validator, token *PollToken) {
+	v.Check(len(token.Scopes) > 0, "scopes", "must contain at least one scope")
+	v.Check(validator.Unique(token.Scopes), "scopes", "must not contain duplicate scopes")
+	for _, scope := range token.Scopes {
+		v.Check(validator.PermittedValue(scope, AllScopes...), "scopes", "invalid scope value")
+	}
+	v.Check(
+		len(token.Label) <= maxTokenLabelLength,
+		"label",
+		fmt.Sprintf("must not be more than %d bytes long", maxTokenLabelLength),
+	)
+	if token.Expiry != nil {
+		v.Check(token.Expiry.After(time.Now()), "expires_at", "must be in the future")
+	}
+}
+
+type TokenModel struct {
+	DB *pgxpool.Pool
+}
+
+func (t TokenModel) Insert(token *PollToken, tokenHash []byte) error {
+	query := `
+		INSERT INTO tokens (hash, poll_id, label, scopes, expiry)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at;
+	`
+
+	args := []any{tokenHash, token.PollID, token.Label, token.Scopes, token.Expiry}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	err := t.DB.QueryRow(ctx, query, args...).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert token: %w", err)
+	}
+
+	return nil
+}
+
+// RotateOwner replaces the owner tokens of a poll, the unlabelled ones with
+// every scope like the token a poll is created with, by a new one. Tokens
+// minted for others are kept. It returns how many tokens were revoked.
+func (t TokenModel) RotateOwner(token *PollToken, tokenHash []byte) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := t.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("rotate owner token: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	queryRevoke := `
+		DELETE FROM tokens
+		WHERE poll_id = $1 AND label = '' AND scopes @> $2;
+	`
+	result, err := tx.Exec(ctx, queryRevoke, token.PollID, AllScopes)
+	if err != nil {
+		return 0, fmt.Errorf("rotate owner token - revoke: %w", err)
+	}
+
+	token.Label = ""
+	token.Scopes = AllScopes
+	token.Expiry = nil
+
+	query := `
+		INSERT INTO tokens (hash, poll_id, label, scopes)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at;
+	`
+	err = tx.QueryRow(ctx, query, tokenHash, token.PollID, token.Label, token.Scopes).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("rotate owner token - insert: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("rotate owner token: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// GetAllForPoll lists the tokens of a poll that have not expired yet.
+func (t TokenModel) GetAllForPoll(pollID string) ([]*PollToken, error) {
+	query := `
+		SELECT id, poll_id, label, scopes, expiry, last_used_at, created_at
+		FROM tokens
+		WHERE poll_id = $1 AND (expiry IS NULL OR expiry > NOW())
+		ORDER BY created_at ASC, id ASC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := t.DB.Query(ctx, query, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []*PollToken{}
+
+	for rows.Next() {
+		var token PollToken
+		err := rows.Scan(
+			&token.ID,
+			&token.PollID,
+			&token.Label,
+			&token.Scopes,
+			&token.Expiry,
+			&token.LastUsedAt,
+			&token.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get tokens - scan: %w", err)
+		}
+		tokens = append(tokens, &token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("get tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (t TokenModel) Delete(id string, pollID string) error {
+	query := `
+		DELETE FROM tokens
+		WHERE id = $1 AND poll_id = $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := t.DB.Exec(ctx, query, id, pollID)
+	if err != nil {
+		return fmt.Errorf("delete token: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Deny revokes a signed token until it expires on its own. Entries past
+// their expiry are dropped on the way, so the denylist stays small.
+func (t TokenModel) Deny(jti string, pollID string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	queryPurge := `
+		DELETE FROM revoked_tokens
+		WHERE expires_at < NOW();
+	`
+	_, err := t.DB.Exec(ctx, queryPurge)
+	if err != nil {
+		return fmt.Errorf("deny token - purge: %w", err)
+	}
+
+	query := `
+		INSERT INTO revoked_tokens (jti, poll_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING;
+	`
+	_, err = t.DB.Exec(ctx, query, jti, pollID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("deny token: %w", err)
+	}
+
+	return nil
+}
+
+func (t TokenModel) IsDenied(jti string) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var denied bool
+	err := t.DB.QueryRow(ctx, query, jti).Scan(&denied)
+	if err != nil {
+		return false, fmt.Errorf("check denied token: %w", err)
+	}
+
+	return denied, nil
+}
@@ -0,0 +1,272 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrDuplicateEmail = errors.New("duplicate email")
+
+const (
+	maxEmailLength    = 254
+	maxNameLength     = 100
+	minPasswordLength = 8
+	// bcrypt ignores everything past 72 bytes
+	maxPasswordLength = 72
+	passwordCost      = 12
+)
+
+type User struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	// Issuer and Subject are set for users who sign in with an identity
+	// provider
+	Issuer   string       `json:"issuer,omitempty"`
+	Subject  string       `json:"-"`
+	Password userPassword `json:"-"`
+}
+
+type userPassword struct {
+	plaintext *string
+	hash      []byte
+}
+
+func (p *userPassword) Set(plaintext string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(plaintext), passwordCost)
+	if err != nil {
+		return err
+	}
+	p.plaintext = &plaintext
+	p.hash = hash
+	return nil
+}
+
+func (p *userPassword) Matches(plaintext string) (bool, error) {
+	// users from an identity provider have no password
+	if p.hash == nil {
+		return false, nil
+	}
+	err := bcrypt.CompareHashAndPassword(p.hash, []byte(plaintext))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func ValidateEmail(v *validator.Validator, email string) {
+	v.Check(email != "", "email", "must be provided")
+	v.Check(len(email) <= maxEmailLength, "email", fmt.Sprintf("must not be more than %d bytes long", maxEmailLength))
+	v.Check(validator.Matches(email, validator.EmailRX), "email", "must be a valid email address")
+}
+
+func ValidatePasswordPlaintext(v *validator.Validator, password string) {
+	v.Check(password != "", "password", "must be provided")
+	v.Check(len(password) >= minPasswordLength, "password", fmt.Sprintf("must be at least %d bytes long", minPasswordLength))
+	v.Check(len(password) <= maxPasswordLength, "password", fmt.Sprintf("must not be more than %d bytes long", maxPasswordLength))
+}
+
+func ValidateUser(v *validator.Validator, user *User) {
+	ValidateEmail(v, user.Email)
+	v.Check(len(user.Name) <= maxNameLength, "name", fmt.Sprintf("must not be more than %d bytes long", maxNameLength))
+	if user.Password.plaintext != nil {
+		ValidatePasswordPlaintext(v, *user.Password.plaintext)
+	}
+}
+
+type UserModel struct {
+	DB *pgxpool.Pool
+}
+
+func (u UserModel) Insert(user *User) error {
+	query := `
+		INSERT INTO users (email, name, password_hash)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	err := u.DB.QueryRow(
+		ctx, query, user.Email, user.Name, user.Password.hash,
+	).Scan(&user.ID, &user.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDuplicateEmail
+		}
+		return fmt.Errorf("insert user: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertOIDC finds the user signed in by an identity provider, creating
+// them on their first login. Users are keyed by issuer and subject only, so
+// a password account registered with the same email doesn't block them.
+// Email and name follow the provider.
+func (u UserModel) UpsertOIDC(user *User) error {
+	query := `
+		INSERT INTO users (email, name, oidc_issuer, oidc_subject)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (oidc_issuer, oidc_subject)
+		DO UPDATE SET email = EXCLUDED.email, name = EXCLUDED.name
+		RETURNING id, created_at;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	err := u.DB.QueryRow(
+		ctx, query, user.Email, user.Name, user.Issuer, user.Subject,
+	).Scan(&user.ID, &user.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDuplicateEmail
+		}
+		return fmt.Errorf("upsert oidc user: %w", err)
+	}
+
+	return nil
+}
+
+// GetByEmail finds the password account signing in with email. Accounts
+// from an identity provider can share its email and are never matched.
+func (u UserModel) GetByEmail(email string) (*User, error) {
+	query := `
+		SELECT id, email, name, password_hash, created_at,
+		COALESCE(oidc_issuer, ''), COALESCE(oidc_subject, '')
+		FROM users
+		WHERE email = $1 AND oidc_subject IS NULL;
+	`
+	return u.get(query, email)
+}
+
+// GetByVerifiedEmail finds the user whose email was verified by an identity
+// provider. Emails of password accounts are not verified, so anyone could
+// register one they don't own.
+func (u UserModel) GetByVerifiedEmail(email string) (*User, error) {
+	query := `
+		SELECT id, email, name, password_hash, created_at,
+		COALESCE(oidc_issuer, ''), COALESCE(oidc_subject, '')
+		FROM users
+		WHERE email = $1 AND oidc_subject IS NOT NULL;
+	`
+	return u.get(query, email)
+}
+
+func (u UserModel) Get(id string) (*User, error) {
+	query := `
+		SELECT id, email, name, password_hash, created_at,
+		COALESCE(oidc_issuer, ''), COALESCE(oidc_subject, '')
+		FROM users
+		WHERE id = $1;
+	`
+	return u.get(query, id)
+}
+
+func (u UserModel) get(query string, arg string) (*User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var user User
+	err := u.DB.QueryRow(ctx, query, arg).Scan(
+		&user.ID, &user.Email, &user.Name, &user.Password.hash, &user.CreatedAt,
+		&user.Issuer, &user.Subject,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// NewSession stores the hash of a new session token for the user. Only the
+// returned token holds the plaintext.
+func (u UserModel) NewSession(userID string, ttl time.Duration) (*Token, error) {
+	token, err := GenerateToken()
+	if err != nil {
+		return nil, fmt.Errorf("new session: %w", err)
+	}
+
+	query := `
+		INSERT INTO sessions (hash, user_id, expiry)
+		VALUES ($1, $2, $3);
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	_, err = u.DB.Exec(ctx, query, token.Hash, userID, time.Now().Add(ttl))
+	if err != nil {
+		return nil, fmt.Errorf("new session: %w", err)
+	}
+
+	return token, nil
+}
+
+func (u UserModel) GetForSession(tokenPlaintext string) (*User, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	query := `
+		SELECT u.id, u.email, u.name, u.password_hash, u.created_at,
+		COALESCE(u.oidc_issuer, ''), COALESCE(u.oidc_subject, '')
+		FROM users u
+		JOIN sessions s ON s.user_id = u.id
+		WHERE s.hash = $1 AND s.expiry > NOW();
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var user User
+	err := u.DB.QueryRow(ctx, query, tokenHash[:]).Scan(
+		&user.ID, &user.Email, &user.Name, &user.Password.hash, &user.CreatedAt,
+		&user.Issuer, &user.Subject,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get user for session: %w", err)
+	}
+
+	return &user, nil
+}
+
+func (u UserModel) DeleteSession(tokenPlaintext string) error {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	query := `
+		DELETE FROM sessions
+		WHERE hash = $1;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	_, err := u.DB.Exec(ctx, query, tokenHash[:])
+	if err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+
+	return nil
+}
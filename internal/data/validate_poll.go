@@ -1,30 +1,74 @@
 package data
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ivcp/polls/internal/validator"
 )
 
+const (
+	PollTypeSingleChoice = "single_choice"
+	PollTypeOpenText     = "open_text"
+	PollTypeQuiz         = "quiz"
+	PollTypeSchedule     = "schedule"
+	PollTypeMatrix       = "matrix"
+)
+
+const (
+	maxQuestionLength    = 500
+	maxDescriptionLength = 1000
+	maxOptionValueLength = 500
+	maxSeriesLength      = 100
+	maxIssuerLength      = 500
+	maxDomainLength      = 253
+)
+
 var resultsVisibilitySafelist = []string{"always", "after_vote", "after_deadline"}
 
+var pollTypeSafelist = []string{
+	PollTypeSingleChoice,
+	PollTypeOpenText,
+	PollTypeQuiz,
+	PollTypeSchedule,
+	PollTypeMatrix,
+}
+
 func ValidatePoll(v *validator.Validator, poll *Poll) {
 	v.Check(poll.Question != "", "question", "must not be empty")
-	v.Check(len(poll.Question) <= 500, "question", "must not be more than 500 bytes long")
-	v.Check(len(poll.Description) <= 1000, "description", "must not be more than 1000 bytes long")
-	v.Check(poll.Options != nil, "options", "must be provided")
-	v.Check(len(poll.Options) >= 2, "options", "must contain at least two options")
-	var optValues []string
+	v.Check(
+		len(poll.Question) <= maxQuestionLength,
+		"question",
+		fmt.Sprintf("must not be more than %d bytes long", maxQuestionLength),
+	)
+	v.Check(
+		len(poll.Description) <= maxDescriptionLength,
+		"description",
+		fmt.Sprintf("must not be more than %d bytes long", maxDescriptionLength),
+	)
+	// open text polls collect answers from voters, options only appear once
+	// the owner promotes an answer
+	if poll.PollType != PollTypeOpenText {
+		v.Check(poll.Options != nil, "options", "must be provided")
+		v.Check(len(poll.Options) >= 2, "options", "must contain at least two options")
+	}
+	var optValues, normalized []string
 	var optPositions []int
 	for _, opt := range poll.Options {
 		optValues = append(optValues, opt.Value)
+		normalized = append(normalized, NormalizeOptionValue(opt.Value))
 		optPositions = append(optPositions, opt.Position)
 	}
-	v.Check(validator.Unique(optValues), "options", "must not contain duplicate values")
+	v.Check(validator.Unique(normalized), "options", "must not contain duplicate values")
 	v.Check(validator.Unique(optPositions), "options", "positions must be unique")
 	for _, o := range optValues {
 		v.Check(o != "", "options", "option values must not be empty")
-		v.Check(len(o) <= 500, "options", "option value must not be more than 500 bytes long")
+		v.Check(
+			len(o) <= maxOptionValueLength,
+			"options",
+			fmt.Sprintf("option value must not be more than %d bytes long", maxOptionValueLength),
+		)
 	}
 	for _, p := range optPositions {
 		v.Check(p >= 0, "options", "position must be greater or equal to 0")
@@ -40,4 +84,105 @@ func ValidatePoll(v *validator.Validator, poll *Poll) {
 	v.Check(validator.PermittedValue(
 		poll.ResultsVisibility, resultsVisibilitySafelist...,
 	), "results_visibility", "invalid results_visibility value")
+	v.Check(validator.PermittedValue(
+		poll.PollType, pollTypeSafelist...,
+	), "poll_type", "invalid poll_type value")
+	v.Check(
+		!poll.AllowWriteIn || poll.PollType == PollTypeSingleChoice,
+		"allow_write_in",
+		"only supported for single_choice polls",
+	)
+	if poll.PollType == PollTypeQuiz {
+		correct := 0
+		for _, opt := range poll.Options {
+			if opt.IsCorrect {
+				correct++
+			}
+		}
+		v.Check(correct > 0, "options", "must mark at least one option as correct")
+		v.Check(!poll.ExpiresAt.IsZero(), "expires_at", "must be set for quiz polls")
+	}
+	if poll.PollType == PollTypeSchedule {
+		var slots []*TimeSlot
+		for _, opt := range poll.Options {
+			v.Check(opt.Slot != nil, "options", "every option must have a time slot")
+			if opt.Slot != nil {
+				slots = append(slots, opt.Slot)
+			}
+		}
+		ValidateTimeSlots(v, slots)
+	} else {
+		for _, opt := range poll.Options {
+			v.Check(opt.Slot == nil, "options", "time slots are only supported for schedule polls")
+		}
+	}
+	if poll.PollType == PollTypeMatrix {
+		ValidateScale(v, poll.Scale)
+	} else {
+		v.Check(poll.Scale == nil, "scale", "only supported for matrix polls")
+	}
+	v.Check(
+		poll.Series == "" || poll.PollType == PollTypeQuiz,
+		"series",
+		"only supported for quiz polls",
+	)
+	v.Check(
+		len(poll.Series) <= maxSeriesLength,
+		"series",
+		fmt.Sprintf("must not be more than %d bytes long", maxSeriesLength),
+	)
+	v.Check(
+		len(poll.VoterIssuer) <= maxIssuerLength,
+		"voter_issuer",
+		fmt.Sprintf("must not be more than %d bytes long", maxIssuerLength),
+	)
+	if poll.VoterEmailDomain != "" {
+		// password accounts don't prove they own their email
+		v.Check(poll.VoterIssuer != "", "voter_email_domain", "requires voter_issuer")
+		v.Check(
+			len(poll.VoterEmailDomain) <= maxDomainLength &&
+				validator.Matches(poll.VoterEmailDomain, validator.DomainRX),
+			"voter_email_domain",
+			"must be a valid domain",
+		)
+	}
+	if poll.VoterAuth {
+		v.Check(poll.PollType == PollTypeSingleChoice, "voter_auth", "only supported for single_choice polls")
+		v.Check(!poll.AllowWriteIn, "voter_auth", "not supported with allow_write_in")
+	}
+	v.Check(!poll.PublicBallots || poll.VoterAuth, "public_ballots", "requires voter_auth")
+}
+
+// VoterAllowed reports whether a user satisfies the poll's voter
+// restrictions. Email domains must match exactly, subdomains are not
+// included.
+func VoterAllowed(poll *Poll, user *User) bool {
+	if poll.VoterIssuer != "" && user.Issuer != poll.VoterIssuer {
+		return false
+	}
+	if poll.VoterEmailDomain != "" {
+		at := strings.LastIndex(user.Email, "@")
+		if at < 0 || !strings.EqualFold(user.Email[at+1:], poll.VoterEmailDomain) {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeOptionValue folds case and whitespace, so "Red", " red" and
+// "RED " are treated as the same write-in.
+func NormalizeOptionValue(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(value), " "))
+}
+
+// ValidateResponse checks a value submitted by a voter, either a free text
+// answer or a write-in option, against the same limits that apply to
+// option values.
+func ValidateResponse(v *validator.Validator, value string) {
+	v.Check(value != "", "value", "must not be empty")
+	v.Check(
+		len(value) <= maxOptionValueLength,
+		"value",
+		fmt.Sprintf("must not be more than %d bytes long", maxOptionValueLength),
+	)
 }
@@ -0,0 +1,204 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ivcp/polls/internal/validator"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrAlreadyVoted = errors.New("already voted")
+
+const maxVoterLabelLength = 100
+
+// Voter is who cast a ballot on a poll with voter_auth. ID is unique per
+// poll, Name is what public ballots show.
+type Voter struct {
+	ID   string
+	Name string
+}
+
+func UserVoter(user *User) *Voter {
+	name := user.Name
+	if name == "" {
+		name = user.Email
+	}
+	return &Voter{ID: "user:" + user.ID, Name: name}
+}
+
+func CredentialVoter(credential *VoterCredential) *Voter {
+	return &Voter{ID: "credential:" + credential.ID, Name: credential.Label}
+}
+
+// VoterCredential is handed out by the poll owner to someone without an
+// account. The plaintext is only set right after it is minted.
+type VoterCredential struct {
+	ID        string    `json:"id"`
+	Label     string    `json:"label"`
+	CreatedAt time.Time `json:"created_at"`
+	Plaintext string    `json:"credential,omitempty"`
+}
+
+type Ballot struct {
+	Voter    string    `json:"voter"`
+	OptionID string    `json:"option_id"`
+	VotedAt  time.Time `json:"voted_at"`
+}
+
+func ValidateVoterCredential(v *validator.Validator, credential *VoterCredential) {
+	v.Check(credential.Label != "", "label", "must be provided")
+	v.Check(
+		len(credential.Label) <= maxVoterLabelLength,
+		"label",
+		fmt.Sprintf("must not be more than %d bytes long", maxVoterLabelLength),
+	)
+}
+
+type VoterModel struct {
+	DB *pgxpool.Pool
+}
+
+func (vm VoterModel) InsertCredential(credential *VoterCredential, pollID string, hash []byte) error {
+	query := `
+		INSERT INTO voter_credentials (poll_id, label, hash)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	err := vm.DB.QueryRow(ctx, query, pollID, credential.Label, hash).Scan(
+		&credential.ID, &credential.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert voter credential: %w", err)
+	}
+
+	return nil
+}
+
+func (vm VoterModel) GetCredentials(pollID string) ([]*VoterCredential, error) {
+	query := `
+		SELECT id, label, created_at
+		FROM voter_credentials
+		WHERE poll_id = $1
+		ORDER BY created_at ASC, id ASC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := vm.DB.Query(ctx, query, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get voter credentials: %w", err)
+	}
+	defer rows.Close()
+
+	credentials := []*VoterCredential{}
+
+	for rows.Next() {
+		var credential VoterCredential
+		err := rows.Scan(&credential.ID, &credential.Label, &credential.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("get voter credentials - scan: %w", err)
+		}
+		credentials = append(credentials, &credential)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("get voter credentials: %w", err)
+	}
+
+	return credentials, nil
+}
+
+// GetForCredential looks up a credential minted for the poll.
+func (vm VoterModel) GetForCredential(pollID string, plaintext string) (*VoterCredential, error) {
+	hash := sha256.Sum256([]byte(plaintext))
+
+	query := `
+		SELECT id, label, created_at
+		FROM voter_credentials
+		WHERE hash = $1 AND poll_id = $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var credential VoterCredential
+	err := vm.DB.QueryRow(ctx, query, hash[:], pollID).Scan(
+		&credential.ID, &credential.Label, &credential.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("get voter credential: %w", err)
+	}
+
+	return &credential, nil
+}
+
+// DeleteCredential stops a credential from voting. A ballot already cast
+// with it is kept.
+func (vm VoterModel) DeleteCredential(id string, pollID string) error {
+	query := `
+		DELETE FROM voter_credentials
+		WHERE id = $1 AND poll_id = $2;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	result, err := vm.DB.Exec(ctx, query, id, pollID)
+	if err != nil {
+		return fmt.Errorf("delete voter credential: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (vm VoterModel) GetBallots(pollID string) ([]*Ballot, error) {
+	query := `
+		SELECT voter_name, option_id, created_at
+		FROM ballots
+		WHERE poll_id = $1
+		ORDER BY created_at ASC, voter ASC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := vm.DB.Query(ctx, query, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("get ballots: %w", err)
+	}
+	defer rows.Close()
+
+	ballots := []*Ballot{}
+
+	for rows.Next() {
+		var ballot Ballot
+		err := rows.Scan(&ballot.Voter, &ballot.OptionID, &ballot.VotedAt)
+		if err != nil {
+			return nil, fmt.Errorf("get ballots - scan: %w", err)
+		}
+		ballots = append(ballots, &ballot)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("get ballots: %w", err)
+	}
+
+	return ballots, nil
+}
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS poll_responses (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    poll_id uuid NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    value text NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    merged_into uuid REFERENCES poll_responses (id) ON DELETE SET NULL,
+    option_id uuid REFERENCES poll_options (id) ON DELETE SET NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS poll_responses_poll_id_idx ON poll_responses (poll_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS poll_responses;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE poll_options ADD COLUMN is_correct BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE polls ADD COLUMN series text NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS polls_series_idx ON polls (series);
+CREATE TABLE IF NOT EXISTS quiz_answers (
+    id bigserial PRIMARY KEY,
+    poll_id uuid NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    option_id uuid NOT NULL REFERENCES poll_options (id) ON DELETE CASCADE,
+    voter text NOT NULL,
+    correct BOOLEAN NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS quiz_answers;
+DROP INDEX IF EXISTS polls_series_idx;
+ALTER TABLE polls DROP COLUMN series;
+ALTER TABLE poll_options DROP COLUMN is_correct;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE poll_options ADD COLUMN payload jsonb;
+CREATE TABLE IF NOT EXISTS availability (
+    id bigserial PRIMARY KEY,
+    poll_id uuid NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    option_id uuid NOT NULL REFERENCES poll_options (id) ON DELETE CASCADE,
+    voter text NOT NULL,
+    answer text NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS availability_poll_id_idx ON availability (poll_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS availability;
+ALTER TABLE poll_options DROP COLUMN payload;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN scale jsonb;
+CREATE TABLE IF NOT EXISTS ratings (
+    id bigserial PRIMARY KEY,
+    poll_id uuid NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    option_id uuid NOT NULL REFERENCES poll_options (id) ON DELETE CASCADE,
+    ballot_id uuid NOT NULL,
+    value int NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    UNIQUE (ballot_id, option_id)
+);
+CREATE INDEX IF NOT EXISTS ratings_poll_id_idx ON ratings (poll_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ratings;
+ALTER TABLE polls DROP COLUMN scale;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tokens ADD COLUMN id uuid NOT NULL UNIQUE DEFAULT gen_random_uuid();
+ALTER TABLE tokens ADD COLUMN scopes text[] NOT NULL 
+    DEFAULT '{poll:edit,poll:delete,results:read,voters:manage}';
+ALTER TABLE tokens ADD COLUMN label text NOT NULL DEFAULT '';
+ALTER TABLE tokens ADD COLUMN expiry timestamp(0) with time zone;
+ALTER TABLE tokens ADD COLUMN last_used_at timestamp(0) with time zone;
+ALTER TABLE tokens ADD COLUMN created_at timestamp(0) with time zone NOT NULL DEFAULT NOW();
+CREATE INDEX IF NOT EXISTS tokens_poll_id_idx ON tokens (poll_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS tokens_poll_id_idx;
+ALTER TABLE tokens DROP COLUMN created_at;
+ALTER TABLE tokens DROP COLUMN last_used_at;
+ALTER TABLE tokens DROP COLUMN expiry;
+ALTER TABLE tokens DROP COLUMN label;
+ALTER TABLE tokens DROP COLUMN scopes;
+ALTER TABLE tokens DROP COLUMN id;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE ips ADD COLUMN created_at timestamp(0) with time zone;
+ALTER TABLE ips ALTER COLUMN created_at SET DEFAULT NOW();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE ips DROP COLUMN created_at;
+-- +goose StatementEnd
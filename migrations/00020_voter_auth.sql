@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN voter_auth boolean NOT NULL DEFAULT false;
+ALTER TABLE polls ADD COLUMN public_ballots boolean NOT NULL DEFAULT false;
+CREATE TABLE IF NOT EXISTS voter_credentials (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    poll_id uuid NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    label text NOT NULL,
+    hash bytea NOT NULL UNIQUE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS voter_credentials_poll_id_idx ON voter_credentials (poll_id);
+CREATE TABLE IF NOT EXISTS ballots (
+    poll_id uuid NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    voter text NOT NULL,
+    voter_name text NOT NULL,
+    option_id uuid NOT NULL REFERENCES poll_options (id) ON DELETE CASCADE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (poll_id, voter)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ballots;
+DROP TABLE IF EXISTS voter_credentials;
+ALTER TABLE polls DROP COLUMN public_ballots;
+ALTER TABLE polls DROP COLUMN voter_auth;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS poll_revisions (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    seq bigserial NOT NULL,
+    poll_id uuid NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    action text NOT NULL,
+    actor text NOT NULL,
+    changes jsonb NOT NULL,
+    snapshot jsonb NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS poll_revisions_poll_id_idx ON poll_revisions (poll_id, seq);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS poll_revisions;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN deleted_at timestamp(0) with time zone;
+CREATE INDEX IF NOT EXISTS polls_deleted_at_idx ON polls (deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS polls_deleted_at_idx;
+ALTER TABLE polls DROP COLUMN deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE polls ADD COLUMN hidden_at timestamp(0) with time zone;
+CREATE INDEX IF NOT EXISTS polls_hidden_at_idx ON polls (hidden_at) WHERE hidden_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS polls_hidden_at_idx;
+ALTER TABLE polls DROP COLUMN hidden_at;
+-- +goose StatementEnd
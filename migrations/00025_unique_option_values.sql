@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION normalize_option_value(value text) RETURNS text
+LANGUAGE sql IMMUTABLE PARALLEL SAFE
+AS $$ SELECT lower(btrim(regexp_replace(value, '\s+', ' ', 'g'))) $$;
+
+-- options that only differed in case or spacing get a number appended
+UPDATE poll_options po
+SET value = po.value || ' (' || d.n || ')'
+FROM (
+	SELECT id, row_number() OVER (
+		PARTITION BY poll_id, normalize_option_value(value) ORDER BY position, id
+	) AS n
+	FROM poll_options
+) d
+WHERE po.id = d.id AND d.n > 1;
+
+ALTER TABLE poll_options
+ADD COLUMN normalized_value text GENERATED ALWAYS AS (normalize_option_value(value)) STORED;
+ALTER TABLE poll_options
+ADD CONSTRAINT poll_options_poll_id_normalized_value_key UNIQUE (poll_id, normalized_value)
+DEFERRABLE INITIALLY IMMEDIATE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE poll_options DROP CONSTRAINT poll_options_poll_id_normalized_value_key;
+ALTER TABLE poll_options DROP COLUMN normalized_value;
+DROP FUNCTION normalize_option_value(text);
+-- +goose StatementEnd
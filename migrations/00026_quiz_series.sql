@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS quiz_series (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name text NOT NULL,
+    owner_id uuid REFERENCES users (id) ON DELETE CASCADE,
+    org_id uuid REFERENCES organizations (id) ON DELETE CASCADE,
+    api_key_id uuid REFERENCES api_keys (id) ON DELETE CASCADE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    CHECK (num_nonnulls(owner_id, org_id, api_key_id) = 1)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS quiz_series_scope_name_idx
+ON quiz_series ((COALESCE(org_id, owner_id, api_key_id)), name);
+ALTER TABLE polls ADD COLUMN series_id uuid REFERENCES quiz_series (id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS polls_series_id_idx ON polls (series_id);
+
+-- series of anonymous polls have no one to belong to and are left behind
+INSERT INTO quiz_series (name, org_id, owner_id, api_key_id)
+SELECT DISTINCT series, org_id,
+    CASE WHEN org_id IS NULL THEN owner_id END,
+    CASE WHEN org_id IS NULL AND owner_id IS NULL THEN api_key_id END
+FROM polls
+WHERE series <> '' AND num_nonnulls(owner_id, org_id, api_key_id) > 0;
+UPDATE polls p
+SET series_id = s.id
+FROM quiz_series s
+WHERE p.series = s.name AND COALESCE(p.org_id, p.owner_id, p.api_key_id) = COALESCE(s.org_id, s.owner_id, s.api_key_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS polls_series_id_idx;
+ALTER TABLE polls DROP COLUMN series_id;
+DROP TABLE IF EXISTS quiz_series;
+-- +goose StatementEnd
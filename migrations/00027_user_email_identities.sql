@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_password_email_idx ON users (email) WHERE oidc_subject IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS users_oidc_email_idx ON users (email) WHERE oidc_subject IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS users_oidc_email_idx;
+DROP INDEX IF EXISTS users_password_email_idx;
+DELETE FROM users u
+WHERE u.oidc_subject IS NULL
+AND EXISTS (SELECT 1 FROM users o WHERE o.email = u.email AND o.oidc_subject IS NOT NULL);
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+-- +goose StatementEnd
@@ -0,0 +1,8 @@
+// Package migrations embeds the goose migrations so the binary can apply
+// them wherever it runs from.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
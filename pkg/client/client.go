@@ -0,0 +1,252 @@
+// Package client is a Go client for the polls API.
+//
+// Every method takes a context and returns the resource decoded from the
+// API's envelope. Failed requests return an *Error or, for input that fails
+// validation, a *ValidationError. Requests rejected by the rate limiter are
+// retried after the Retry-After the API sends.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxRetries   = 3
+	defaultMaxRetryWait = time.Minute
+	defaultBackoff      = time.Second
+)
+
+// Client calls the API at one base URL. It is safe for concurrent use.
+type Client struct {
+	baseURL      string
+	httpClient   *http.Client
+	apiKey       string
+	maxRetries   int
+	maxRetryWait time.Duration
+	// backoff is the first wait when a 429 comes without Retry-After, it
+	// doubles with each retry
+	backoff time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sets the client requests are sent with, e.g. to set a
+// timeout or a transport. http.DefaultClient is used otherwise.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAPIKey sends the key with every request. Requests are then limited
+// per key instead of per IP, and polls created with the key can be managed
+// without an owner token.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithMaxRetries sets how often a rate limited request is retried, 3 by
+// default. 0 turns retries off.
+func WithMaxRetries(n int) Option {
+	return func(c *Client) {
+		c.maxRetries = n
+	}
+}
+
+// WithMaxRetryWait sets the longest Retry-After the client waits for, a
+// minute by default. Longer waits, like those for an exhausted daily quota,
+// are returned as an error instead.
+func WithMaxRetryWait(d time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetryWait = d
+	}
+}
+
+// New returns a client for the API at baseURL, e.g. https://polls.example.com.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("client: base URL %q must be an absolute http or https URL", baseURL)
+	}
+
+	c := &Client{
+		baseURL:      strings.TrimSuffix(u.String(), "/"),
+		httpClient:   http.DefaultClient,
+		maxRetries:   defaultMaxRetries,
+		maxRetryWait: defaultMaxRetryWait,
+		backoff:      defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// request is one call to the API. token is sent as a bearer token when set
+// and dst, if not nil, receives the decoded response envelope.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	token  string
+	body   any
+	dst    any
+}
+
+func (c *Client) do(ctx context.Context, r request) error {
+	var body []byte
+	if r.body != nil {
+		var err error
+		body, err = json.Marshal(r.body)
+		if err != nil {
+			return fmt.Errorf("client: encode request: %w", err)
+		}
+	}
+
+	target := c.baseURL + r.path
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, r.method, target, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("client: %w", err)
+		}
+		req.Header.Set("Accept", "application/json")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if r.token != "" {
+			req.Header.Set("Authorization", "Bearer "+r.token)
+		}
+		if c.apiKey != "" {
+			req.Header.Set("X-API-Key", c.apiKey)
+		}
+
+		res, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("client: %s %s: %w", r.method, r.path, err)
+		}
+
+		if res.StatusCode == http.StatusTooManyRequests && attempt < c.maxRetries {
+			wait, ok := retryAfter(res.Header, time.Now())
+			if !ok {
+				wait = c.backoff << attempt
+			}
+			if wait <= c.maxRetryWait {
+				io.Copy(io.Discard, res.Body)
+				res.Body.Close()
+				if err := sleep(ctx, wait); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		return decodeResponse(res, r.dst)
+	}
+}
+
+func decodeResponse(res *http.Response, dst any) error {
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return decodeError(res)
+	}
+
+	if dst == nil {
+		io.Copy(io.Discard, res.Body)
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(dst); err != nil {
+		return fmt.Errorf("client: decode response: %w", err)
+	}
+	return nil
+}
+
+// retryAfter reads the Retry-After header, given either in seconds or as
+// an HTTP date.
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	value := h.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Health is the status the API reports about itself.
+type Health struct {
+	Status     string `json:"status"`
+	SystemInfo struct {
+		Environment string `json:"environment"`
+		Version     string `json:"version"`
+	} `json:"system_info"`
+}
+
+func (c *Client) Healthcheck(ctx context.Context) (*Health, error) {
+	var health Health
+	err := c.do(ctx, request{method: http.MethodGet, path: "/v1/healthcheck", dst: &health})
+	if err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+// pathf builds a request path, escaping each of the arguments.
+func pathf(format string, args ...string) string {
+	escaped := make([]any, 0, len(args))
+	for _, arg := range args {
+		escaped = append(escaped, url.PathEscape(arg))
+	}
+	return fmt.Sprintf(format, escaped...)
+}
+
+var errEmptyID = errors.New("client: id must not be empty")
+
+// checkIDs catches empty IDs, which would otherwise address a different
+// route.
+func checkIDs(ids ...string) error {
+	for _, id := range ids {
+		if id == "" {
+			return errEmptyID
+		}
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// call is what a test expects the client to send.
+type call struct {
+	method string
+	path   string
+	query  string
+	token  string
+	body   string
+}
+
+// stub returns a client for a server that checks the request against want
+// and answers with status and response.
+func stub(t *testing.T, want call, status int, response string) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != want.method {
+			t.Errorf("expected method %s, but got %s", want.method, r.Method)
+		}
+		if r.URL.EscapedPath() != want.path {
+			t.Errorf("expected path %q, but got %q", want.path, r.URL.EscapedPath())
+		}
+		if r.URL.RawQuery != want.query {
+			t.Errorf("expected query %q, but got %q", want.query, r.URL.RawQuery)
+		}
+		wantAuth := ""
+		if want.token != "" {
+			wantAuth = "Bearer " + want.token
+		}
+		if r.Header.Get("Authorization") != wantAuth {
+			t.Errorf("expected Authorization %q, but got %q", wantAuth, r.Header.Get("Authorization"))
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		if want.body == "" && len(body) != 0 {
+			t.Errorf("expected no body, but got %s", body)
+		}
+		if want.body != "" && !jsonEqual(t, want.body, string(body)) {
+			t.Errorf("expected body %s, but got %s", want.body, body)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func jsonEqual(t *testing.T, a, b string) bool {
+	t.Helper()
+
+	var x, y any
+	if err := json.Unmarshal([]byte(a), &x); err != nil {
+		t.Fatalf("invalid JSON %s: %v", a, err)
+	}
+	if err := json.Unmarshal([]byte(b), &y); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		wantURL string
+		wantErr bool
+	}{
+		{"valid", "https://polls.example.com", "https://polls.example.com", false},
+		{"trailing slash", "http://localhost:4000/", "http://localhost:4000", false},
+		{"relative", "/v1", "", true},
+		{"no host", "https://", "", true},
+		{"other scheme", "ftp://polls.example.com", "", true},
+		{"invalid", "http://[::1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(tt.baseURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, but got %v", tt.wantErr, err)
+			}
+			if err == nil && c.baseURL != tt.wantURL {
+				t.Errorf("expected base URL %q, but got %q", tt.wantURL, c.baseURL)
+			}
+		})
+	}
+}
+
+func Test_Client_errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		is       error
+		want     error
+	}{
+		{
+			name:     "message",
+			status:   http.StatusNotFound,
+			response: `{"error": "the requested resource could not be found"}`,
+			is:       ErrNotFound,
+			want:     &Error{StatusCode: 404, Message: "the requested resource could not be found"},
+		},
+		{
+			name:     "server error",
+			status:   http.StatusInternalServerError,
+			response: `{"error": "the server encountered a problem", "request_id": "abc"}`,
+			is:       ErrServer,
+			want:     &Error{StatusCode: 500, Message: "the server encountered a problem", RequestID: "abc"},
+		},
+		{
+			name:     "validation",
+			status:   http.StatusUnprocessableEntity,
+			response: `{"error": {"question": "must be provided"}}`,
+			is:       ErrValidation,
+			want:     &ValidationError{StatusCode: 422, Fields: map[string]string{"question": "must be provided"}},
+		},
+		{
+			name:     "not json",
+			status:   http.StatusBadGateway,
+			response: `<html>bad gateway</html>`,
+			is:       ErrServer,
+			want:     &Error{StatusCode: 502, Message: "Bad Gateway"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := stub(t, call{method: http.MethodGet, path: "/v1/healthcheck"}, tt.status, tt.response)
+
+			_, err := c.Healthcheck(context.Background())
+			if !errors.Is(err, tt.is) {
+				t.Errorf("expected error to be %v, but got %v", tt.is, err)
+			}
+			if !reflect.DeepEqual(err, tt.want) {
+				t.Errorf("expected %#v, but got %#v", tt.want, err)
+			}
+		})
+	}
+}
+
+func Test_Client_ValidationErrorMessage(t *testing.T) {
+	err := &ValidationError{StatusCode: 422, Fields: map[string]string{
+		"question": "must be provided",
+		"options":  "must contain at least 2 options",
+	}}
+
+	want := "polls api: 422 options: must contain at least 2 options, question: must be provided"
+	if err.Error() != want {
+		t.Errorf("expected %q, but got %q", want, err.Error())
+	}
+}
+
+// limited answers the first n requests with 429 and the given Retry-After.
+func limited(t *testing.T, n int32, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == http.MethodPost && !bytes.Equal(body, []byte(`{"value":"Blue"}`)) {
+			t.Errorf("expected the body to be resent, but got %s", body)
+		}
+
+		if requests.Add(1) <= n {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error": "rate limit exceeded"}`))
+			return
+		}
+		w.Write([]byte(`{"message": "option added successfully"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func Test_Client_retries(t *testing.T) {
+	t.Run("retries after Retry-After", func(t *testing.T) {
+		srv, requests := limited(t, 1, "1")
+		c, _ := New(srv.URL)
+
+		start := time.Now()
+		err := c.AddOption(context.Background(), "token", "poll", "Blue", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if requests.Load() != 2 {
+			t.Errorf("expected 2 requests, but got %d", requests.Load())
+		}
+		if time.Since(start) < time.Second {
+			t.Errorf("expected to wait for Retry-After, but waited %s", time.Since(start))
+		}
+	})
+
+	t.Run("backs off without Retry-After", func(t *testing.T) {
+		srv, requests := limited(t, 2, "")
+		c, _ := New(srv.URL)
+		c.backoff = time.Millisecond
+
+		if err := c.AddOption(context.Background(), "token", "poll", "Blue", nil); err != nil {
+			t.Fatal(err)
+		}
+		if requests.Load() != 3 {
+			t.Errorf("expected 3 requests, but got %d", requests.Load())
+		}
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		srv, requests := limited(t, 10, "0")
+		c, _ := New(srv.URL, WithMaxRetries(2))
+
+		err := c.AddOption(context.Background(), "token", "poll", "Blue", nil)
+		if !errors.Is(err, ErrRateLimited) {
+			t.Errorf("expected rate limited error, but got %v", err)
+		}
+		if requests.Load() != 3 {
+			t.Errorf("expected 3 requests, but got %d", requests.Load())
+		}
+	})
+
+	t.Run("does not wait longer than max retry wait", func(t *testing.T) {
+		srv, requests := limited(t, 1, "3600")
+		c, _ := New(srv.URL)
+
+		err := c.AddOption(context.Background(), "token", "poll", "Blue", nil)
+		var apiErr *Error
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("expected *Error, but got %v", err)
+		}
+		if apiErr.RetryAfter != time.Hour {
+			t.Errorf("expected RetryAfter %s, but got %s", time.Hour, apiErr.RetryAfter)
+		}
+		if requests.Load() != 1 {
+			t.Errorf("expected 1 request, but got %d", requests.Load())
+		}
+	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		srv, requests := limited(t, 1, "30")
+		c, _ := New(srv.URL)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err := c.AddOption(ctx, "token", "poll", "Blue", nil)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, but got %v", err)
+		}
+		if requests.Load() != 1 {
+			t.Errorf("expected 1 request, but got %d", requests.Load())
+		}
+	})
+}
+
+func Test_retryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"missing", "", 0, false},
+		{"seconds", "4", 4 * time.Second, true},
+		{"date", now.Add(time.Minute).Format(http.TimeFormat), time.Minute, true},
+		{"past date", now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"negative", "-1", 0, false},
+		{"invalid", "soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			if tt.value != "" {
+				h.Set("Retry-After", tt.value)
+			}
+			got, ok := retryAfter(h, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("expected %s %v, but got %s %v", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func Test_Client_headers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "key" {
+			t.Errorf("expected X-API-Key %q, but got %q", "key", r.Header.Get("X-API-Key"))
+		}
+		if r.Header.Get("Accept") != "application/json" {
+			t.Errorf("expected Accept application/json, but got %q", r.Header.Get("Accept"))
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected Content-Type application/json, but got %q", r.Header.Get("Content-Type"))
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"poll": {"id": "1", "question": "Test?", "expires_at": ""}}`))
+	}))
+	defer srv.Close()
+
+	c, _ := New(srv.URL, WithAPIKey("key"), WithHTTPClient(srv.Client()))
+	poll, err := c.CreatePoll(context.Background(), &CreatePollInput{Question: "Test?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if poll.Question != "Test?" || !poll.ExpiresAt.IsZero() {
+		t.Errorf("unexpected poll %+v", poll)
+	}
+}
+
+func Test_Client_emptyID(t *testing.T) {
+	c, _ := New("http://localhost:4000")
+
+	if _, err := c.GetPoll(context.Background(), "", nil); !errors.Is(err, errEmptyID) {
+		t.Errorf("expected %v, but got %v", errEmptyID, err)
+	}
+	if err := c.DeleteOption(context.Background(), "token", "poll", ""); !errors.Is(err, errEmptyID) {
+		t.Errorf("expected %v, but got %v", errEmptyID, err)
+	}
+}
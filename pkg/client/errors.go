@@ -0,0 +1,124 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// These match failed requests by their status with errors.Is, e.g.
+// errors.Is(err, client.ErrNotFound).
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrGone         = errors.New("gone")
+	ErrValidation   = errors.New("validation failed")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrServer       = errors.New("server error")
+)
+
+func statusError(status int) error {
+	switch {
+	case status == http.StatusBadRequest:
+		return ErrBadRequest
+	case status == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case status == http.StatusForbidden:
+		return ErrForbidden
+	case status == http.StatusNotFound:
+		return ErrNotFound
+	case status == http.StatusConflict:
+		return ErrConflict
+	case status == http.StatusGone:
+		return ErrGone
+	case status == http.StatusUnprocessableEntity:
+		return ErrValidation
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status >= http.StatusInternalServerError:
+		return ErrServer
+	}
+	return nil
+}
+
+// Error is a failed request whose envelope holds a message,
+// {"error": "..."}.
+type Error struct {
+	StatusCode int
+	Message    string
+	// RequestID is set on server errors, quote it when reporting them
+	RequestID string
+	// RetryAfter is how long a rate limited client should wait, if the API
+	// said so
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("polls api: %d %s", e.StatusCode, e.Message)
+	if e.RequestID != "" {
+		msg += " (request " + e.RequestID + ")"
+	}
+	return msg
+}
+
+func (e *Error) Is(target error) bool {
+	return target != nil && target == statusError(e.StatusCode)
+}
+
+// ValidationError is a request the API refused because of its input. The
+// envelope maps each invalid field to the problem, {"error": {"field": "..."}}.
+type ValidationError struct {
+	StatusCode int
+	Fields     map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field, problem := range e.Fields {
+		fields = append(fields, field+": "+problem)
+	}
+	sort.Strings(fields)
+	return fmt.Sprintf("polls api: %d %s", e.StatusCode, strings.Join(fields, ", "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target != nil && target == statusError(e.StatusCode)
+}
+
+func decodeError(res *http.Response) error {
+	var env struct {
+		Error     json.RawMessage `json:"error"`
+		RequestID string          `json:"request_id"`
+	}
+	body, err := io.ReadAll(res.Body)
+	if err == nil {
+		err = json.Unmarshal(body, &env)
+	}
+
+	// e.g. a proxy in front of the API answered
+	if err != nil || len(env.Error) == 0 {
+		return &Error{StatusCode: res.StatusCode, Message: http.StatusText(res.StatusCode)}
+	}
+
+	var fields map[string]string
+	if json.Unmarshal(env.Error, &fields) == nil {
+		return &ValidationError{StatusCode: res.StatusCode, Fields: fields}
+	}
+
+	apiErr := &Error{StatusCode: res.StatusCode, RequestID: env.RequestID}
+	if json.Unmarshal(env.Error, &apiErr.Message) != nil {
+		apiErr.Message = string(env.Error)
+	}
+	if wait, ok := retryAfter(res.Header, time.Now()); ok {
+		apiErr.RetryAfter = wait
+	}
+	return apiErr
+}
@@ -0,0 +1,355 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type CreatePollInput struct {
+	Question          string        `json:"question"`
+	Description       string        `json:"description,omitempty"`
+	Options           []OptionInput `json:"options"`
+	ExpiresAt         *time.Time    `json:"expires_at,omitempty"`
+	ResultsVisibility string        `json:"results_visibility,omitempty"`
+	IsPrivate         bool          `json:"is_private,omitempty"`
+	PollType          string        `json:"poll_type,omitempty"`
+	AllowWriteIn      bool          `json:"allow_write_in,omitempty"`
+	Series            string        `json:"series,omitempty"`
+	Scale             []string      `json:"scale,omitempty"`
+	// TokenFormat is opaque by default, or jwt for a signed owner token
+	TokenFormat      string `json:"token_format,omitempty"`
+	VoterIssuer      string `json:"voter_issuer,omitempty"`
+	VoterEmailDomain string `json:"voter_email_domain,omitempty"`
+	VoterAuth        bool   `json:"voter_auth,omitempty"`
+	PublicBallots    bool   `json:"public_ballots,omitempty"`
+	OrgID            string `json:"org_id,omitempty"`
+}
+
+type OptionInput struct {
+	Value     string    `json:"value"`
+	Position  int       `json:"position"`
+	IsCorrect bool      `json:"is_correct,omitempty"`
+	Slot      *TimeSlot `json:"slot,omitempty"`
+}
+
+// UpdatePollInput changes the fields that are set.
+type UpdatePollInput struct {
+	Question     *string    `json:"question,omitempty"`
+	Description  *string    `json:"description,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	AllowWriteIn *bool      `json:"allow_write_in,omitempty"`
+}
+
+// ListOptions selects a page of polls. Zero values use the API's defaults.
+type ListOptions struct {
+	Search   string
+	Page     int
+	PageSize int
+	// Sort is created_at or question, prefixed with - for descending order
+	Sort string
+}
+
+func (o *ListOptions) query() url.Values {
+	q := url.Values{}
+	if o == nil {
+		return q
+	}
+	if o.Search != "" {
+		q.Set("search", o.Search)
+	}
+	if o.Page != 0 {
+		q.Set("page", strconv.Itoa(o.Page))
+	}
+	if o.PageSize != 0 {
+		q.Set("page_size", strconv.Itoa(o.PageSize))
+	}
+	if o.Sort != "" {
+		q.Set("sort", o.Sort)
+	}
+	return q
+}
+
+// ReadOptions are for reading a poll or its results. Token is needed for
+// private polls of an organization and shows owners the results before
+// they are public. TimeZone renders schedule poll slots in an IANA zone.
+type ReadOptions struct {
+	Token    string
+	TimeZone string
+}
+
+func (o *ReadOptions) token() string {
+	if o == nil {
+		return ""
+	}
+	return o.Token
+}
+
+func (o *ReadOptions) query() url.Values {
+	q := url.Values{}
+	if o != nil && o.TimeZone != "" {
+		q.Set("tz", o.TimeZone)
+	}
+	return q
+}
+
+// CreatePoll creates an anonymous poll, or one owned by the client's API
+// key. The returned poll holds the owner token, which is not shown again.
+func (c *Client) CreatePoll(ctx context.Context, input *CreatePollInput) (*Poll, error) {
+	return c.CreatePollAs(ctx, "", input)
+}
+
+// CreatePollAs creates a poll owned by the user the session token belongs
+// to. Polls of an organization need an owner or editor of it.
+func (c *Client) CreatePollAs(ctx context.Context, sessionToken string, input *CreatePollInput) (*Poll, error) {
+	var env struct {
+		Poll *Poll `json:"poll"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/v1/polls",
+		token:  sessionToken,
+		body:   input,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Poll, nil
+}
+
+func (c *Client) GetPoll(ctx context.Context, pollID string, opts *ReadOptions) (*Poll, error) {
+	if err := checkIDs(pollID); err != nil {
+		return nil, err
+	}
+
+	var env struct {
+		Poll           *Poll    `json:"poll"`
+		CorrectOptions []string `json:"correct_options"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   pathf("/v1/polls/%s", pollID),
+		query:  opts.query(),
+		token:  opts.token(),
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	env.Poll.CorrectOptions = env.CorrectOptions
+	return env.Poll, nil
+}
+
+// ListPolls lists public polls. opts may be nil.
+func (c *Client) ListPolls(ctx context.Context, opts *ListOptions) ([]*Poll, *Metadata, error) {
+	return c.listPolls(ctx, "/v1/polls", "", opts)
+}
+
+func (c *Client) listPolls(ctx context.Context, path string, token string, opts *ListOptions) ([]*Poll, *Metadata, error) {
+	var env struct {
+		Polls    []*Poll   `json:"polls"`
+		Metadata *Metadata `json:"metadata"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   path,
+		query:  opts.query(),
+		token:  token,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return env.Polls, env.Metadata, nil
+}
+
+// UpdatePoll needs the poll:edit scope. Polls can't be edited once voting
+// has begun.
+func (c *Client) UpdatePoll(ctx context.Context, token string, pollID string, input *UpdatePollInput) (*Poll, error) {
+	if err := checkIDs(pollID); err != nil {
+		return nil, err
+	}
+
+	var env struct {
+		Poll *Poll `json:"poll"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPatch,
+		path:   pathf("/v1/polls/%s", pollID),
+		token:  token,
+		body:   input,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Poll, nil
+}
+
+// DeletePoll needs the poll:delete scope. It returns until when the poll
+// can be restored.
+func (c *Client) DeletePoll(ctx context.Context, token string, pollID string) (time.Time, error) {
+	if err := checkIDs(pollID); err != nil {
+		return time.Time{}, err
+	}
+
+	var env struct {
+		RestorableUntil time.Time `json:"restorable_until"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   pathf("/v1/polls/%s", pollID),
+		token:  token,
+		dst:    &env,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return env.RestorableUntil, nil
+}
+
+// RestorePoll undoes DeletePoll within the grace period.
+func (c *Client) RestorePoll(ctx context.Context, token string, pollID string) error {
+	if err := checkIDs(pollID); err != nil {
+		return err
+	}
+
+	return c.do(ctx, request{
+		method: http.MethodPost,
+		path:   pathf("/v1/polls/%s/restore", pollID),
+		token:  token,
+	})
+}
+
+// AddOption adds an option to the end of the poll. slot is only for
+// schedule polls and may be nil.
+func (c *Client) AddOption(ctx context.Context, token string, pollID string, value string, slot *TimeSlot) error {
+	if err := checkIDs(pollID); err != nil {
+		return err
+	}
+
+	input := struct {
+		Value string    `json:"value"`
+		Slot  *TimeSlot `json:"slot,omitempty"`
+	}{value, slot}
+	return c.do(ctx, request{
+		method: http.MethodPost,
+		path:   pathf("/v1/polls/%s/options", pollID),
+		token:  token,
+		body:   input,
+	})
+}
+
+func (c *Client) UpdateOption(ctx context.Context, token string, pollID string, optionID string, value string) error {
+	if err := checkIDs(pollID, optionID); err != nil {
+		return err
+	}
+
+	input := struct {
+		Value string `json:"value"`
+	}{value}
+	return c.do(ctx, request{
+		method: http.MethodPatch,
+		path:   pathf("/v1/polls/%s/options/%s", pollID, optionID),
+		token:  token,
+		body:   input,
+	})
+}
+
+// ReorderOptions moves each option to its position, keyed by option ID.
+func (c *Client) ReorderOptions(ctx context.Context, token string, pollID string, positions map[string]int) error {
+	if err := checkIDs(pollID); err != nil {
+		return err
+	}
+
+	type option struct {
+		ID       string `json:"id"`
+		Position int    `json:"position"`
+	}
+	input := struct {
+		Options []option `json:"options"`
+	}{}
+	for id, position := range positions {
+		input.Options = append(input.Options, option{id, position})
+	}
+	return c.do(ctx, request{
+		method: http.MethodPatch,
+		path:   pathf("/v1/polls/%s/options", pollID),
+		token:  token,
+		body:   input,
+	})
+}
+
+func (c *Client) DeleteOption(ctx context.Context, token string, pollID string, optionID string) error {
+	if err := checkIDs(pollID, optionID); err != nil {
+		return err
+	}
+
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   pathf("/v1/polls/%s/options/%s", pollID, optionID),
+		token:  token,
+	})
+}
+
+// SetAnswers marks the correct options of a quiz.
+func (c *Client) SetAnswers(ctx context.Context, token string, pollID string, optionIDs []string) error {
+	if err := checkIDs(pollID); err != nil {
+		return err
+	}
+
+	input := struct {
+		OptionIDs []string `json:"option_ids"`
+	}{optionIDs}
+	return c.do(ctx, request{
+		method: http.MethodPut,
+		path:   pathf("/v1/polls/%s/answers", pollID),
+		token:  token,
+		body:   input,
+	})
+}
+
+// ListRevisions lists the changes made to a poll, newest first.
+func (c *Client) ListRevisions(ctx context.Context, token string, pollID string) ([]*Revision, error) {
+	if err := checkIDs(pollID); err != nil {
+		return nil, err
+	}
+
+	var env struct {
+		Revisions []*Revision `json:"revisions"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   pathf("/v1/polls/%s/revisions", pollID),
+		token:  token,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Revisions, nil
+}
+
+// RestoreRevision puts the poll back to how it was after the revision.
+func (c *Client) RestoreRevision(ctx context.Context, token string, pollID string, revisionID string) (*Poll, error) {
+	if err := checkIDs(pollID, revisionID); err != nil {
+		return nil, err
+	}
+
+	var env struct {
+		Poll *Poll `json:"poll"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   pathf("/v1/polls/%s/revisions/%s/restore", pollID, revisionID),
+		token:  token,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Poll, nil
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testPoll = `{
+	"id": "4e6c2d53-3c9b-4fd0-a2c6-0e0e4e3a1f10",
+	"question": "Test?",
+	"description": "",
+	"options": [{"id": "b9f0e8a4-5f29-4e62-93d3-4f4f4d9b2a11", "value": "Yes", "position": 0}],
+	"created_at": "2024-01-01T12:00:00Z",
+	"updated_at": "2024-01-01T12:00:00Z",
+	"expires_at": "2024-02-01T12:00:00Z",
+	"results_visibility": "always",
+	"is_private": false,
+	"poll_type": "single_choice",
+	"allow_write_in": false,
+	"voter_auth": false,
+	"public_ballots": false
+}`
+
+func Test_Client_CreatePoll(t *testing.T) {
+	expires := time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+	c := stub(t, call{
+		method: http.MethodPost,
+		path:   "/v1/polls",
+		body: `{
+			"question": "Test?",
+			"options": [{"value": "Yes", "position": 0}, {"value": "No", "position": 1, "is_correct": true}],
+			"expires_at": "2024-02-01T12:00:00Z",
+			"poll_type": "quiz"
+		}`,
+	}, http.StatusCreated, `{"poll": `+testPoll+`}`)
+
+	poll, err := c.CreatePoll(context.Background(), &CreatePollInput{
+		Question:  "Test?",
+		Options:   []OptionInput{{Value: "Yes"}, {Value: "No", Position: 1, IsCorrect: true}},
+		ExpiresAt: &expires,
+		PollType:  PollTypeQuiz,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !poll.ExpiresAt.Equal(expires) {
+		t.Errorf("expected expires_at %s, but got %s", expires, poll.ExpiresAt)
+	}
+	if len(poll.Options) != 1 || poll.Options[0].Value != "Yes" {
+		t.Errorf("unexpected options %+v", poll.Options)
+	}
+}
+
+func Test_Client_GetPoll(t *testing.T) {
+	c := stub(t, call{
+		method: http.MethodGet,
+		path:   "/v1/polls/1",
+		query:  "tz=Europe%2FBerlin",
+		token:  "owner",
+	}, http.StatusOK, `{"poll": `+testPoll+`, "correct_options": ["b9f0e8a4-5f29-4e62-93d3-4f4f4d9b2a11"]}`)
+
+	poll, err := c.GetPoll(context.Background(), "1", &ReadOptions{Token: "owner", TimeZone: "Europe/Berlin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(poll.CorrectOptions) != 1 {
+		t.Errorf("expected 1 correct option, but got %v", poll.CorrectOptions)
+	}
+}
+
+func Test_Client_ListPolls(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  *ListOptions
+		query string
+	}{
+		{"defaults", nil, ""},
+		{"all", &ListOptions{Search: "cats dogs", Page: 2, PageSize: 5, Sort: "-created_at"}, "page=2&page_size=5&search=cats+dogs&sort=-created_at"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := stub(t, call{method: http.MethodGet, path: "/v1/polls", query: tt.query}, http.StatusOK,
+				`{"polls": [`+testPoll+`], "metadata": {"current_page": 2, "page_size": 5, "first_page": 1, "last_page": 3, "total_records": 11}}`)
+
+			polls, metadata, err := c.ListPolls(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(polls) != 1 {
+				t.Errorf("expected 1 poll, but got %d", len(polls))
+			}
+			if metadata.TotalRecords != 11 {
+				t.Errorf("expected 11 total records, but got %d", metadata.TotalRecords)
+			}
+		})
+	}
+}
+
+func Test_Client_UpdatePoll(t *testing.T) {
+	question := "Updated?"
+	c := stub(t, call{
+		method: http.MethodPatch,
+		path:   "/v1/polls/1",
+		token:  "owner",
+		body:   `{"question": "Updated?"}`,
+	}, http.StatusOK, `{"poll": `+testPoll+`}`)
+
+	if _, err := c.UpdatePoll(context.Background(), "owner", "1", &UpdatePollInput{Question: &question}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Client_DeletePoll(t *testing.T) {
+	c := stub(t, call{method: http.MethodDelete, path: "/v1/polls/1", token: "owner"}, http.StatusOK,
+		`{"message": "poll deleted", "restorable_until": "2024-01-08T12:00:00Z"}`)
+
+	until, err := c.DeletePoll(context.Background(), "owner", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC); !until.Equal(want) {
+		t.Errorf("expected restorable until %s, but got %s", want, until)
+	}
+}
+
+func Test_Client_options(t *testing.T) {
+	ctx := context.Background()
+
+	c := stub(t, call{
+		method: http.MethodPost,
+		path:   "/v1/polls/1/options",
+		token:  "owner",
+		body:   `{"value": "Maybe"}`,
+	}, http.StatusCreated, `{"message": "option added successfully"}`)
+	if err := c.AddOption(ctx, "owner", "1", "Maybe", nil); err != nil {
+		t.Error(err)
+	}
+
+	c = stub(t, call{
+		method: http.MethodPatch,
+		path:   "/v1/polls/1/options/a%2Fb",
+		token:  "owner",
+		body:   `{"value": "Perhaps"}`,
+	}, http.StatusCreated, `{"message": "option updated successfully"}`)
+	if err := c.UpdateOption(ctx, "owner", "1", "a/b", "Perhaps"); err != nil {
+		t.Error(err)
+	}
+
+	c = stub(t, call{
+		method: http.MethodPatch,
+		path:   "/v1/polls/1/options",
+		token:  "owner",
+		body:   `{"options": [{"id": "2", "position": 0}]}`,
+	}, http.StatusOK, `{"message": "options updated successfully"}`)
+	if err := c.ReorderOptions(ctx, "owner", "1", map[string]int{"2": 0}); err != nil {
+		t.Error(err)
+	}
+
+	c = stub(t, call{method: http.MethodDelete, path: "/v1/polls/1/options/2", token: "owner"},
+		http.StatusOK, `{"message": "option removed successfully"}`)
+	if err := c.DeleteOption(ctx, "owner", "1", "2"); err != nil {
+		t.Error(err)
+	}
+
+	c = stub(t, call{
+		method: http.MethodPut,
+		path:   "/v1/polls/1/answers",
+		token:  "owner",
+		body:   `{"option_ids": ["2"]}`,
+	}, http.StatusOK, `{"message": "answers set"}`)
+	if err := c.SetAnswers(ctx, "owner", "1", []string{"2"}); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Client_revisions(t *testing.T) {
+	ctx := context.Background()
+
+	c := stub(t, call{method: http.MethodGet, path: "/v1/polls/1/revisions", token: "owner"}, http.StatusOK,
+		`{"revisions": [{"id": "3", "action": "update", "actor": "owner", "changes": [{"field": "question", "before": "Test?", "after": "Updated?"}], "created_at": "2024-01-01T12:00:00Z"}]}`)
+	revisions, err := c.ListRevisions(ctx, "owner", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 1 || revisions[0].Changes[0].After != "Updated?" {
+		t.Errorf("unexpected revisions %+v", revisions)
+	}
+
+	c = stub(t, call{method: http.MethodPost, path: "/v1/polls/1/revisions/3/restore", token: "owner"},
+		http.StatusOK, `{"poll": `+testPoll+`}`)
+	if _, err := c.RestoreRevision(ctx, "owner", "1", "3"); err != nil {
+		t.Error(err)
+	}
+}
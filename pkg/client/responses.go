@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// ListResponses lists the answers to an open text poll. status filters
+// them and may be empty.
+func (c *Client) ListResponses(ctx context.Context, token string, pollID string, status string) ([]*PollResponse, error) {
+	if err := checkIDs(pollID); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	var env struct {
+		Responses []*PollResponse `json:"responses"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   pathf("/v1/polls/%s/responses", pollID),
+		query:  query,
+		token:  token,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Responses, nil
+}
+
+func (c *Client) UpdateResponseStatus(ctx context.Context, token string, pollID string, responseID string, status string) (*PollResponse, error) {
+	if err := checkIDs(pollID, responseID); err != nil {
+		return nil, err
+	}
+
+	input := struct {
+		Status string `json:"status"`
+	}{status}
+	var env struct {
+		Response *PollResponse `json:"response"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPatch,
+		path:   pathf("/v1/polls/%s/responses/%s", pollID, responseID),
+		token:  token,
+		body:   input,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Response, nil
+}
+
+// MergeResponse counts a response towards the target response.
+func (c *Client) MergeResponse(ctx context.Context, token string, pollID string, responseID string, targetID string) error {
+	if err := checkIDs(pollID, responseID, targetID); err != nil {
+		return err
+	}
+
+	input := struct {
+		TargetID string `json:"target_id"`
+	}{targetID}
+	return c.do(ctx, request{
+		method: http.MethodPost,
+		path:   pathf("/v1/polls/%s/responses/%s/merge", pollID, responseID),
+		token:  token,
+		body:   input,
+	})
+}
+
+// PromoteResponse turns a response into an option of the poll.
+func (c *Client) PromoteResponse(ctx context.Context, token string, pollID string, responseID string) (*PollOption, error) {
+	if err := checkIDs(pollID, responseID); err != nil {
+		return nil, err
+	}
+
+	var env struct {
+		Option *PollOption `json:"option"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   pathf("/v1/polls/%s/responses/%s/promote", pollID, responseID),
+		token:  token,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Option, nil
+}
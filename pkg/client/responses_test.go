@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+const testResponse = `{"id": "5", "value": "Blue", "status": "approved", "count": 2, "created_at": "2024-01-01T12:00:00Z"}`
+
+func Test_Client_responses(t *testing.T) {
+	ctx := context.Background()
+
+	c := stub(t, call{method: http.MethodGet, path: "/v1/polls/1/responses", query: "status=pending", token: "owner"},
+		http.StatusOK, `{"responses": [`+testResponse+`]}`)
+	responses, err := c.ListResponses(ctx, "owner", "1", ResponseStatusPending)
+	if err != nil {
+		t.Error(err)
+	} else if len(responses) != 1 || responses[0].Count != 2 {
+		t.Errorf("unexpected responses %+v", responses)
+	}
+
+	c = stub(t, call{
+		method: http.MethodPatch,
+		path:   "/v1/polls/1/responses/5",
+		token:  "owner",
+		body:   `{"status": "approved"}`,
+	}, http.StatusOK, `{"response": `+testResponse+`}`)
+	response, err := c.UpdateResponseStatus(ctx, "owner", "1", "5", ResponseStatusApproved)
+	if err != nil {
+		t.Error(err)
+	} else if response.Status != ResponseStatusApproved {
+		t.Errorf("expected status approved, but got %q", response.Status)
+	}
+
+	c = stub(t, call{
+		method: http.MethodPost,
+		path:   "/v1/polls/1/responses/5/merge",
+		token:  "owner",
+		body:   `{"target_id": "6"}`,
+	}, http.StatusOK, `{"message": "response merged"}`)
+	if err := c.MergeResponse(ctx, "owner", "1", "5", "6"); err != nil {
+		t.Error(err)
+	}
+
+	c = stub(t, call{method: http.MethodPost, path: "/v1/polls/1/responses/5/promote", token: "owner"},
+		http.StatusCreated, `{"option": {"id": "7", "value": "Blue", "position": 1}}`)
+	option, err := c.PromoteResponse(ctx, "owner", "1", "5")
+	if err != nil {
+		t.Error(err)
+	} else if option.ID != "7" {
+		t.Errorf("expected option 7, but got %+v", option)
+	}
+}
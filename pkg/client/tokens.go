@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type CreateTokenInput struct {
+	Label  string   `json:"label"`
+	Scopes []string `json:"scopes"`
+	// ExpiresAt is optional, tokens don't expire by default
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateToken issues a token with some of the scopes of the caller's.
+func (c *Client) CreateToken(ctx context.Context, token string, pollID string, input *CreateTokenInput) (*Token, error) {
+	if err := checkIDs(pollID); err != nil {
+		return nil, err
+	}
+
+	var env struct {
+		Token *Token `json:"token"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   pathf("/v1/polls/%s/tokens", pollID),
+		token:  token,
+		body:   input,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Token, nil
+}
+
+func (c *Client) ListTokens(ctx context.Context, token string, pollID string) ([]*Token, error) {
+	if err := checkIDs(pollID); err != nil {
+		return nil, err
+	}
+
+	var env struct {
+		Tokens []*Token `json:"tokens"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   pathf("/v1/polls/%s/tokens", pollID),
+		token:  token,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Tokens, nil
+}
+
+func (c *Client) DeleteToken(ctx context.Context, token string, pollID string, tokenID string) error {
+	if err := checkIDs(pollID, tokenID); err != nil {
+		return err
+	}
+
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   pathf("/v1/polls/%s/tokens/%s", pollID, tokenID),
+		token:  token,
+	})
+}
+
+// RevokeJWT rejects a signed owner token of the poll from now on.
+func (c *Client) RevokeJWT(ctx context.Context, token string, pollID string, jwt string) error {
+	if err := checkIDs(pollID); err != nil {
+		return err
+	}
+
+	input := struct {
+		Token string `json:"token"`
+	}{jwt}
+	return c.do(ctx, request{
+		method: http.MethodPost,
+		path:   pathf("/v1/polls/%s/tokens/revoke", pollID),
+		token:  token,
+		body:   input,
+	})
+}
+
+// JWKS returns the public keys signed owner tokens can be verified with.
+func (c *Client) JWKS(ctx context.Context) ([]*JWK, error) {
+	var env struct {
+		Keys []*JWK `json:"keys"`
+	}
+	err := c.do(ctx, request{method: http.MethodGet, path: "/.well-known/jwks.json", dst: &env})
+	if err != nil {
+		return nil, err
+	}
+	return env.Keys, nil
+}
+
+// CreateVoterCredential needs the voters:manage scope.
+func (c *Client) CreateVoterCredential(ctx context.Context, token string, pollID string, label string) (*VoterCredential, error) {
+	if err := checkIDs(pollID); err != nil {
+		return nil, err
+	}
+
+	input := struct {
+		Label string `json:"label"`
+	}{label}
+	var env struct {
+		Voter *VoterCredential `json:"voter"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   pathf("/v1/polls/%s/voters", pollID),
+		token:  token,
+		body:   input,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Voter, nil
+}
+
+func (c *Client) ListVoterCredentials(ctx context.Context, token string, pollID string) ([]*VoterCredential, error) {
+	if err := checkIDs(pollID); err != nil {
+		return nil, err
+	}
+
+	var env struct {
+		Voters []*VoterCredential `json:"voters"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   pathf("/v1/polls/%s/voters", pollID),
+		token:  token,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Voters, nil
+}
+
+func (c *Client) DeleteVoterCredential(ctx context.Context, token string, pollID string, voterID string) error {
+	if err := checkIDs(pollID, voterID); err != nil {
+		return err
+	}
+
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   pathf("/v1/polls/%s/voters/%s", pollID, voterID),
+		token:  token,
+	})
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func Test_Client_tokens(t *testing.T) {
+	ctx := context.Background()
+
+	c := stub(t, call{
+		method: http.MethodPost,
+		path:   "/v1/polls/1/tokens",
+		token:  "owner",
+		body:   `{"label": "ci", "scopes": ["results:read"]}`,
+	}, http.StatusCreated, `{"token": {"id": "8", "label": "ci", "scopes": ["results:read"], "expires_at": null, "last_used_at": null, "created_at": "2024-01-01T12:00:00Z", "token": "SECRET"}}`)
+	token, err := c.CreateToken(ctx, "owner", "1", &CreateTokenInput{Label: "ci", Scopes: []string{ScopeResultsRead}})
+	if err != nil {
+		t.Error(err)
+	} else if token.Plaintext != "SECRET" || token.ExpiresAt != nil {
+		t.Errorf("unexpected token %+v", token)
+	}
+
+	c = stub(t, call{method: http.MethodGet, path: "/v1/polls/1/tokens", token: "owner"}, http.StatusOK,
+		`{"tokens": [{"id": "8", "label": "ci", "scopes": ["results:read"], "expires_at": null, "last_used_at": "2024-01-02T12:00:00Z", "created_at": "2024-01-01T12:00:00Z"}]}`)
+	tokens, err := c.ListTokens(ctx, "owner", "1")
+	if err != nil {
+		t.Error(err)
+	} else if len(tokens) != 1 || tokens[0].LastUsedAt == nil {
+		t.Errorf("unexpected tokens %+v", tokens)
+	}
+
+	c = stub(t, call{method: http.MethodDelete, path: "/v1/polls/1/tokens/8", token: "owner"},
+		http.StatusOK, `{"message": "token deleted"}`)
+	if err := c.DeleteToken(ctx, "owner", "1", "8"); err != nil {
+		t.Error(err)
+	}
+
+	c = stub(t, call{method: http.MethodPost, path: "/v1/polls/1/tokens/revoke", token: "owner", body: `{"token": "a.b.c"}`},
+		http.StatusOK, `{"message": "token revoked"}`)
+	if err := c.RevokeJWT(ctx, "owner", "1", "a.b.c"); err != nil {
+		t.Error(err)
+	}
+
+	c = stub(t, call{method: http.MethodGet, path: "/.well-known/jwks.json"}, http.StatusOK,
+		`{"keys": [{"kty": "OKP", "crv": "Ed25519", "x": "abc", "kid": "k1", "alg": "EdDSA", "use": "sig"}]}`)
+	keys, err := c.JWKS(ctx)
+	if err != nil {
+		t.Error(err)
+	} else if len(keys) != 1 || keys[0].KeyID != "k1" {
+		t.Errorf("unexpected keys %+v", keys)
+	}
+}
+
+func Test_Client_voterCredentials(t *testing.T) {
+	ctx := context.Background()
+
+	c := stub(t, call{method: http.MethodPost, path: "/v1/polls/1/voters", token: "owner", body: `{"label": "ana"}`},
+		http.StatusCreated, `{"voter": {"id": "9", "label": "ana", "created_at": "2024-01-01T12:00:00Z", "credential": "SECRET"}}`)
+	voter, err := c.CreateVoterCredential(ctx, "owner", "1", "ana")
+	if err != nil {
+		t.Error(err)
+	} else if voter.Plaintext != "SECRET" {
+		t.Errorf("unexpected voter %+v", voter)
+	}
+
+	c = stub(t, call{method: http.MethodGet, path: "/v1/polls/1/voters", token: "owner"}, http.StatusOK,
+		`{"voters": [{"id": "9", "label": "ana", "created_at": "2024-01-01T12:00:00Z"}]}`)
+	voters, err := c.ListVoterCredentials(ctx, "owner", "1")
+	if err != nil {
+		t.Error(err)
+	} else if len(voters) != 1 {
+		t.Errorf("expected 1 voter, but got %d", len(voters))
+	}
+
+	c = stub(t, call{method: http.MethodDelete, path: "/v1/polls/1/voters/9", token: "owner"},
+		http.StatusOK, `{"message": "voter credential deleted"}`)
+	if err := c.DeleteVoterCredential(ctx, "owner", "1", "9"); err != nil {
+		t.Error(err)
+	}
+}
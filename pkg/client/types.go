@@ -0,0 +1,241 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+const (
+	PollTypeSingleChoice = "single_choice"
+	PollTypeOpenText     = "open_text"
+	PollTypeQuiz         = "quiz"
+	PollTypeSchedule     = "schedule"
+	PollTypeMatrix       = "matrix"
+)
+
+const (
+	ScopePollEdit     = "poll:edit"
+	ScopePollDelete   = "poll:delete"
+	ScopeResultsRead  = "results:read"
+	ScopeVotersManage = "voters:manage"
+)
+
+const (
+	ResponseStatusPending  = "pending"
+	ResponseStatusApproved = "approved"
+	ResponseStatusHidden   = "hidden"
+	ResponseStatusMerged   = "merged"
+)
+
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleEditor = "editor"
+	OrgRoleViewer = "viewer"
+)
+
+// ExpiresAt is when a poll closes. It is zero for polls that never do,
+// which the API sends as an empty string.
+type ExpiresAt struct{ time.Time }
+
+func (e ExpiresAt) MarshalJSON() ([]byte, error) {
+	if e.IsZero() {
+		return []byte(`""`), nil
+	}
+	return json.Marshal(e.Time)
+}
+
+func (e *ExpiresAt) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte(`""`)) || bytes.Equal(b, []byte("null")) {
+		e.Time = time.Time{}
+		return nil
+	}
+	return json.Unmarshal(b, &e.Time)
+}
+
+type Poll struct {
+	ID                string        `json:"id"`
+	Question          string        `json:"question"`
+	Description       string        `json:"description"`
+	Options           []*PollOption `json:"options"`
+	CreatedAt         time.Time     `json:"created_at"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+	ExpiresAt         ExpiresAt     `json:"expires_at"`
+	ResultsVisibility string        `json:"results_visibility"`
+	IsPrivate         bool          `json:"is_private"`
+	PollType          string        `json:"poll_type"`
+	AllowWriteIn      bool          `json:"allow_write_in"`
+	Series            string        `json:"series,omitempty"`
+	SeriesID          string        `json:"series_id,omitempty"`
+	Scale             []string      `json:"scale,omitempty"`
+	VoterIssuer       string        `json:"voter_issuer,omitempty"`
+	VoterEmailDomain  string        `json:"voter_email_domain,omitempty"`
+	VoterAuth         bool          `json:"voter_auth"`
+	PublicBallots     bool          `json:"public_ballots"`
+	OrgID             string        `json:"org_id,omitempty"`
+	DeletedAt         *time.Time    `json:"deleted_at,omitempty"`
+	// Token is the owner token, only returned by CreatePoll
+	Token string `json:"token,omitempty"`
+	// CorrectOptions are the answers of a closed quiz, only set by GetPoll
+	CorrectOptions []string `json:"-"`
+}
+
+type PollOption struct {
+	ID       string    `json:"id"`
+	Value    string    `json:"value"`
+	Position int       `json:"position"`
+	Slot     *TimeSlot `json:"slot,omitempty"`
+}
+
+// TimeSlot is the payload of a schedule poll option. Start and End are
+// rendered in TimeZone.
+type TimeSlot struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	TimeZone string    `json:"time_zone"`
+}
+
+type Metadata struct {
+	CurrentPage  int `json:"current_page,omitempty"`
+	PageSize     int `json:"page_size,omitempty"`
+	FirstPage    int `json:"first_page,omitempty"`
+	LastPage     int `json:"last_page,omitempty"`
+	TotalRecords int `json:"total_records,omitempty"`
+}
+
+// Results holds the vote counts of a poll. Turnout is only set for callers
+// with the results:read scope, the other fields depend on the poll type.
+type Results struct {
+	Results []struct {
+		ID        string `json:"id"`
+		Value     string `json:"value"`
+		Position  int    `json:"position"`
+		VoteCount int    `json:"vote_count"`
+	} `json:"results"`
+	Turnout *Turnout  `json:"turnout,omitempty"`
+	Ballots []*Ballot `json:"ballots,omitempty"`
+	Slots   []struct {
+		ID       string    `json:"id"`
+		Value    string    `json:"value"`
+		Slot     *TimeSlot `json:"slot"`
+		Yes      int       `json:"yes"`
+		IfNeedBe int       `json:"if_need_be"`
+		No       int       `json:"no"`
+	} `json:"slots,omitempty"`
+	Rows []struct {
+		ID           string `json:"id"`
+		Value        string `json:"value"`
+		Position     int    `json:"position"`
+		Distribution []struct {
+			Label string `json:"label"`
+			Value int    `json:"value"`
+			Count int    `json:"count"`
+		} `json:"distribution"`
+		Responses int     `json:"responses"`
+		Mean      float64 `json:"mean"`
+		Median    float64 `json:"median"`
+		StdDev    float64 `json:"std_dev"`
+	} `json:"rows,omitempty"`
+	Responses []struct {
+		Value string `json:"value"`
+		Count int    `json:"count"`
+	} `json:"responses,omitempty"`
+}
+
+type Turnout struct {
+	Ballots     int        `json:"ballots"`
+	DistinctIPs int        `json:"distinct_ips"`
+	LastVoteAt  *time.Time `json:"last_vote_at"`
+}
+
+type Ballot struct {
+	Voter    string    `json:"voter"`
+	OptionID string    `json:"option_id"`
+	VotedAt  time.Time `json:"voted_at"`
+}
+
+type LeaderboardEntry struct {
+	Voter    string `json:"voter"`
+	Score    int    `json:"score"`
+	Answered int    `json:"answered"`
+}
+
+type PollResponse struct {
+	ID         string    `json:"id"`
+	Value      string    `json:"value"`
+	Status     string    `json:"status"`
+	MergedInto string    `json:"merged_into,omitempty"`
+	OptionID   string    `json:"option_id,omitempty"`
+	Count      int       `json:"count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Revision struct {
+	ID      string `json:"id"`
+	Action  string `json:"action"`
+	Actor   string `json:"actor"`
+	Changes []struct {
+		Field  string `json:"field"`
+		Before any    `json:"before"`
+		After  any    `json:"after"`
+	} `json:"changes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Token is a token for one poll. Plaintext is only set by CreateToken.
+type Token struct {
+	ID         string     `json:"id"`
+	Label      string     `json:"label"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Plaintext  string     `json:"token,omitempty"`
+}
+
+// VoterCredential lets someone without an account vote on a poll that
+// requires voters to sign in. Plaintext is only set by CreateVoterCredential.
+type VoterCredential struct {
+	ID        string    `json:"id"`
+	Label     string    `json:"label"`
+	CreatedAt time.Time `json:"created_at"`
+	Plaintext string    `json:"credential,omitempty"`
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
+type User struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Issuer    string    `json:"issuer,omitempty"`
+}
+
+type Session struct {
+	Token  string    `json:"token"`
+	Expiry time.Time `json:"expiry"`
+}
+
+type Organization struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	// Role is what the signed in user can do in the organization
+	Role string `json:"role,omitempty"`
+}
+
+type OrgMember struct {
+	UserID   string    `json:"user_id"`
+	Email    string    `json:"email"`
+	Name     string    `json:"name"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+func (c *Client) Register(ctx context.Context, email, name, password string) (*User, error) {
+	input := struct {
+		Email    string `json:"email"`
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}{email, name, password}
+	var env struct {
+		User *User `json:"user"`
+	}
+	err := c.do(ctx, request{method: http.MethodPost, path: "/v1/users", body: input, dst: &env})
+	if err != nil {
+		return nil, err
+	}
+	return env.User, nil
+}
+
+// SignIn starts a session. Its token is passed to the methods for users.
+func (c *Client) SignIn(ctx context.Context, email, password string) (*Session, error) {
+	input := struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}{email, password}
+	var env struct {
+		Session *Session `json:"session"`
+	}
+	err := c.do(ctx, request{method: http.MethodPost, path: "/v1/sessions", body: input, dst: &env})
+	if err != nil {
+		return nil, err
+	}
+	return env.Session, nil
+}
+
+func (c *Client) SignOut(ctx context.Context, sessionToken string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/v1/sessions", token: sessionToken})
+}
+
+// ListUserPolls lists the polls the user created. Search is ignored.
+func (c *Client) ListUserPolls(ctx context.Context, sessionToken string, opts *ListOptions) ([]*Poll, *Metadata, error) {
+	return c.listPolls(ctx, "/v1/users/me/polls", sessionToken, opts)
+}
+
+// CreateOrganization makes the user the owner of a new organization.
+func (c *Client) CreateOrganization(ctx context.Context, sessionToken string, name string) (*Organization, error) {
+	input := struct {
+		Name string `json:"name"`
+	}{name}
+	var env struct {
+		Organization *Organization `json:"organization"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/v1/orgs",
+		token:  sessionToken,
+		body:   input,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Organization, nil
+}
+
+// ListOrganizations lists the organizations the user is a member of.
+func (c *Client) ListOrganizations(ctx context.Context, sessionToken string) ([]*Organization, error) {
+	var env struct {
+		Organizations []*Organization `json:"organizations"`
+	}
+	err := c.do(ctx, request{method: http.MethodGet, path: "/v1/orgs", token: sessionToken, dst: &env})
+	if err != nil {
+		return nil, err
+	}
+	return env.Organizations, nil
+}
+
+func (c *Client) ListOrgPolls(ctx context.Context, sessionToken string, orgID string, opts *ListOptions) ([]*Poll, *Metadata, error) {
+	if err := checkIDs(orgID); err != nil {
+		return nil, nil, err
+	}
+	return c.listPolls(ctx, pathf("/v1/orgs/%s/polls", orgID), sessionToken, opts)
+}
+
+func (c *Client) ListOrgMembers(ctx context.Context, sessionToken string, orgID string) ([]*OrgMember, error) {
+	if err := checkIDs(orgID); err != nil {
+		return nil, err
+	}
+
+	var env struct {
+		Members []*OrgMember `json:"members"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   pathf("/v1/orgs/%s/members", orgID),
+		token:  sessionToken,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Members, nil
+}
+
+// SetOrgMember adds the user whose email was verified by the identity
+// provider to the organization, or changes their role. Only owners can.
+// Password accounts are added with SetOrgMemberByID.
+func (c *Client) SetOrgMember(ctx context.Context, sessionToken string, orgID string, email string, role string) (*OrgMember, error) {
+	input := struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}{email, role}
+	return c.setOrgMember(ctx, sessionToken, orgID, input)
+}
+
+// SetOrgMemberByID adds the user to the organization, or changes their
+// role. Only owners can.
+func (c *Client) SetOrgMemberByID(ctx context.Context, sessionToken string, orgID string, userID string, role string) (*OrgMember, error) {
+	if err := checkIDs(userID); err != nil {
+		return nil, err
+	}
+
+	input := struct {
+		UserID string `json:"user_id"`
+		Role   string `json:"role"`
+	}{userID, role}
+	return c.setOrgMember(ctx, sessionToken, orgID, input)
+}
+
+func (c *Client) setOrgMember(ctx context.Context, sessionToken string, orgID string, input any) (*OrgMember, error) {
+	if err := checkIDs(orgID); err != nil {
+		return nil, err
+	}
+
+	var env struct {
+		Member *OrgMember `json:"member"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPut,
+		path:   pathf("/v1/orgs/%s/members", orgID),
+		token:  sessionToken,
+		body:   input,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Member, nil
+}
+
+func (c *Client) RemoveOrgMember(ctx context.Context, sessionToken string, orgID string, userID string) error {
+	if err := checkIDs(orgID, userID); err != nil {
+		return err
+	}
+
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   pathf("/v1/orgs/%s/members/%s", orgID, userID),
+		token:  sessionToken,
+	})
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func Test_Client_users(t *testing.T) {
+	ctx := context.Background()
+
+	c := stub(t, call{
+		method: http.MethodPost,
+		path:   "/v1/users",
+		body:   `{"email": "ana@example.com", "name": "Ana", "password": "pa55word"}`,
+	}, http.StatusCreated, `{"user": {"id": "1", "email": "ana@example.com", "name": "Ana", "created_at": "2024-01-01T12:00:00Z"}}`)
+	user, err := c.Register(ctx, "ana@example.com", "Ana", "pa55word")
+	if err != nil {
+		t.Error(err)
+	} else if user.Name != "Ana" {
+		t.Errorf("unexpected user %+v", user)
+	}
+
+	c = stub(t, call{
+		method: http.MethodPost,
+		path:   "/v1/sessions",
+		body:   `{"email": "ana@example.com", "password": "pa55word"}`,
+	}, http.StatusCreated, `{"session": {"token": "SESSION", "expiry": "2024-01-02T12:00:00Z"}}`)
+	session, err := c.SignIn(ctx, "ana@example.com", "pa55word")
+	if err != nil {
+		t.Error(err)
+	} else if session.Token != "SESSION" {
+		t.Errorf("unexpected session %+v", session)
+	}
+
+	c = stub(t, call{method: http.MethodGet, path: "/v1/users/me/polls", query: "page=2", token: "SESSION"},
+		http.StatusOK, `{"polls": [], "metadata": {}}`)
+	if _, _, err := c.ListUserPolls(ctx, "SESSION", &ListOptions{Page: 2}); err != nil {
+		t.Error(err)
+	}
+
+	c = stub(t, call{method: http.MethodDelete, path: "/v1/sessions", token: "SESSION"},
+		http.StatusOK, `{"message": "signed out"}`)
+	if err := c.SignOut(ctx, "SESSION"); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Client_organizations(t *testing.T) {
+	ctx := context.Background()
+	const org = `{"id": "2", "name": "Acme", "created_at": "2024-01-01T12:00:00Z", "role": "owner"}`
+	const member = `{"user_id": "3", "email": "bo@example.com", "name": "Bo", "role": "editor", "joined_at": "2024-01-01T12:00:00Z"}`
+
+	c := stub(t, call{method: http.MethodPost, path: "/v1/orgs", token: "SESSION", body: `{"name": "Acme"}`},
+		http.StatusCreated, `{"organization": `+org+`}`)
+	if _, err := c.CreateOrganization(ctx, "SESSION", "Acme"); err != nil {
+		t.Error(err)
+	}
+
+	c = stub(t, call{method: http.MethodGet, path: "/v1/orgs", token: "SESSION"},
+		http.StatusOK, `{"organizations": [`+org+`]}`)
+	orgs, err := c.ListOrganizations(ctx, "SESSION")
+	if err != nil {
+		t.Error(err)
+	} else if len(orgs) != 1 || orgs[0].Role != OrgRoleOwner {
+		t.Errorf("unexpected organizations %+v", orgs)
+	}
+
+	c = stub(t, call{method: http.MethodGet, path: "/v1/orgs/2/polls", query: "search=lunch", token: "SESSION"},
+		http.StatusOK, `{"polls": [], "metadata": {}}`)
+	if _, _, err := c.ListOrgPolls(ctx, "SESSION", "2", &ListOptions{Search: "lunch"}); err != nil {
+		t.Error(err)
+	}
+
+	c = stub(t, call{
+		method: http.MethodPut,
+		path:   "/v1/orgs/2/members",
+		token:  "SESSION",
+		body:   `{"email": "bo@example.com", "role": "editor"}`,
+	}, http.StatusOK, `{"member": `+member+`}`)
+	if _, err := c.SetOrgMember(ctx, "SESSION", "2", "bo@example.com", OrgRoleEditor); err != nil {
+		t.Error(err)
+	}
+
+	c = stub(t, call{
+		method: http.MethodPut,
+		path:   "/v1/orgs/2/members",
+		token:  "SESSION",
+		body:   `{"user_id": "3", "role": "viewer"}`,
+	}, http.StatusOK, `{"member": `+member+`}`)
+	if _, err := c.SetOrgMemberByID(ctx, "SESSION", "2", "3", OrgRoleViewer); err != nil {
+		t.Error(err)
+	}
+
+	c = stub(t, call{method: http.MethodGet, path: "/v1/orgs/2/members", token: "SESSION"},
+		http.StatusOK, `{"members": [`+member+`]}`)
+	members, err := c.ListOrgMembers(ctx, "SESSION", "2")
+	if err != nil {
+		t.Error(err)
+	} else if len(members) != 1 || members[0].Role != OrgRoleEditor {
+		t.Errorf("unexpected members %+v", members)
+	}
+
+	c = stub(t, call{method: http.MethodDelete, path: "/v1/orgs/2/members/3", token: "SESSION"},
+		http.StatusOK, `{"message": "member removed"}`)
+	if err := c.RemoveOrgMember(ctx, "SESSION", "2", "3"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Answers of a schedule poll.
+const (
+	AvailabilityYes      = "yes"
+	AvailabilityIfNeedBe = "if_need_be"
+	AvailabilityNo       = "no"
+)
+
+// The methods voters call take a token that may be empty. Polls requiring
+// voters to sign in need a session token or a voter credential.
+
+// Vote casts the voter's ballot. Correct is only set for quizzes.
+func (c *Client) Vote(ctx context.Context, token string, pollID string, optionID string, voter string) (correct *bool, err error) {
+	if err := checkIDs(pollID, optionID); err != nil {
+		return nil, err
+	}
+
+	input := struct {
+		Voter string `json:"voter"`
+	}{voter}
+	var env struct {
+		Correct *bool `json:"correct"`
+	}
+	err = c.do(ctx, request{
+		method: http.MethodPost,
+		path:   pathf("/v1/polls/%s/options/%s", pollID, optionID),
+		token:  token,
+		body:   input,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Correct, nil
+}
+
+// SubmitResponse answers an open text poll.
+func (c *Client) SubmitResponse(ctx context.Context, token string, pollID string, value string) error {
+	if err := checkIDs(pollID); err != nil {
+		return err
+	}
+
+	input := struct {
+		Value string `json:"value"`
+	}{value}
+	return c.do(ctx, request{
+		method: http.MethodPost,
+		path:   pathf("/v1/polls/%s/responses", pollID),
+		token:  token,
+		body:   input,
+	})
+}
+
+// WriteIn votes for an option not on the poll, adding it unless an option
+// with the same value exists.
+func (c *Client) WriteIn(ctx context.Context, token string, pollID string, value string) (*PollOption, error) {
+	if err := checkIDs(pollID); err != nil {
+		return nil, err
+	}
+
+	input := struct {
+		Value string `json:"value"`
+	}{value}
+	var env struct {
+		Option *PollOption `json:"option"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   pathf("/v1/polls/%s/write-ins", pollID),
+		token:  token,
+		body:   input,
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Option, nil
+}
+
+// SubmitAvailability answers a schedule poll, mapping option IDs to one of
+// the Availability answers.
+func (c *Client) SubmitAvailability(ctx context.Context, token string, pollID string, voter string, answers map[string]string) error {
+	if err := checkIDs(pollID); err != nil {
+		return err
+	}
+
+	type answer struct {
+		OptionID string `json:"option_id"`
+		Answer   string `json:"answer"`
+	}
+	input := struct {
+		Voter   string   `json:"voter"`
+		Answers []answer `json:"answers"`
+	}{Voter: voter}
+	for optionID, a := range answers {
+		input.Answers = append(input.Answers, answer{optionID, a})
+	}
+	return c.do(ctx, request{
+		method: http.MethodPost,
+		path:   pathf("/v1/polls/%s/availability", pollID),
+		token:  token,
+		body:   input,
+	})
+}
+
+// SubmitRatings answers a matrix poll, mapping option IDs to a point on the
+// poll's scale, starting at 1.
+func (c *Client) SubmitRatings(ctx context.Context, token string, pollID string, ratings map[string]int) error {
+	if err := checkIDs(pollID); err != nil {
+		return err
+	}
+
+	type rating struct {
+		OptionID string `json:"option_id"`
+		Value    int    `json:"value"`
+	}
+	input := struct {
+		Ratings []rating `json:"ratings"`
+	}{}
+	for optionID, value := range ratings {
+		input.Ratings = append(input.Ratings, rating{optionID, value})
+	}
+	return c.do(ctx, request{
+		method: http.MethodPost,
+		path:   pathf("/v1/polls/%s/ratings", pollID),
+		token:  token,
+		body:   input,
+	})
+}
+
+// Results returns the poll's results, if its visibility allows the caller
+// to see them.
+func (c *Client) Results(ctx context.Context, pollID string, opts *ReadOptions) (*Results, error) {
+	if err := checkIDs(pollID); err != nil {
+		return nil, err
+	}
+
+	var results Results
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   pathf("/v1/polls/%s/results", pollID),
+		query:  opts.query(),
+		token:  opts.token(),
+		dst:    &results,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+// Leaderboard ranks the voters of a quiz series by their score. The series
+// ID comes with the series' polls as SeriesID.
+func (c *Client) Leaderboard(ctx context.Context, seriesID string) ([]*LeaderboardEntry, error) {
+	if err := checkIDs(seriesID); err != nil {
+		return nil, err
+	}
+
+	var env struct {
+		Leaderboard []*LeaderboardEntry `json:"leaderboard"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   pathf("/v1/quizzes/%s/leaderboard", seriesID),
+		dst:    &env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env.Leaderboard, nil
+}
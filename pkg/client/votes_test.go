@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func Test_Client_Vote(t *testing.T) {
+	tests := []struct {
+		name        string
+		token       string
+		response    string
+		wantCorrect *bool
+	}{
+		{"anonymous", "", `{"message": "you have voted successfully"}`, nil},
+		{"quiz", "credential", `{"message": "you have voted successfully", "correct": true}`, new(bool)},
+	}
+	*tests[1].wantCorrect = true
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := stub(t, call{
+				method: http.MethodPost,
+				path:   "/v1/polls/1/options/2",
+				token:  tt.token,
+				body:   `{"voter": "ana"}`,
+			}, http.StatusOK, tt.response)
+
+			correct, err := c.Vote(context.Background(), tt.token, "1", "2", "ana")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (correct == nil) != (tt.wantCorrect == nil) || (correct != nil && *correct != *tt.wantCorrect) {
+				t.Errorf("expected correct %v, but got %v", tt.wantCorrect, correct)
+			}
+		})
+	}
+}
+
+func Test_Client_answers(t *testing.T) {
+	ctx := context.Background()
+
+	c := stub(t, call{method: http.MethodPost, path: "/v1/polls/1/responses", body: `{"value": "Blue"}`},
+		http.StatusCreated, `{"message": "response submitted"}`)
+	if err := c.SubmitResponse(ctx, "", "1", "Blue"); err != nil {
+		t.Error(err)
+	}
+
+	c = stub(t, call{method: http.MethodPost, path: "/v1/polls/1/write-ins", body: `{"value": "Green"}`},
+		http.StatusCreated, `{"message": "you have voted successfully", "option": {"id": "3", "value": "Green", "position": 2}}`)
+	option, err := c.WriteIn(ctx, "", "1", "Green")
+	if err != nil {
+		t.Error(err)
+	} else if option.ID != "3" {
+		t.Errorf("expected option 3, but got %+v", option)
+	}
+
+	c = stub(t, call{
+		method: http.MethodPost,
+		path:   "/v1/polls/1/availability",
+		body:   `{"voter": "ana", "answers": [{"option_id": "2", "answer": "if_need_be"}]}`,
+	}, http.StatusOK, `{"message": "availability submitted"}`)
+	if err := c.SubmitAvailability(ctx, "", "1", "ana", map[string]string{"2": AvailabilityIfNeedBe}); err != nil {
+		t.Error(err)
+	}
+
+	c = stub(t, call{
+		method: http.MethodPost,
+		path:   "/v1/polls/1/ratings",
+		token:  "session",
+		body:   `{"ratings": [{"option_id": "2", "value": 4}]}`,
+	}, http.StatusOK, `{"message": "ratings submitted"}`)
+	if err := c.SubmitRatings(ctx, "session", "1", map[string]int{"2": 4}); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Client_Results(t *testing.T) {
+	c := stub(t, call{method: http.MethodGet, path: "/v1/polls/1/results", token: "owner"}, http.StatusOK, `{
+		"results": [{"id": "2", "value": "Yes", "position": 0, "vote_count": 3}],
+		"turnout": {"ballots": 3, "distinct_ips": 2, "last_vote_at": null}
+	}`)
+
+	results, err := c.Results(context.Background(), "1", &ReadOptions{Token: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Results) != 1 || results.Results[0].VoteCount != 3 {
+		t.Errorf("unexpected results %+v", results.Results)
+	}
+	if results.Turnout == nil || results.Turnout.DistinctIPs != 2 {
+		t.Errorf("unexpected turnout %+v", results.Turnout)
+	}
+}
+
+func Test_Client_Leaderboard(t *testing.T) {
+	c := stub(t, call{method: http.MethodGet, path: "/v1/quizzes/4/leaderboard"}, http.StatusOK,
+		`{"series": {"id": "4", "name": "week 1"}, "leaderboard": [{"voter": "ana", "score": 2, "answered": 3}]}`)
+
+	leaderboard, err := c.Leaderboard(context.Background(), "4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leaderboard) != 1 || leaderboard[0].Score != 2 {
+		t.Errorf("unexpected leaderboard %+v", leaderboard)
+	}
+}